@@ -0,0 +1,541 @@
+package command
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// apiType 是从 DTO 或 swagger 注释中解析出的类型, 可以渲染为不同语言的类型
+type apiType struct {
+	Kind   string // named, builtin, slice, map, pointer, external, unknown
+	Name   string // named: DTO 类型名; builtin: Go 内置类型; external: pkg.Name
+	Import string // external 类型的导入路径
+	Elem   *apiType
+}
+
+// apiField 是 DTO 结构体中的一个字段
+type apiField struct {
+	GoName    string
+	JSONName  string
+	Type      *apiType
+	OmitEmpty bool
+	Embedded  bool
+	Example   string // swagger 的 example tag
+}
+
+// apiStruct 是 DTO 包中的一个具名类型, 非结构体类型 (如 type Status string) 使用 Underlying
+type apiStruct struct {
+	Name       string
+	Doc        string
+	Fields     []apiField
+	Underlying *apiType
+}
+
+// apiParam 是路径参数, 如 /songs/:id 中的 id
+type apiParam struct {
+	Name string
+	Type *apiType
+}
+
+// 接口响应的解析方式, 对应 pkg/response 中的不同返回函数
+const (
+	responseEnvelope = "envelope" // response.Success 等: {"code":0,"msg":"ok","data":T}
+	responseFlat     = "flat"     // response.SuccessFlat 等: T 的字段与 code/msg 平铺在同一层
+	responseFlatData = "flatData" // 平铺响应中无法展开为对象的数据 (如切片) 位于 data 字段
+	responseRaw      = "raw"      // 直接返回 T, 没有统一返回结构
+	responseNone     = "none"     // 无响应体, 如 response.NoContent
+)
+
+// apiEndpoint 是一个路由及其请求、响应类型
+type apiEndpoint struct {
+	Group      string // 资源名, 如 Song
+	Name       string // 处理函数名, 如 GetByID
+	Method     string
+	Path       string
+	Summary    string
+	Tag        string // 第一个 @Tags, 没有时为空
+	PathParams []apiParam
+	Body       *apiType
+	Result     *apiType
+	Mode       string
+}
+
+// apiGroup 是同一个 Handler 下的所有接口
+type apiGroup struct {
+	Name      string
+	Endpoints []*apiEndpoint
+}
+
+// apiModel 汇总了生成客户端和接口集合所需的全部信息
+type apiModel struct {
+	Groups []*apiGroup
+	Types  []*apiStruct // 接口中引用到的 DTO 类型, 按名称排序
+}
+
+// dtoDecl 是 DTO 包中的类型声明及其所在文件的导入
+type dtoDecl struct {
+	Spec    *ast.TypeSpec
+	Doc     *ast.CommentGroup
+	Imports map[string]string
+}
+
+var (
+	goBuiltinTypes = map[string]bool{
+		"string": true, "bool": true, "byte": true, "rune": true, "any": true,
+		"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+		"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true,
+		"float32": true, "float64": true,
+	}
+	pathParamRe = regexp.MustCompile(`:(\w+)\??`)
+)
+
+// loadAPIModel 从 router.go、handler 的 swagger 注释以及 DTO 包中提取接口信息
+func loadAPIModel() (*apiModel, error) {
+	routerPath := findRouterPath()
+	if routerPath == "" {
+		return nil, fmt.Errorf("未能找到 router.go 文件")
+	}
+	routes, err := parseRoutes(routerPath)
+	if err != nil {
+		return nil, fmt.Errorf("解析路由文件失败: %w", err)
+	}
+	handlers, err := parseHandlerFuncs(findHandlerDirs())
+	if err != nil {
+		return nil, err
+	}
+	module, _ := getProjectModule()
+	dtos, err := parseDTODecls(findDTODirs())
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(routes))
+	for key := range routes {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := routes[keys[i]], routes[keys[j]]
+		if a.Handler != b.Handler {
+			return a.Handler < b.Handler
+		}
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return httpMethodOrder(a.HTTPMethod) < httpMethodOrder(b.HTTPMethod)
+	})
+
+	model := &apiModel{}
+	groups := make(map[string]*apiGroup)
+	for _, key := range keys {
+		route := routes[key]
+		fn, ok := handlers[key]
+		if !ok {
+			fmt.Printf("   ⚠️ 未找到 %s 的处理函数, 跳过路由 %s %s\n", key, route.HTTPMethod, route.Path)
+			continue
+		}
+		endpoint := buildEndpoint(route, fn, dtos)
+		if endpoint == nil {
+			fmt.Printf("   ⏭️ %s 不是 JSON 接口 (如文件上传/下载), 跳过路由 %s %s\n", key, route.HTTPMethod, route.Path)
+			continue
+		}
+		group, ok := groups[endpoint.Group]
+		if !ok {
+			group = &apiGroup{Name: endpoint.Group}
+			groups[endpoint.Group] = group
+			model.Groups = append(model.Groups, group)
+		}
+		group.Endpoints = append(group.Endpoints, endpoint)
+	}
+
+	model.Types = collectAPITypes(model, dtos, module)
+	return model, nil
+}
+
+// httpMethodOrder 让同一路径下的接口按 CRUD 的习惯顺序排列
+func httpMethodOrder(method string) int {
+	for i, m := range []string{"POST", "GET", "PUT", "PATCH", "DELETE"} {
+		if m == method {
+			return i
+		}
+	}
+	return 10
+}
+
+func findDTODirs() []string {
+	var dirs []string
+	for _, p := range []string{"internal/adapter/dto", "internal/interfaces/dto", "internal/dto"} {
+		if _, err := os.Stat(p); err == nil {
+			dirs = append(dirs, p)
+		}
+	}
+	return dirs
+}
+
+// parseHandlerFuncs 解析 handler 目录, 返回以 "XxxHandler.Method" 为键的处理函数
+func parseHandlerFuncs(dirs []string) (map[string]*ast.FuncDecl, error) {
+	funcs := make(map[string]*ast.FuncDecl)
+	for _, dir := range dirs {
+		files, err := parseGoFiles(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			for _, decl := range file.Decls {
+				fn, ok := decl.(*ast.FuncDecl)
+				if !ok || fn.Recv == nil || len(fn.Recv.List) == 0 {
+					continue
+				}
+				recv := fn.Recv.List[0].Type
+				if star, ok := recv.(*ast.StarExpr); ok {
+					recv = star.X
+				}
+				if ident, ok := recv.(*ast.Ident); ok {
+					funcs[ident.Name+"."+fn.Name.Name] = fn
+				}
+			}
+		}
+	}
+	return funcs, nil
+}
+
+// parseDTODecls 解析 DTO 目录中的所有类型声明
+func parseDTODecls(dirs []string) (map[string]*dtoDecl, error) {
+	decls := make(map[string]*dtoDecl)
+	for _, dir := range dirs {
+		files, err := parseGoFiles(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			imports := make(map[string]string)
+			for _, imp := range file.Imports {
+				path, _ := strconv.Unquote(imp.Path.Value)
+				name := filepath.Base(path)
+				if imp.Name != nil {
+					name = imp.Name.Name
+				}
+				imports[name] = path
+			}
+			for _, decl := range file.Decls {
+				gen, ok := decl.(*ast.GenDecl)
+				if !ok || gen.Tok != token.TYPE {
+					continue
+				}
+				for _, spec := range gen.Specs {
+					ts := spec.(*ast.TypeSpec)
+					if !ts.Name.IsExported() || ts.TypeParams != nil {
+						continue
+					}
+					doc := ts.Doc
+					if doc == nil && len(gen.Specs) == 1 {
+						doc = gen.Doc
+					}
+					decls[ts.Name.Name] = &dtoDecl{Spec: ts, Doc: doc, Imports: imports}
+				}
+			}
+		}
+	}
+	return decls, nil
+}
+
+func parseGoFiles(dir string) ([]*ast.File, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+		if err != nil {
+			return nil, fmt.Errorf("解析 %s 失败: %w", path, err)
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// buildEndpoint 根据处理函数上的 swagger 注释和函数体推断请求、响应类型, 非 JSON 接口返回 nil
+func buildEndpoint(route RouteInfo, fn *ast.FuncDecl, dtos map[string]*dtoDecl) *apiEndpoint {
+	endpoint := &apiEndpoint{
+		Group:  strings.TrimSuffix(route.Handler, "Handler"),
+		Name:   route.Function,
+		Method: route.HTTPMethod,
+		Path:   route.Path,
+	}
+	if len(endpoint.Path) > 1 {
+		endpoint.Path = strings.TrimRight(endpoint.Path, "/")
+	}
+
+	paramTypes := make(map[string]string)
+	successStatus, successType := "", ""
+	if fn.Doc != nil {
+		for _, c := range fn.Doc.List {
+			line := strings.TrimSpace(strings.TrimPrefix(c.Text, "//"))
+			fields := strings.Fields(line)
+			if len(fields) < 2 {
+				continue
+			}
+			switch fields[0] {
+			case "@Summary":
+				endpoint.Summary = strings.Join(fields[1:], " ")
+			case "@Tags":
+				endpoint.Tag, _, _ = strings.Cut(fields[1], ",")
+			case "@Accept", "@Produce":
+				if fields[1] != "json" && fields[1] != "application/json" {
+					return nil
+				}
+			case "@Param":
+				if len(fields) >= 4 {
+					switch fields[2] {
+					case "path":
+						paramTypes[fields[1]] = fields[3]
+					case "body":
+						endpoint.Body = parseAnnotationType(fields[3], dtos)
+					}
+				}
+			case "@Success":
+				if successStatus == "" && len(fields) >= 4 {
+					successStatus, successType = fields[1], fields[3]
+					if fields[2] == "{array}" {
+						successType = "[]" + successType
+					}
+				}
+			}
+		}
+	}
+
+	for _, m := range pathParamRe.FindAllStringSubmatch(endpoint.Path, -1) {
+		param := apiParam{Name: m[1], Type: &apiType{Kind: "builtin", Name: "string"}}
+		if t, ok := paramTypes[m[1]]; ok && goBuiltinTypes[t] {
+			param.Type = &apiType{Kind: "builtin", Name: t}
+		}
+		endpoint.PathParams = append(endpoint.PathParams, param)
+	}
+
+	responseFuncs := make(map[string]bool)
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if pkg, ok := sel.X.(*ast.Ident); ok && pkg.Name == "response" {
+				responseFuncs[sel.Sel.Name] = true
+			}
+		}
+		return true
+	})
+	if endpoint.Body == nil {
+		endpoint.Body = boundRequestType(fn, dtos)
+	}
+
+	// 统一返回结构中的 data 类型, 如 response.Response{data=[]dto.SongResponse}
+	if inner, ok := strings.CutPrefix(successType, "response.Response{data="); ok {
+		successType = strings.TrimSuffix(inner, "}")
+	} else if strings.HasPrefix(successType, "response.Response") {
+		successType = ""
+	}
+	if successType != "" && successType != "nil" {
+		endpoint.Result = parseAnnotationType(successType, dtos)
+	}
+
+	switch {
+	case successStatus == "204" || (responseFuncs["NoContent"] && len(responseFuncs) == 1):
+		endpoint.Mode, endpoint.Result = responseNone, nil
+	case responseFuncs["SuccessFlat"] || responseFuncs["CreatedFlat"] || responseFuncs["JSONFlat"]:
+		endpoint.Mode = responseFlat
+		if endpoint.Result != nil && endpoint.Result.Kind != "named" {
+			endpoint.Mode = responseFlatData
+		}
+	case len(responseFuncs) > 0:
+		endpoint.Mode = responseEnvelope
+	default:
+		endpoint.Mode = responseRaw
+	}
+	if endpoint.Result == nil && endpoint.Mode != responseNone {
+		endpoint.Result = &apiType{Kind: "unknown"}
+	}
+	return endpoint
+}
+
+// boundRequestType 在缺少 @Param body 注释时, 从 ctx.Bind().JSON(&req) 推断请求体类型
+func boundRequestType(fn *ast.FuncDecl, dtos map[string]*dtoDecl) *apiType {
+	vars := make(map[string]ast.Expr)
+	var bound string
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.ValueSpec:
+			for _, name := range n.Names {
+				if n.Type != nil {
+					vars[name.Name] = n.Type
+				}
+			}
+		case *ast.CallExpr:
+			sel, ok := n.Fun.(*ast.SelectorExpr)
+			if !ok || (sel.Sel.Name != "JSON" && sel.Sel.Name != "Body") || len(n.Args) != 1 {
+				return true
+			}
+			if inner, ok := sel.X.(*ast.CallExpr); ok {
+				if bindSel, ok := inner.Fun.(*ast.SelectorExpr); ok && bindSel.Sel.Name == "Bind" {
+					if unary, ok := n.Args[0].(*ast.UnaryExpr); ok && unary.Op == token.AND {
+						if ident, ok := unary.X.(*ast.Ident); ok {
+							bound = ident.Name
+						}
+					}
+				}
+			}
+		}
+		return true
+	})
+	if expr, ok := vars[bound]; ok {
+		return toAPIType(expr, nil, dtos)
+	}
+	return nil
+}
+
+// parseAnnotationType 解析 swagger 注释中的类型, 如 dto.SongResponse 或 []dto.SongResponse
+func parseAnnotationType(s string, dtos map[string]*dtoDecl) *apiType {
+	expr, err := parser.ParseExpr(s)
+	if err != nil {
+		return &apiType{Kind: "unknown"}
+	}
+	return toAPIType(expr, map[string]string{"time": "time"}, dtos)
+}
+
+// toAPIType 将 Go 类型表达式转换为 apiType, imports 为类型所在文件的导入
+func toAPIType(expr ast.Expr, imports map[string]string, dtos map[string]*dtoDecl) *apiType {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if goBuiltinTypes[t.Name] {
+			return &apiType{Kind: "builtin", Name: t.Name}
+		}
+		if _, ok := dtos[t.Name]; ok {
+			return &apiType{Kind: "named", Name: t.Name}
+		}
+	case *ast.SelectorExpr:
+		pkg, ok := t.X.(*ast.Ident)
+		if !ok {
+			break
+		}
+		if _, isDTO := dtos[t.Sel.Name]; pkg.Name == "dto" && isDTO {
+			return &apiType{Kind: "named", Name: t.Sel.Name}
+		}
+		if path, ok := imports[pkg.Name]; ok {
+			return &apiType{Kind: "external", Name: pkg.Name + "." + t.Sel.Name, Import: path}
+		}
+	case *ast.StarExpr:
+		return &apiType{Kind: "pointer", Elem: toAPIType(t.X, imports, dtos)}
+	case *ast.ArrayType:
+		if t.Len != nil {
+			break
+		}
+		if ident, ok := t.Elt.(*ast.Ident); ok && ident.Name == "byte" {
+			return &apiType{Kind: "builtin", Name: "[]byte"}
+		}
+		return &apiType{Kind: "slice", Elem: toAPIType(t.Elt, imports, dtos)}
+	case *ast.MapType:
+		return &apiType{Kind: "map", Elem: toAPIType(t.Value, imports, dtos)}
+	case *ast.InterfaceType:
+		return &apiType{Kind: "builtin", Name: "any"}
+	}
+	return &apiType{Kind: "unknown"}
+}
+
+// collectAPITypes 收集接口直接或间接引用的所有 DTO 类型
+func collectAPITypes(model *apiModel, dtos map[string]*dtoDecl, module string) []*apiStruct {
+	seen := make(map[string]*apiStruct)
+	var visit func(t *apiType)
+	visit = func(t *apiType) {
+		for ; t != nil; t = t.Elem {
+			if t.Kind == "external" && module != "" && strings.HasPrefix(t.Import, module+"/") {
+				// 项目内部的其他包对客户端不可见
+				t.Kind, t.Name, t.Import = "unknown", "", ""
+			}
+			if t.Kind != "named" || seen[t.Name] != nil {
+				continue
+			}
+			s := buildAPIStruct(dtos[t.Name], dtos)
+			seen[t.Name] = s
+			for _, f := range s.Fields {
+				visit(f.Type)
+			}
+			visit(s.Underlying)
+		}
+	}
+	for _, g := range model.Groups {
+		for _, e := range g.Endpoints {
+			visit(e.Body)
+			visit(e.Result)
+		}
+	}
+
+	types := make([]*apiStruct, 0, len(seen))
+	for _, s := range seen {
+		types = append(types, s)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i].Name < types[j].Name })
+	return types
+}
+
+func buildAPIStruct(decl *dtoDecl, dtos map[string]*dtoDecl) *apiStruct {
+	s := &apiStruct{Name: decl.Spec.Name.Name}
+	if decl.Doc != nil {
+		s.Doc = strings.TrimSpace(decl.Doc.Text())
+	}
+	st, ok := decl.Spec.Type.(*ast.StructType)
+	if !ok {
+		s.Underlying = toAPIType(decl.Spec.Type, decl.Imports, dtos)
+		return s
+	}
+	for _, field := range st.Fields.List {
+		var tag reflect.StructTag
+		if field.Tag != nil {
+			value, _ := strconv.Unquote(field.Tag.Value)
+			tag = reflect.StructTag(value)
+		}
+		jsonName, opts, _ := strings.Cut(tag.Get("json"), ",")
+		if jsonName == "-" {
+			continue
+		}
+		typ := toAPIType(field.Type, decl.Imports, dtos)
+		omit := strings.Contains(opts, "omitempty")
+		if len(field.Names) == 0 {
+			name := embeddedFieldName(field.Type)
+			s.Fields = append(s.Fields, apiField{GoName: name, JSONName: jsonName, Type: typ, OmitEmpty: omit, Embedded: jsonName == ""})
+			continue
+		}
+		for _, ident := range field.Names {
+			if !ident.IsExported() {
+				continue
+			}
+			name := jsonName
+			if name == "" {
+				name = ident.Name
+			}
+			s.Fields = append(s.Fields, apiField{GoName: ident.Name, JSONName: name, Type: typ, OmitEmpty: omit, Example: tag.Get("example")})
+		}
+	}
+	return s
+}
+
+// embeddedFieldName 返回嵌入字段的字段名, 即类型名去掉指针和包名
+func embeddedFieldName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return embeddedFieldName(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
@@ -0,0 +1,69 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+// generateAudit 控制 generate 是否为实体记录变更历史并生成历史查询接口
+var generateAudit bool
+
+const (
+	// createdByField 和 updatedByField 是记录操作者的字段名, 实体包含它们时服务自动填入当前用户
+	createdByField = "CreatedBy"
+	updatedByField = "UpdatedBy"
+)
+
+// auditRoutes 是变更历史的路由, 路径相对于实体的路由前缀
+var auditRoutes = []crudRoute{{Name: "history", Method: "Get", Path: "/:id/history", Handler: "History"}}
+
+// checkAuthorFieldsSupported 检查 CreatedBy/UpdatedBy 能否由服务自动填充: 当前用户从请求上下文读取, 目前只有 DDD 项目的服务接收 context。
+// 无法自动填充时这两个字段会被当作普通字段通过请求 DTO 写入, 调用方可以伪造操作者, 因此返回错误由调用方跳过该实体
+func checkAuthorFieldsSupported(info *EntityInfo, paths PathConfig) error {
+	if !paths.IsDDD {
+		return fmt.Errorf("自动填充 %s/%s 目前只支持 DDD 项目 (标准项目的服务不接收 context), 已跳过 %s; 请重命名这些字段以便在服务中自行赋值, 或改用 DDD 项目", createdByField, updatedByField, info.EntityName)
+	}
+	for _, f := range []*FieldInfo{info.CreatedBy, info.UpdatedBy} {
+		if f != nil && !idFieldTypes[f.Type] {
+			return fmt.Errorf("%s.%s 的类型 %s 不受支持 (可用 string 或整数类型), 已跳过 %s", info.EntityName, f.Name, f.Type, info.EntityName)
+		}
+	}
+	if info.Skips("service") {
+		return fmt.Errorf("操作者字段由生成的 Service 填充, //gps:skip=service 时无法自动填充, 已跳过 %s", info.EntityName)
+	}
+	return nil
+}
+
+// checkAuditSupported 检查实体能否记录变更历史: 历史接口按主键类型解析路径参数, 并且依赖生成的 Repository 和 Service
+func checkAuditSupported(info *EntityInfo, paths PathConfig) error {
+	if !paths.IsDDD {
+		return fmt.Errorf("变更历史目前只支持 DDD 项目, 已忽略 %s 的 --audit", info.EntityName)
+	}
+	if !idFieldTypes[info.PrimaryKey.Type] {
+		return fmt.Errorf("%s 的主键类型 %s 不受支持 (可用 string 或整数类型), 已忽略 --audit", info.EntityName, info.PrimaryKey.Type)
+	}
+	if info.Skips("repository") || info.Skips("service") {
+		return fmt.Errorf("变更历史依赖生成的 Repository 和 Service, 已跳过 %s 的变更历史", info.EntityName)
+	}
+	return nil
+}
+
+// generateAuditAPI 为实体生成变更历史处理器, 注册到 DI 容器并挂载 <路由前缀>/:id/history
+func generateAuditAPI(info *EntityInfo, paths PathConfig) error {
+	renderTask(FileGenerationTask{TemplatePath: "tmpl/generate/audit/handler.go.ddd.tmpl", OutputDir: strings.TrimPrefix(paths.HandlerPackagePath, "/"), Suffix: "_history_handler", Layer: "handler"}, info.EntityName, info)
+
+	importPath := info.ProjectModule + paths.HandlerPackagePath
+	handlerName := info.EntityName + "HistoryHandler"
+	if err := addExtraProvidersToDI(paths, importPath,
+		diProvider{Comment: info.EntityName + " History Handler", Expr: "handler.New" + handlerName},
+	); err != nil {
+		return fmt.Errorf("自动修改 %s 失败: %w", paths.DIFile, err)
+	}
+	if err := injectRouterDependency(paths.RouterFile, handlerName, "*handler."+handlerName, importPath); err != nil {
+		return fmt.Errorf("自动修改 %s 失败: %w", paths.RouterFile, err)
+	}
+	if err := addRoutesBeforeEntityRoutes(info, paths, "history", handlerName, auditRoutes); err != nil {
+		return fmt.Errorf("自动添加变更历史路由到 %s 失败: %w", paths.RouterFile, err)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package command
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckAuthorFieldsSupported(t *testing.T) {
+	tests := []struct {
+		name    string
+		ddd     bool
+		typ     string
+		skip    string
+		wantErr string
+	}{
+		{name: "ddd", ddd: true, typ: "string"},
+		// 无法自动填充时字段会通过请求 DTO 写入, 必须拒绝而不是静默忽略
+		{name: "standard layout", ddd: false, typ: "string", wantErr: "只支持 DDD 项目"},
+		{name: "unsupported type", ddd: true, typ: "float64", wantErr: "不受支持"},
+		{name: "skipped service", ddd: true, typ: "string", skip: "service", wantErr: "skip=service"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := &EntityInfo{EntityName: "Song", CreatedBy: &FieldInfo{Name: createdByField, Type: tt.typ}}
+			if tt.skip != "" {
+				info.SkipLayers = map[string]bool{tt.skip: true}
+			}
+			err := checkAuthorFieldsSupported(info, PathConfig{IsDDD: tt.ddd})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("checkAuthorFieldsSupported() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) || !strings.Contains(err.Error(), "已跳过 Song") {
+				t.Fatalf("checkAuthorFieldsSupported() = %v, want an error containing %q that skips the entity", err, tt.wantErr)
+			}
+		})
+	}
+}
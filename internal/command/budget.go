@@ -0,0 +1,219 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"text/tabwriter"
+
+	"github.com/Skyenought/goprojectstarter/internal/common"
+	"github.com/Skyenought/goprojectstarter/internal/llm"
+)
+
+// showBudget 为 true 时打印 gen-logic prompt 的 token 预算明细
+var showBudget bool
+
+const (
+	blockFull     = "完整"
+	blockOver     = "完整 (超出预算)"
+	blockSummary  = "声明摘要"
+	blockOmitted  = "已省略"
+	blockNotFound = "无内容"
+)
+
+// contextBlock 是 prompt 中一段可以按预算裁剪的上下文, content 指向 LogicAdditionInfo 中的字段
+type contextBlock struct {
+	label     string
+	content   *string
+	required  bool // 目标方法即使超出预算也必须保留
+	summarize bool // 超出预算时可以精简为只包含声明的摘要
+	tokens    int  // 原始内容的 token 数
+	used      int  // 实际放入 prompt 的 token 数
+	status    string
+}
+
+// budgetReport 记录一次预算分配的结果
+type budgetReport struct {
+	budget common.PromptBudget
+	base   int // 不含可裁剪上下文的 prompt (规则、用户目标、输出格式) 的 token 数
+	total  int // 最终 prompt 的 token 数, 包括省略说明
+	blocks []*contextBlock
+}
+
+// logicContextBlocks 按优先级返回 gen-logic 的上下文: 目标方法、参考方法、仓库接口签名、检索到的相关声明, 最后是完整的实体和映射器文件
+func logicContextBlocks(info *LogicAdditionInfo) []*contextBlock {
+	blocks := []*contextBlock{
+		{label: "目标方法 " + info.HandlerStructName + "." + info.MethodName, content: &info.ExistingHandlerCode, required: true},
+		{label: "目标方法 " + info.ServiceImplStructName + "." + info.MethodName, content: &info.ExistingServiceCode, required: true},
+		{label: "目标方法 " + info.RepoImplStructName + "." + info.MethodName, content: &info.ExistingRepoCode, required: true},
+		{label: "参考方法 " + info.HandlerStructName + "." + info.ExampleMethodName, content: &info.ExampleHandlerCode},
+		{label: "参考方法 " + info.ServiceImplStructName + "." + info.ExampleMethodName, content: &info.ExampleServiceCode},
+		{label: "参考方法 " + info.RepoImplStructName + "." + info.ExampleMethodName, content: &info.ExampleRepoCode},
+		{label: "仓库接口 " + info.RepoInterfacePath, content: &info.RepoInterfaceFileContent, summarize: true},
+	}
+	for i := range info.AdditionalContexts {
+		add := &info.AdditionalContexts[i]
+		blocks = append(blocks, &contextBlock{label: "仓库接口 " + add.RepoInterfacePath, content: &add.RepoInterfaceFileContent, summarize: true})
+	}
+	for i := range info.RetrievedContext {
+		decl := &info.RetrievedContext[i]
+		blocks = append(blocks, &contextBlock{label: "相关声明 " + decl.Name, content: &decl.Code})
+	}
+	blocks = append(blocks,
+		&contextBlock{label: "实体定义 " + info.EntityPath, content: &info.EntityFileContent, summarize: true},
+		&contextBlock{label: "DTO 映射器 " + info.MapperPath, content: &info.MapperFileContent, summarize: true},
+	)
+	for i := range info.AdditionalContexts {
+		add := &info.AdditionalContexts[i]
+		blocks = append(blocks,
+			&contextBlock{label: "实体定义 " + add.EntityPath, content: &add.EntityFileContent, summarize: true},
+			&contextBlock{label: "DTO 映射器 " + add.MapperPath, content: &add.MapperFileContent, summarize: true},
+		)
+	}
+	return blocks
+}
+
+// fitLogicContextToBudget 按优先级把上下文放入 prompt 预算: 放不下的文件先精简为声明摘要, 仍然放不下则省略,
+// 并在 info.OmittedContext 中记录, 由 prompt 告知 LLM 哪些内容被精简或省略了。
+// 省略说明本身也占用预算, 因此填充后重新估算整个 prompt, 超出时为说明预留相应的 tokens 重新分配
+func fitLogicContextToBudget(info *LogicAdditionInfo, budget common.PromptBudget) (*budgetReport, error) {
+	report := &budgetReport{budget: budget, blocks: logicContextBlocks(info)}
+	estimate := func(s string) int { return llm.EstimateTokens(budget.Model, s) }
+
+	// 先清空所有可裁剪的上下文, 得到 prompt 固定部分的大小
+	originals := make([]string, len(report.blocks))
+	for i, b := range report.blocks {
+		originals[i], *b.content = *b.content, ""
+	}
+	info.OmittedContext = nil
+	base, err := buildPromptFromInfo(info)
+	if err != nil {
+		return nil, err
+	}
+	report.base = estimate(base)
+
+	reserve := 0
+	for range len(report.blocks) + 1 {
+		placed := report.allocate(info, originals, budget.Tokens-report.base-reserve, estimate)
+		prompt, err := buildPromptFromInfo(info)
+		if err != nil {
+			return nil, err
+		}
+		report.total = estimate(prompt)
+		over := report.total - budget.Tokens
+		// 没有可选的上下文可以再让出时, 超出的部分来自必须保留的目标方法
+		if over <= 0 || !placed {
+			break
+		}
+		// 至少为本次的省略说明预留空间; 说明没有变长时 (估算的误差) 按超出的部分继续增加
+		reserve = max(reserve+over, report.notice())
+	}
+	return report, nil
+}
+
+// allocate 在 remaining 个 tokens 内按优先级放入上下文, 返回是否放入了可以省略的上下文
+func (r *budgetReport) allocate(info *LogicAdditionInfo, originals []string, remaining int, estimate func(string) int) bool {
+	info.OmittedContext = nil
+	placed := false
+	for i, b := range r.blocks {
+		content := originals[i]
+		b.tokens, b.used = estimate(content), 0
+		switch {
+		case content == "":
+			b.status = blockNotFound
+			*b.content = ""
+			continue
+		case b.tokens <= remaining:
+			b.status, b.used = blockFull, b.tokens
+			placed = placed || !b.required
+		case b.required:
+			b.status, b.used = blockOver, b.tokens
+		case b.summarize:
+			if summary, err := summarizeGoSource(content); err == nil && estimate(summary) <= remaining {
+				content = summary
+				b.status, b.used = blockSummary, estimate(summary)
+				placed = true
+				info.OmittedContext = append(info.OmittedContext, b.label+": 只保留了类型和函数签名, 函数体已省略")
+				break
+			}
+			content = ""
+			b.status = blockOmitted
+			info.OmittedContext = append(info.OmittedContext, b.label+": 已整体省略")
+		default:
+			content = ""
+			b.status = blockOmitted
+			info.OmittedContext = append(info.OmittedContext, b.label+": 已整体省略")
+		}
+		*b.content = content
+		remaining -= b.used
+	}
+	return placed
+}
+
+// notice 返回最终 prompt 中不属于固定部分和上下文的 token 数, 即省略说明的大小
+func (r *budgetReport) notice() int {
+	n := r.total - r.base
+	for _, b := range r.blocks {
+		n -= b.used
+	}
+	return n
+}
+
+// print 打印预算明细
+func (r *budgetReport) print() {
+	fmt.Printf("\n📊 Prompt token 预算 (按 %s 估算, 预算 %d tokens):\n", r.budget.Model, r.budget.Tokens)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "   上下文\t原始\t使用\t状态\n")
+	fmt.Fprintf(w, "   固定部分 (规则、用户目标、输出格式)\t%d\t%d\t\n", r.base, r.base)
+	for _, b := range r.blocks {
+		if b.status == blockNotFound {
+			continue
+		}
+		fmt.Fprintf(w, "   %s\t%d\t%d\t%s\n", b.label, b.tokens, b.used, b.status)
+	}
+	if notice := r.notice(); notice > 0 {
+		fmt.Fprintf(w, "   省略的上下文说明\t%d\t%d\t\n", notice, notice)
+	}
+	fmt.Fprintf(w, "   合计\t\t%d\t/ %d\n", r.total, r.budget.Tokens)
+	w.Flush()
+}
+
+// summarizeGoSource 把 Go 源文件精简为只包含声明的摘要: 保留 import、类型、常量、变量和函数签名, 删除函数体及其中的注释
+func summarizeGoSource(src string) (string, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return "", err
+	}
+	var bodies []*ast.BlockStmt
+	for _, decl := range file.Decls {
+		if fd, ok := decl.(*ast.FuncDecl); ok && fd.Body != nil {
+			bodies = append(bodies, fd.Body)
+			fd.Body = nil
+		}
+	}
+	comments := file.Comments[:0]
+	for _, cg := range file.Comments {
+		inBody := false
+		for _, body := range bodies {
+			if cg.Pos() >= body.Pos() && cg.End() <= body.End() {
+				inBody = true
+				break
+			}
+		}
+		if !inBody {
+			comments = append(comments, cg)
+		}
+	}
+	file.Comments = comments
+
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, file); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,136 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Skyenought/goprojectstarter/internal/common"
+	"github.com/Skyenought/goprojectstarter/internal/llm"
+)
+
+const budgetEntitySrc = `package entity
+
+import "strings"
+
+// Song 是歌曲实体
+type Song struct {
+	ID    uint
+	Title string
+}
+
+// Normalize 规范化标题
+func (s *Song) Normalize() {
+	// 去掉首尾空格
+	s.Title = strings.TrimSpace(s.Title)
+}
+`
+
+func TestSummarizeGoSource(t *testing.T) {
+	got, err := summarizeGoSource(budgetEntitySrc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"type Song struct", "// Normalize 规范化标题", "func (s *Song) Normalize()", `import "strings"`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("summary is missing %q:\n%s", want, got)
+		}
+	}
+	for _, unwanted := range []string{"TrimSpace", "去掉首尾空格"} {
+		if strings.Contains(got, unwanted) {
+			t.Errorf("summary still contains %q:\n%s", unwanted, got)
+		}
+	}
+}
+
+func TestFitLogicContextToBudget(t *testing.T) {
+	newInfo := func() *LogicAdditionInfo {
+		return &LogicAdditionInfo{
+			EntityName:               "Song",
+			MethodName:               "Create",
+			HandlerStructName:        "SongHandler",
+			ServiceImplStructName:    "songServiceImpl",
+			RepoImplStructName:       "songRepositoryImpl",
+			ExistingHandlerCode:      "func (h *SongHandler) Create() {}",
+			ExistingServiceCode:      "func (s *songServiceImpl) Create() {}",
+			EntityPath:               "internal/domain/entity/song.go",
+			EntityFileContent:        budgetEntitySrc + "\nfunc (s *Song) Long() {\n" + strings.Repeat("\ts.Normalize()\n", 200) + "}\n",
+			MapperPath:               "internal/interfaces/dto/song_mapper.go",
+			MapperFileContent:        "package dto\n\n" + strings.Repeat("// 映射器的长篇说明, 精简后仍然放不下\n", 100) + "func ToSong() {}\n",
+			RepoInterfacePath:        "internal/domain/repository/song_repository.go",
+			RepoInterfaceFileContent: "package repository\n\ntype SongRepository interface {\n\tCreate() error\n}\n",
+		}
+	}
+
+	info := newInfo()
+	report, err := fitLogicContextToBudget(info, common.PromptBudget{Model: "deepseek-chat", Tokens: 100_000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info.OmittedContext) != 0 || info.MapperFileContent != newInfo().MapperFileContent {
+		t.Fatalf("a large budget should keep everything, omitted = %v", info.OmittedContext)
+	}
+
+	// 只够放下固定部分、目标方法、接口和省略说明: 实体文件被精简为声明, 映射器被省略
+	info = newInfo()
+	report, err = fitLogicContextToBudget(info, common.PromptBudget{Model: "deepseek-chat", Tokens: report.base + 300})
+	if err != nil {
+		t.Fatal(err)
+	}
+	statuses := make(map[string]string)
+	for _, b := range report.blocks {
+		statuses[b.label] = b.status
+	}
+	want := map[string]string{
+		"目标方法 SongHandler.Create":                            blockFull,
+		"仓库接口 internal/domain/repository/song_repository.go": blockFull,
+		"实体定义 internal/domain/entity/song.go":                blockSummary,
+		"DTO 映射器 internal/interfaces/dto/song_mapper.go":     blockOmitted,
+	}
+	for label, status := range want {
+		if statuses[label] != status {
+			t.Errorf("%s = %q, want %q", label, statuses[label], status)
+		}
+	}
+	if info.MapperFileContent != "" || strings.Contains(info.EntityFileContent, "TrimSpace") {
+		t.Error("omitted and summarized content should be replaced in the info")
+	}
+	if len(info.OmittedContext) != 2 {
+		t.Errorf("OmittedContext = %v, want the entity and mapper", info.OmittedContext)
+	}
+	prompt, err := buildPromptFromInfo(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(prompt, "省略的上下文") || !strings.Contains(prompt, "song_mapper.go: 已整体省略") {
+		t.Error("the prompt should tell the LLM what was omitted")
+	}
+
+	// 省略说明也计入预算: 只要预算放得下省略全部可选上下文后的最小 prompt, 最终的 prompt 都不超过预算
+	minimal, err := fitLogicContextToBudget(newInfo(), common.PromptBudget{Model: "deepseek-chat", Tokens: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for tokens := minimal.total; tokens <= minimal.total+2000; tokens += 5 {
+		info = newInfo()
+		report, err := fitLogicContextToBudget(info, common.PromptBudget{Model: "deepseek-chat", Tokens: tokens})
+		if err != nil {
+			t.Fatal(err)
+		}
+		prompt, err := buildPromptFromInfo(info)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := llm.EstimateTokens("deepseek-chat", prompt); got != report.total || got > tokens {
+			t.Errorf("budget %d: prompt has %d tokens (report %d)", tokens, got, report.total)
+		}
+	}
+
+	// 目标方法即使超出预算也必须保留
+	info = newInfo()
+	if _, err := fitLogicContextToBudget(info, common.PromptBudget{Model: "deepseek-chat", Tokens: 10}); err != nil {
+		t.Fatal(err)
+	}
+	if info.ExistingHandlerCode == "" || info.RepoInterfaceFileContent != "" {
+		t.Errorf("target methods must be kept and everything else dropped, got handler %q, interface %q", info.ExistingHandlerCode, info.RepoInterfaceFileContent)
+	}
+}
@@ -0,0 +1,64 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// generateBulk 控制 generate 是否额外生成批量创建/更新/删除接口
+var generateBulk bool
+
+const (
+	defaultBulkMaxItems  = 1000
+	defaultBulkBatchSize = 100
+)
+
+// bulkRoutes 是批量接口的路由, 路径相对于实体的路由前缀
+var bulkRoutes = []crudRoute{
+	{Name: "bulk-create", Method: "Post", Path: "/bulk", Handler: "BulkCreate"},
+	{Name: "bulk-update", Method: "Patch", Path: "/bulk", Handler: "BulkUpdate"},
+	{Name: "bulk-delete", Method: "Delete", Path: "/bulk", Handler: "BulkDelete"},
+}
+
+// renderBulkDTO 生成所有实体共享的批量结果 DTO, 只在首次生成或使用 -F 时渲染
+func renderBulkDTO(info *EntityInfo, paths PathConfig) {
+	if _, err := os.Stat(filepath.Join(paths.dtoDir(), "bulk.go")); os.IsNotExist(err) || forceGenerate {
+		renderTask(FileGenerationTask{TemplatePath: "tmpl/generate/bulk/dto.go.tmpl", OutputDir: paths.dtoDir(), FileName: "bulk", IsSingular: true, Layer: "dto"}, info.EntityName, info)
+	}
+}
+
+// generateBulkAPI 为实体生成批量处理器, 注册到 DI 容器并挂载批量路由, 同时为旧项目补充 bulk 配置
+func generateBulkAPI(info *EntityInfo, paths PathConfig) error {
+	if info.Skips("service") || info.Skips("dto") {
+		return fmt.Errorf("批量接口依赖生成的 Service 和 DTO, 已跳过 %s 的批量接口生成", info.EntityName)
+	}
+
+	handlerTemplate := "tmpl/generate/bulk/handler.go.tmpl"
+	if paths.IsDDD {
+		handlerTemplate = "tmpl/generate/bulk/handler.go.ddd.tmpl"
+	}
+	renderTask(FileGenerationTask{TemplatePath: handlerTemplate, OutputDir: strings.TrimPrefix(paths.HandlerPackagePath, "/"), Suffix: "_bulk_handler", Layer: "handler"}, info.EntityName, info)
+
+	importPath := info.ProjectModule + paths.HandlerPackagePath
+	handlerName := info.EntityName + "BulkHandler"
+	if err := addExtraProvidersToDI(paths, importPath,
+		diProvider{Comment: info.EntityName + " Bulk Handler", Expr: "handler.New" + handlerName},
+	); err != nil {
+		return fmt.Errorf("自动修改 %s 失败: %w", paths.DIFile, err)
+	}
+	if err := injectRouterDependency(paths.RouterFile, handlerName, "*handler."+handlerName, importPath); err != nil {
+		return fmt.Errorf("自动修改 %s 失败: %w", paths.RouterFile, err)
+	}
+	if err := addRoutesBeforeEntityRoutes(info, paths, "bulk", handlerName, bulkRoutes); err != nil {
+		return fmt.Errorf("自动添加批量路由到 %s 失败: %w", paths.RouterFile, err)
+	}
+	return ensureConfigSection(configSection{
+		Field:    "Bulk",
+		Type:     "BulkConfig",
+		Key:      "bulk",
+		TypeDecl: "// BulkConfig holds limits for the generated bulk endpoints.\ntype BulkConfig struct {\n\tMaxItems  int `mapstructure:\"max_items\"`\n\tBatchSize int `mapstructure:\"batch_size\"`\n}\n",
+		YAML:     fmt.Sprintf("bulk:\n  max_items: %d\n  batch_size: %d\n", defaultBulkMaxItems, defaultBulkBatchSize),
+	})
+}
@@ -0,0 +1,69 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// generateCache 控制 generate 是否为仓库额外生成缓存装饰器
+var generateCache bool
+
+const (
+	defaultCacheSize       = 10000
+	defaultCacheTTLSeconds = 300
+)
+
+// generateCacheDecorator 为实体生成仓库的缓存装饰器, 并在 DI 容器中用它替换原有的仓库构造函数,
+// 使 Service 拿到的是带缓存的仓库。所有实体共用的缓存后端只注册一次, 同时为旧项目补充 cache 配置
+func generateCacheDecorator(info *EntityInfo, paths PathConfig) error {
+	if info.Skips("repository") {
+		return fmt.Errorf("缓存装饰器依赖生成的 Repository, 已跳过 %s 的缓存装饰器生成", info.EntityName)
+	}
+
+	repoDir, pkgName := "internal/adapter/repository", "repository"
+	repoTemplate, providerTemplate := "tmpl/generate/cache/repository.go.tmpl", "tmpl/generate/cache/provider.go.tmpl"
+	if paths.IsDDD {
+		repoDir, pkgName = "internal/infrastructure/persistence", "persistence"
+		repoTemplate, providerTemplate = "tmpl/generate/cache/repository.go.ddd.tmpl", "tmpl/generate/cache/provider.go.ddd.tmpl"
+	}
+	renderTask(FileGenerationTask{TemplatePath: repoTemplate, OutputDir: repoDir, Suffix: "_repository_cache", Layer: "repository"}, info.EntityName, info)
+	if _, err := os.Stat(filepath.Join(repoDir, "cache.go")); os.IsNotExist(err) || forceGenerate {
+		renderTask(FileGenerationTask{TemplatePath: providerTemplate, OutputDir: repoDir, FileName: "cache", IsSingular: true, Layer: "repository"}, info.EntityName, info)
+	}
+
+	if err := useCachedRepository(paths, pkgName, info.EntityName); err != nil {
+		return fmt.Errorf("自动修改 %s 失败: %w", paths.DIFile, err)
+	}
+	if err := addExtraProvidersToDI(paths, info.ProjectModule+"/"+repoDir,
+		diProvider{Comment: "Repository Cache", Expr: pkgName + ".NewCache"},
+	); err != nil {
+		return fmt.Errorf("自动修改 %s 失败: %w", paths.DIFile, err)
+	}
+	return ensureConfigSection(configSection{
+		Field:    "Cache",
+		Type:     "CacheConfig",
+		Key:      "cache",
+		TypeDecl: "// CacheConfig holds settings for the generated repository caches.\ntype CacheConfig struct {\n\tDriver           string           `mapstructure:\"driver\"`\n\tSize             int              `mapstructure:\"size\"`\n\tTTLSeconds       int              `mapstructure:\"ttl_seconds\"`\n\tEntityTTLSeconds map[string]int   `mapstructure:\"entity_ttl_seconds\"`\n\tRedis            CacheRedisConfig `mapstructure:\"redis\"`\n}\n\n// CacheRedisConfig holds the Redis connection used when cache.driver is redis.\ntype CacheRedisConfig struct {\n\tAddr     string `mapstructure:\"addr\"`\n\tPassword string `mapstructure:\"password\"`\n\tDB       int    `mapstructure:\"db\"`\n}\n",
+		YAML:     fmt.Sprintf("cache:\n  driver: memory\n  size: %d\n  ttl_seconds: %d\n  entity_ttl_seconds: {}\n  redis:\n    addr: \"localhost:6379\"\n    password: \"\"\n    db: 0\n", defaultCacheSize, defaultCacheTTLSeconds),
+	})
+}
+
+// useCachedRepository 将 DI 容器中实体的仓库构造函数替换为带缓存的版本, 已替换时不做任何修改
+func useCachedRepository(paths PathConfig, pkgName, entityName string) error {
+	content, err := os.ReadFile(paths.DIFile)
+	if err != nil {
+		return err
+	}
+	plain := fmt.Sprintf("%s.New%sRepository,", pkgName, entityName)
+	cached := fmt.Sprintf("%s.NewCached%sRepository,", pkgName, entityName)
+	if strings.Contains(string(content), cached) {
+		return nil
+	}
+	if !strings.Contains(string(content), plain) {
+		return fmt.Errorf("未找到 %s 的仓库构造函数 %s", entityName, strings.TrimSuffix(plain, ","))
+	}
+	fmt.Printf("  -> Modifying %s (using %s)...\n", paths.DIFile, strings.TrimSuffix(cached, ","))
+	return os.WriteFile(paths.DIFile, []byte(strings.Replace(string(content), plain, cached, 1)), 0o644)
+}
@@ -0,0 +1,51 @@
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUseCachedRepository(t *testing.T) {
+	src := `package di
+
+func BuildContainer() {
+	providers := []interface{}{
+		// Song Providers
+		persistence.NewSongRepository,
+		service.NewSongService,
+
+		// SongLyric Providers
+		persistence.NewSongLyricRepository,
+
+		// [GENERATOR ANCHOR] - Don't remove this comment!
+	}
+}
+`
+	path := filepath.Join(t.TempDir(), "container.go")
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	paths := PathConfig{DIFile: path}
+	for i := 0; i < 2; i++ {
+		if err := useCachedRepository(paths, "persistence", "Song"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(content)
+	if strings.Contains(got, "persistence.NewSongRepository,") || strings.Count(got, "persistence.NewCachedSongRepository,") != 1 {
+		t.Errorf("Song repository provider was not replaced exactly once:\n%s", got)
+	}
+	if !strings.Contains(got, "persistence.NewSongLyricRepository,") {
+		t.Errorf("other repositories must keep their providers:\n%s", got)
+	}
+	if err := useCachedRepository(paths, "persistence", "Album"); err == nil {
+		t.Error("expected an error when the entity has no repository provider")
+	}
+}
@@ -0,0 +1,253 @@
+package command
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/Skyenought/goprojectstarter/internal/common"
+	"github.com/Skyenought/goprojectstarter/internal/llm"
+
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/imports"
+)
+
+//go:embed prompt-compile-feedback.tmpl
+var compileFeedbackPromptTemplate string
+
+// maxLLMAttempts 是 LLM 生成的代码未通过类型检查时, 连同第一次在内最多请求 LLM 的次数
+var maxLLMAttempts int
+
+// sourceOverlay 在内存中保存注入 LLM 代码后的文件内容。
+// 类型检查通过 go/packages 的 Overlay 进行, 检查通过之前不会修改磁盘上的任何文件。
+type sourceOverlay struct {
+	files map[string][]byte // 绝对路径 -> 文件内容
+}
+
+func newSourceOverlay() *sourceOverlay {
+	return &sourceOverlay{files: make(map[string][]byte)}
+}
+
+// ReadFile 优先返回覆盖层中的内容, 否则读取磁盘上的文件
+func (o *sourceOverlay) ReadFile(path string) ([]byte, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if content, ok := o.files[abs]; ok {
+		return bytes.Clone(content), nil
+	}
+	return os.ReadFile(path)
+}
+
+// WriteFile 把内容写入覆盖层
+func (o *sourceOverlay) WriteFile(path string, content []byte) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	o.files[abs] = bytes.Clone(content)
+	return nil
+}
+
+// paths 返回覆盖层中的文件, 按路径排序
+func (o *sourceOverlay) paths() []string {
+	paths := make([]string, 0, len(o.files))
+	for path := range o.files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// organizeImports 对覆盖层中的每个文件执行与 goimports 相同的处理。
+// LLM 返回的代码片段经常依赖 goimports 补全 import, 不先处理会产生大量无意义的 undefined 诊断。
+func (o *sourceOverlay) organizeImports() {
+	for _, path := range o.paths() {
+		if processed, err := imports.Process(path, o.files[path], nil); err == nil {
+			o.files[path] = processed
+		}
+	}
+}
+
+// flush 把覆盖层中的内容写入磁盘
+func (o *sourceOverlay) flush() error {
+	for _, path := range o.paths() {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return fmt.Errorf("创建目录 %s 失败: %w", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, o.files[path], 0o644); err != nil {
+			return fmt.Errorf("写入文件 %s 失败: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// diagnostic 是一条类型检查错误
+type diagnostic struct {
+	Pos string // 相对当前目录的 file:line:col, 可能为空
+	Msg string
+}
+
+func (d diagnostic) String() string {
+	if d.Pos == "" {
+		return d.Msg
+	}
+	return d.Pos + ": " + d.Msg
+}
+
+// key 用于和注入前的诊断比较: 注入代码后行号会变化, 因此只比较文件和错误信息
+func (d diagnostic) key() string {
+	file, _, _ := strings.Cut(d.Pos, ":")
+	return file + "|" + d.Msg
+}
+
+// typeCheck 在覆盖层之上加载并类型检查项目中的全部包。
+// 修改仓库或服务接口会影响到实现它们的包 (mock、缓存装饰器、DI 容器), 因此不能只检查被修改的包。
+func (o *sourceOverlay) typeCheck() ([]diagnostic, error) {
+	cfg := &packages.Config{
+		Mode:    packages.NeedName | packages.NeedFiles | packages.NeedSyntax | packages.NeedTypes | packages.NeedImports | packages.NeedDeps,
+		Overlay: o.files,
+	}
+	pkgs, err := packages.Load(cfg, "./...")
+	if err != nil {
+		return nil, fmt.Errorf("加载项目包失败: %w", err)
+	}
+	cwd, _ := os.Getwd()
+	seen := make(map[string]bool)
+	var diags []diagnostic
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		for _, e := range pkg.Errors {
+			d := diagnostic{Pos: e.Pos, Msg: e.Msg}
+			if rel, err := filepath.Rel(cwd, d.Pos); err == nil && !strings.HasPrefix(rel, "..") {
+				d.Pos = rel
+			}
+			if !seen[d.String()] {
+				seen[d.String()] = true
+				diags = append(diags, d)
+			}
+		}
+	})
+	return diags, nil
+}
+
+// newDiagnostics 过滤掉注入代码之前就已经存在的诊断, 只有 LLM 代码引入的错误才需要反馈
+func newDiagnostics(diags, baseline []diagnostic) []diagnostic {
+	existing := make(map[string]bool, len(baseline))
+	for _, d := range baseline {
+		existing[d.key()] = true
+	}
+	var fresh []diagnostic
+	for _, d := range diags {
+		if !existing[d.key()] {
+			fresh = append(fresh, d)
+		}
+	}
+	return fresh
+}
+
+// compileCheckError 表示所有尝试结束后 LLM 生成的代码仍然无法通过类型检查
+type compileCheckError struct {
+	Attempts    int
+	Diagnostics []diagnostic
+	Response    string // 最后一次的原始 LLM 响应
+}
+
+func (e *compileCheckError) Error() string {
+	return fmt.Sprintf("经过 %d 次尝试, LLM 生成的代码仍然无法通过类型检查", e.Attempts)
+}
+
+// report 打印剩余的编译诊断
+func (e *compileCheckError) report() {
+	fmt.Printf("   剩余 %d 条编译诊断:\n", len(e.Diagnostics))
+	for _, d := range e.Diagnostics {
+		fmt.Printf("     - %s\n", d)
+	}
+}
+
+// generateUntilCompiles 请求 LLM 返回符合 schema 的 JSON, 通过 apply 把响应注入到覆盖层中, 然后对项目做类型检查。
+// 如果响应不符合 schema、注入失败或引入了新的编译错误, 会把诊断和上一次的响应附加到 prompt 中重新请求, 最多 maxLLMAttempts 次。
+// 返回类型检查通过的覆盖层, 调用方负责调用 flush 写入磁盘。
+func generateUntilCompiles(prompt string, schema *llm.Schema, apply func(response string, overlay *sourceOverlay) error) (*sourceOverlay, error) {
+	attempts := max(maxLLMAttempts, 1)
+	baseline, err := newSourceOverlay().typeCheck()
+	if err != nil {
+		return nil, err
+	}
+	if len(baseline) > 0 {
+		fmt.Printf("   ℹ️ 项目在注入代码前已有 %d 条编译诊断, 检查时将忽略它们。\n", len(baseline))
+	}
+
+	currentPrompt := prompt
+	var diags []diagnostic
+	var response string
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			fmt.Printf("\n🔁 正在携带编译诊断重新请求 LLM (第 %d/%d 次)...\n", attempt, attempts)
+		}
+		response, err = common.GenStructuredWithLLM(llmProfile, currentPrompt, schema)
+		var structErr *llm.StructuredError
+		if errors.As(err, &structErr) {
+			response = structErr.Response
+		} else if errors.Is(err, common.ErrLLMCanceled) {
+			return nil, err
+		} else if err != nil {
+			return nil, fmt.Errorf("LLM API 调用失败: %w", err)
+		}
+
+		overlay := newSourceOverlay()
+		if structErr != nil {
+			diags = []diagnostic{{Msg: structErr.Error()}}
+		} else if err := apply(response, overlay); err != nil {
+			diags = []diagnostic{{Msg: err.Error()}}
+		} else {
+			overlay.organizeImports()
+			fmt.Println("   - 正在对注入后的代码进行类型检查...")
+			all, err := overlay.typeCheck()
+			if err != nil {
+				return nil, err
+			}
+			diags = newDiagnostics(all, baseline)
+		}
+		if len(diags) == 0 {
+			fmt.Printf("   ✓ 类型检查通过 (第 %d 次尝试)。\n", attempt)
+			return overlay, nil
+		}
+		fmt.Printf("   ✗ 第 %d 次尝试未通过类型检查, 共 %d 条诊断。\n", attempt, len(diags))
+
+		currentPrompt, err = buildCompileFeedbackPrompt(prompt, attempt, diags, response)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return nil, &compileCheckError{Attempts: attempts, Diagnostics: diags, Response: response}
+}
+
+// buildCompileFeedbackPrompt 在原始 prompt 之后附加编译诊断和上一次的响应
+func buildCompileFeedbackPrompt(prompt string, attempt int, diags []diagnostic, previousResponse string) (string, error) {
+	tmpl, err := template.New("compile_feedback").Parse(compileFeedbackPromptTemplate)
+	if err != nil {
+		return "", fmt.Errorf("解析编译反馈 prompt 模板失败: %w", err)
+	}
+	lines := make([]string, len(diags))
+	for i, d := range diags {
+		lines[i] = d.String()
+	}
+	var buf bytes.Buffer
+	buf.WriteString(prompt)
+	data := map[string]interface{}{
+		"Attempt":          attempt,
+		"Diagnostics":      lines,
+		"PreviousResponse": strings.TrimSpace(previousResponse),
+	}
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("渲染编译反馈 prompt 模板失败: %w", err)
+	}
+	return buf.String(), nil
+}
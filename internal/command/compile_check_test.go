@@ -0,0 +1,89 @@
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSourceOverlayTypeCheck(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":           "module example.com/app\n\ngo 1.21\n",
+		"svc/svc.go":       "package svc\n\ntype Service interface {\n\tGet() int\n}\n",
+		"svc/impl.go":      "package svc\n\ntype impl struct{}\n\nfunc (impl) Get() int { return 1 }\n\nvar _ Service = impl{}\n",
+		"broken/broken.go": "package broken\n\nvar X int = \"existing\"\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(dir)
+
+	baseline, err := newSourceOverlay().typeCheck()
+	if err != nil {
+		t.Fatalf("typeCheck() error = %v", err)
+	}
+	if len(baseline) != 1 || !strings.HasPrefix(baseline[0].Pos, filepath.Join("broken", "broken.go")) {
+		t.Fatalf("baseline = %v, want one diagnostic in broken/broken.go", baseline)
+	}
+
+	tests := []struct {
+		name     string
+		file     string
+		snippet  string
+		wantDiag string
+	}{
+		{
+			name:    "valid method with missing import",
+			file:    "svc/impl.go",
+			snippet: "\nfunc (impl) Name() string { return strings.ToUpper(\"a\") }\n",
+		},
+		{
+			name:     "call to undefined method",
+			file:     "svc/svc.go",
+			snippet:  "\nvar _ = Service(nil).Missing\n",
+			wantDiag: "Missing",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			overlay := newSourceOverlay()
+			content, err := overlay.ReadFile(tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := overlay.WriteFile(tt.file, append(content, tt.snippet...)); err != nil {
+				t.Fatal(err)
+			}
+			overlay.organizeImports()
+
+			all, err := overlay.typeCheck()
+			if err != nil {
+				t.Fatalf("typeCheck() error = %v", err)
+			}
+			diags := newDiagnostics(all, baseline)
+			if tt.wantDiag == "" {
+				if len(diags) != 0 {
+					t.Fatalf("newDiagnostics() = %v, want none", diags)
+				}
+				return
+			}
+			if len(diags) == 0 || !strings.Contains(diags[0].Msg, tt.wantDiag) {
+				t.Fatalf("newDiagnostics() = %v, want a diagnostic containing %q", diags, tt.wantDiag)
+			}
+		})
+	}
+
+	// 覆盖层只在内存中修改, 类型检查不会写入磁盘
+	onDisk, _ := os.ReadFile("svc/impl.go")
+	if string(onDisk) != files["svc/impl.go"] {
+		t.Fatalf("svc/impl.go was modified on disk:\n%s", onDisk)
+	}
+}
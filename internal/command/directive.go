@@ -0,0 +1,275 @@
+package command
+
+import (
+	"fmt"
+	"go/ast"
+	"os"
+	"sort"
+	"strings"
+)
+
+// directivePrefix 是实体文件中生成器指令注释的前缀, 写法与 go 指令一致 (// 与 gps 之间没有空格)
+const directivePrefix = "//gps:"
+
+// crudRoute 描述一条可由 //gps:routes 控制的 CRUD 路由
+type crudRoute struct {
+	Name    string // 指令中使用的名称
+	Method  string // fiber 路由方法名
+	Path    string
+	Handler string // 处理器方法名
+}
+
+// 实体可控制的 CRUD 路由及其对应的处理器方法
+var crudRoutes = []crudRoute{
+	{Name: "create", Method: "Post", Path: "/", Handler: "Create"},
+	{Name: "list", Method: "Get", Path: "/", Handler: "GetAll"},
+	{Name: "get", Method: "Get", Path: "/:id", Handler: "GetByID"},
+	{Name: "update", Method: "Put", Path: "/:id", Handler: "Update"},
+	{Name: "delete", Method: "Delete", Path: "/:id", Handler: "Delete"},
+}
+
+// 可以通过 //gps:skip 跳过的代码层
+var skippableLayers = map[string]bool{
+	"dto":        true,
+	"mapper":     true,
+	"repository": true,
+	"service":    true,
+	"handler":    true,
+	"grpc":       true,
+	"graphql":    true,
+	"tests":      true,
+}
+
+// layerDependencies 是各代码层生成的代码所依赖的其他层。跳过被依赖的层而保留依赖它的层时,
+// 生成的代码和 DI 容器无法编译
+var layerDependencies = map[string][]string{
+	"service": {"repository", "dto", "mapper"},
+	"handler": {"service", "dto"},
+	"grpc":    {"service", "dto"},
+	"graphql": {"service", "dto"},
+	"mapper":  {"dto"},
+}
+
+// parseDirectives 从注释组中提取 //gps:key=value 或 //gps:flag 形式的指令
+func parseDirectives(groups ...*ast.CommentGroup) map[string]string {
+	directives := make(map[string]string)
+	for _, group := range groups {
+		if group == nil {
+			continue
+		}
+		for _, c := range group.List {
+			if !strings.HasPrefix(c.Text, directivePrefix) {
+				continue
+			}
+			body := strings.TrimSpace(strings.TrimPrefix(c.Text, directivePrefix))
+			key, value, _ := strings.Cut(body, "=")
+			directives[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	return directives
+}
+
+// applyEntityDirectives 将结构体上的指令按名称顺序应用到实体信息上, 结果与注释的书写顺序无关
+func applyEntityDirectives(info *EntityInfo, directives map[string]string) error {
+	if _, ok := directives["routes"]; ok {
+		if _, ok := directives["readonly"]; ok {
+			return fmt.Errorf("//gps:routes 和 //gps:readonly 不能同时使用, 只读实体请写 //gps:routes=get,list")
+		}
+	}
+	keys := make([]string, 0, len(directives))
+	for key := range directives {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := directives[key]
+		switch key {
+		case "routes":
+			info.Routes = make(map[string]bool)
+			for _, name := range splitList(value) {
+				if !isCrudRoute(name) {
+					return fmt.Errorf("//gps:routes 中包含未知的路由 '%s'", name)
+				}
+				info.Routes[name] = true
+			}
+		case "readonly":
+			info.Routes = map[string]bool{"get": true, "list": true}
+		case "prefix":
+			if !strings.HasPrefix(value, "/") {
+				return fmt.Errorf("//gps:prefix 必须以 '/' 开头: %s", value)
+			}
+			info.RoutePrefix = strings.TrimSuffix(value, "/")
+		case "skip":
+			info.SkipLayers = make(map[string]bool)
+			for _, layer := range splitList(value) {
+				if !skippableLayers[layer] {
+					return fmt.Errorf("//gps:skip 中包含未知的代码层 '%s'", layer)
+				}
+				info.SkipLayers[layer] = true
+			}
+			if err := checkSkipLayers(info.SkipLayers); err != nil {
+				return err
+			}
+		case "bulk":
+			info.Bulk = true
+		case "export":
+			info.Export = true
+		case "cache":
+			info.Cache = true
+		case "events":
+			info.Events = true
+		case "audit":
+			info.Audit = true
+		case "table":
+			if value == "" {
+				return fmt.Errorf("//gps:table 不能为空")
+			}
+			info.TableName = value
+			info.TableFromDirective = true
+		default:
+			return fmt.Errorf("未知的实体指令 //gps:%s", key)
+		}
+	}
+	return nil
+}
+
+// checkSkipLayers 检查被跳过的层是否仍被保留的层依赖
+func checkSkipLayers(skip map[string]bool) error {
+	layers := make([]string, 0, len(layerDependencies))
+	for layer := range layerDependencies {
+		layers = append(layers, layer)
+	}
+	sort.Strings(layers)
+	for _, layer := range layers {
+		if skip[layer] {
+			continue
+		}
+		for _, dep := range layerDependencies[layer] {
+			if skip[dep] {
+				return fmt.Errorf("//gps:skip 跳过了 %s, 但生成的 %s 依赖它; 请同时跳过 %s, 或者不要跳过 %s", dep, layer, layer, dep)
+			}
+		}
+	}
+	return nil
+}
+
+// applyFieldDirectives 将字段上的指令应用到字段信息上
+func applyFieldDirectives(field *FieldInfo, directives map[string]string) error {
+	for key := range directives {
+		switch key {
+		case "hidden":
+			field.Hidden = true
+		case "readonly":
+			field.ReadOnly = true
+		default:
+			return fmt.Errorf("字段 %s 上有未知的指令 //gps:%s", field.Name, key)
+		}
+	}
+	return nil
+}
+
+// printEffectiveSettings 输出实体最终生效的生成设置, 便于确认指令是否按预期生效
+func printEffectiveSettings(info *EntityInfo) {
+	var routes []string
+	for _, r := range crudRoutes {
+		if info.HasRoute(r.Name) {
+			routes = append(routes, r.Name)
+		}
+	}
+	var skipped, hidden, readonly []string
+	for layer := range info.SkipLayers {
+		skipped = append(skipped, layer)
+	}
+	sort.Strings(skipped)
+	for _, f := range info.Fields {
+		if f.Hidden {
+			hidden = append(hidden, f.Name)
+		}
+		if f.ReadOnly {
+			readonly = append(readonly, f.Name)
+		}
+	}
+
+	fmt.Println("   ⚙️ 生效的生成设置:")
+	fmt.Printf("      - 表名: %s\n", info.TableName)
+	fmt.Printf("      - 路由前缀: /api/v1%s\n", info.RoutePath())
+	fmt.Printf("      - 启用的路由: %s\n", joinOrNone(routes))
+	fmt.Printf("      - 跳过的代码层: %s\n", joinOrNone(skipped))
+	fmt.Printf("      - 隐藏字段: %s\n", joinOrNone(hidden))
+	fmt.Printf("      - 只读字段: %s\n", joinOrNone(readonly))
+	if info.Bulk {
+		fmt.Printf("      - 批量接口: /api/v1%s/bulk\n", info.RoutePath())
+	}
+	if info.Export {
+		fmt.Printf("      - 导出/导入接口: /api/v1%s/export, /api/v1%s/import\n", info.RoutePath(), info.RoutePath())
+	}
+	if info.Cache {
+		fmt.Println("      - 仓库缓存: FindByID")
+	}
+	if info.Tenant != nil {
+		fmt.Printf("      - 多租户: 按 %s 隔离\n", info.Tenant.Name)
+	}
+	if info.HasAuthorFields() {
+		var fields []string
+		for _, f := range []*FieldInfo{info.CreatedBy, info.UpdatedBy} {
+			if f != nil {
+				fields = append(fields, f.Name)
+			}
+		}
+		fmt.Printf("      - 操作者字段: %s\n", strings.Join(fields, ", "))
+	}
+	if info.Audit {
+		fmt.Printf("      - 变更历史: audit_logs, /api/v1%s/:id/history\n", info.RoutePath())
+	}
+	if info.Events {
+		fmt.Printf("      - 领域事件: %sCreated, %sUpdated, %sDeleted\n", info.EntityName, info.EntityName, info.EntityName)
+	}
+}
+
+// ensureTableNameMethod 在实体通过 //gps:table 指定表名但缺少 TableName() 方法时为其补上,
+// 保证 GORM 实际使用的表名与指令一致
+func ensureTableNameMethod(filePath string, info *EntityInfo) error {
+	if !info.TableFromDirective || info.HasTableNameMethod {
+		return nil
+	}
+	f, err := os.OpenFile(filePath, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("无法打开实体文件 %s: %w", filePath, err)
+	}
+	defer f.Close()
+
+	method := fmt.Sprintf("\n// TableName 指定 %s 对应的数据库表名\nfunc (%s) TableName() string {\n\treturn %q\n}\n",
+		info.EntityName, info.EntityName, info.TableName)
+	if _, err := f.WriteString(method); err != nil {
+		return fmt.Errorf("写入 TableName 方法失败: %w", err)
+	}
+	info.HasTableNameMethod = true
+	fmt.Printf("   + 已为 %s 添加 TableName() 方法 (表名: %s)\n", info.EntityName, info.TableName)
+	return nil
+}
+
+func isCrudRoute(name string) bool {
+	for _, r := range crudRoutes {
+		if r.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func joinOrNone(items []string) string {
+	if len(items) == 0 {
+		return "(无)"
+	}
+	return strings.Join(items, ", ")
+}
@@ -0,0 +1,157 @@
+package command
+
+import (
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func TestParseDirectives(t *testing.T) {
+	src := `package entity
+
+// Song 是歌曲
+//gps:routes = get, list
+//gps:prefix=/admin/songs
+//gps:bulk
+// gps:ignored 前面有空格, 不是指令
+type Song struct {
+	ID uint
+	//gps:hidden
+	//gps:readonly
+	Secret string
+}
+`
+	node, err := parser.ParseFile(token.NewFileSet(), "song.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gen := node.Decls[0].(*ast.GenDecl)
+	got := parseDirectives(gen.Doc)
+	if len(got) != 3 || got["routes"] != "get, list" || got["prefix"] != "/admin/songs" || got["bulk"] != "" {
+		t.Errorf("parseDirectives(struct doc) = %v", got)
+	}
+
+	field := gen.Specs[0].(*ast.TypeSpec).Type.(*ast.StructType).Fields.List[1]
+	info := FieldInfo{Name: "Secret"}
+	if err := applyFieldDirectives(&info, parseDirectives(field.Doc)); err != nil || !info.Hidden || !info.ReadOnly {
+		t.Errorf("applyFieldDirectives() = %+v, %v", info, err)
+	}
+}
+
+func TestApplyEntityDirectives(t *testing.T) {
+	tests := []struct {
+		name       string
+		directives map[string]string
+		check      func(*EntityInfo) bool
+		wantErr    string
+	}{
+		{
+			name:       "routes",
+			directives: map[string]string{"routes": "get,list", "prefix": "/admin/songs/"},
+			check: func(e *EntityInfo) bool {
+				return e.HasRoute("get") && e.HasRoute("list") && !e.HasRoute("create") && e.RoutePrefix == "/admin/songs"
+			},
+		},
+		{
+			name:       "readonly",
+			directives: map[string]string{"readonly": ""},
+			check:      func(e *EntityInfo) bool { return e.HasRoute("get") && !e.HasRoute("update") },
+		},
+		{
+			name:       "skip with dependents",
+			directives: map[string]string{"skip": "handler,grpc,graphql"},
+			check:      func(e *EntityInfo) bool { return e.Skips("handler") && !e.Skips("service") },
+		},
+		{
+			name:       "table",
+			directives: map[string]string{"table": "tracks"},
+			check:      func(e *EntityInfo) bool { return e.TableName == "tracks" && e.TableFromDirective },
+		},
+		{name: "routes and readonly", directives: map[string]string{"routes": "get", "readonly": ""}, wantErr: "不能同时使用"},
+		{name: "unknown route", directives: map[string]string{"routes": "get,patch"}, wantErr: "patch"},
+		{name: "relative prefix", directives: map[string]string{"prefix": "admin"}, wantErr: "必须以 '/' 开头"},
+		{name: "unknown layer", directives: map[string]string{"skip": "views"}, wantErr: "views"},
+		{name: "repository kept by service", directives: map[string]string{"skip": "repository"}, wantErr: "跳过了 repository, 但生成的 service 依赖它"},
+		{name: "dto kept by handler", directives: map[string]string{"skip": "dto,mapper,service,grpc,graphql"}, wantErr: "但生成的 handler 依赖它"},
+		{name: "empty table", directives: map[string]string{"table": ""}, wantErr: "不能为空"},
+		{name: "unknown directive", directives: map[string]string{"readOnly": ""}, wantErr: "//gps:readOnly"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := &EntityInfo{EntityName: "Song"}
+			err := applyEntityDirectives(info, tt.directives)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("applyEntityDirectives() error = %v, want it to mention %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applyEntityDirectives() error = %v", err)
+			}
+			if !tt.check(info) {
+				t.Errorf("applyEntityDirectives() = %+v", info)
+			}
+		})
+	}
+}
+
+func TestReadOnlyFieldsInRequestDTOs(t *testing.T) {
+	info := &EntityInfo{
+		EntityName:      "Song",
+		LowerEntityName: "song",
+		ProjectModule:   "example.com/music",
+		PrimaryKey:      FieldInfo{Name: "ID", Type: "uint", LowerName: "iD"},
+		Fields: []FieldInfo{
+			{Name: "ID", Type: "uint", LowerName: "iD"},
+			{Name: "Title", Type: "string", LowerName: "title"},
+			{Name: "Subtitle", Type: "*string", LowerName: "subtitle"},
+			{Name: "PlayCount", Type: "int", LowerName: "playCount", ReadOnly: true},
+		},
+	}
+	for _, path := range []string{"tmpl/generate/dto.go.tmpl", "tmpl/generate/dto.go.ddd.tmpl", "tmpl/generate/mapper.go.ddd.tmpl", "tmpl/generate/service.go.tmpl"} {
+		t.Run(path, func(t *testing.T) {
+			src, err := renderEmbedded(path, info)
+			if err != nil {
+				t.Fatal(err)
+			}
+			formatted, err := format.Source(src)
+			if err != nil {
+				t.Fatalf("rendered code does not parse: %v\n%s", err, src)
+			}
+			file, err := parser.ParseFile(token.NewFileSet(), "", formatted, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.HasPrefix(path, "tmpl/generate/dto") {
+				// 只读字段不出现在请求 DTO 中, 可写字段都出现, 更新请求中不会出现双重指针
+				for _, name := range []string{"CreateSongRequest", "UpdateSongRequest"} {
+					st := findStruct(file, name)
+					if st == nil {
+						t.Fatalf("%s not rendered:\n%s", name, formatted)
+					}
+					var fields []string
+					for _, f := range st.Fields.List {
+						fields = append(fields, f.Names[0].Name)
+					}
+					if strings.Join(fields, ",") != "Title,Subtitle" {
+						t.Errorf("%s fields = %v, want the writable fields only", name, fields)
+					}
+				}
+				if strings.Contains(string(formatted), "**string") {
+					t.Errorf("update request wraps a pointer field twice:\n%s", formatted)
+				}
+				return
+			}
+			if strings.Contains(string(formatted), "req.PlayCount") || strings.Contains(string(formatted), "Value.PlayCount") {
+				t.Errorf("readonly field is read from a request:\n%s", formatted)
+			}
+			if path == "tmpl/generate/mapper.go.ddd.tmpl" && (!strings.Contains(string(formatted), "e.Title = *req.Title") || !strings.Contains(string(formatted), "e.Subtitle = req.Subtitle")) {
+				t.Errorf("UpdateEntityFromDTO does not assign the writable fields:\n%s", formatted)
+			}
+		})
+	}
+}
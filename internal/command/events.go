@@ -0,0 +1,75 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// generateEvents 控制 generate 是否让服务在实体变更时发布领域事件
+var generateEvents bool
+
+const (
+	eventDir     = "internal/domain/event"
+	messagingDir = "internal/infrastructure/messaging"
+
+	defaultOutboxPollIntervalMs = 1000
+	defaultOutboxBatchSize      = 100
+	defaultOutboxMaxAttempts    = 10
+)
+
+// checkEventsSupported 检查实体能否发布领域事件: 事件由 DDD 项目的服务产生, 由仓库写入 outbox 表
+func checkEventsSupported(info *EntityInfo, paths PathConfig) error {
+	if !paths.IsDDD {
+		return fmt.Errorf("领域事件目前只支持 DDD 项目, 已忽略 %s 的 --events", info.EntityName)
+	}
+	if info.Skips("repository") || info.Skips("service") {
+		return fmt.Errorf("领域事件依赖生成的 Repository 和 Service, 已跳过 %s 的领域事件生成", info.EntityName)
+	}
+	return nil
+}
+
+// renderEventFiles 生成领域事件抽象以及实体的 Created/Updated/Deleted 事件, 事件抽象只在首次生成或使用 -F 时渲染
+func renderEventFiles(info *EntityInfo) {
+	if _, err := os.Stat(filepath.Join(eventDir, "event.go")); os.IsNotExist(err) || forceGenerate {
+		renderTask(FileGenerationTask{TemplatePath: "tmpl/generate/events/event.go.tmpl", OutputDir: eventDir, FileName: "event", IsSingular: true}, info.EntityName, info)
+	}
+	renderTask(FileGenerationTask{TemplatePath: "tmpl/generate/events/entity_events.go.tmpl", OutputDir: eventDir, Suffix: "_events"}, info.EntityName, info)
+}
+
+// generateEventRelay 注册事件发布者和 outbox relay, 在 main.go 中启动 relay, 同时为旧项目补充 outbox 配置
+func generateEventRelay(info *EntityInfo, paths PathConfig) error {
+	if _, err := os.Stat(filepath.Join(messagingDir, "outbox.go")); os.IsNotExist(err) || forceGenerate {
+		renderTask(FileGenerationTask{TemplatePath: "tmpl/generate/events/messaging.go.tmpl", OutputDir: messagingDir, FileName: "outbox", IsSingular: true}, info.EntityName, info)
+	}
+
+	if err := addExtraProvidersToDI(paths, info.ProjectModule+"/"+messagingDir,
+		diProvider{Comment: "Domain Event Bus", Expr: "messaging.NewEventBus"},
+		diProvider{Comment: "Outbox Publisher", Expr: "messaging.NewOutboxPublisher"},
+		diProvider{Comment: "Outbox Relay", Expr: "messaging.NewOutboxRelay"},
+	); err != nil {
+		return fmt.Errorf("自动修改 %s 失败: %w", paths.DIFile, err)
+	}
+
+	relay := `// 启动 outbox relay, 将领域事件投递给配置的发布者
+	if err := container.Invoke(func(relay *outbox.Relay) {
+		go relay.Run(context.Background())
+	}); err != nil {
+		log.Fatalf("启动 outbox relay 失败: %v", err)
+	}
+
+	`
+	if err := injectIntoMain("relay.Run(", relay, "starting outbox relay", "运行 outbox.Relay 投递领域事件",
+		"context", "log", "github.com/Skyenought/goprojectstarter/pkg/outbox"); err != nil {
+		return err
+	}
+
+	return ensureConfigSection(configSection{
+		Field:    "Outbox",
+		Type:     "OutboxConfig",
+		Key:      "outbox",
+		TypeDecl: "// OutboxConfig holds settings for delivering domain events from the outbox table.\ntype OutboxConfig struct {\n\tPublisher             string `mapstructure:\"publisher\"`\n\tWebhookURL            string `mapstructure:\"webhook_url\"`\n\tWebhookSecret         string `mapstructure:\"webhook_secret\"`\n\tWebhookTimeoutSeconds int    `mapstructure:\"webhook_timeout_seconds\"`\n\tPollIntervalMs        int    `mapstructure:\"poll_interval_ms\"`\n\tBatchSize             int    `mapstructure:\"batch_size\"`\n\tMaxAttempts           int    `mapstructure:\"max_attempts\"`\n}\n",
+		YAML: fmt.Sprintf("outbox:\n  publisher: bus\n  webhook_url: \"\"\n  webhook_secret: \"\"\n  webhook_timeout_seconds: 10\n  poll_interval_ms: %d\n  batch_size: %d\n  max_attempts: %d\n",
+			defaultOutboxPollIntervalMs, defaultOutboxBatchSize, defaultOutboxMaxAttempts),
+	})
+}
@@ -0,0 +1,71 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// generateExport 控制 generate 是否额外生成 CSV/XLSX 导出和导入接口
+var generateExport bool
+
+const (
+	defaultExportBatchSize     = 500
+	defaultExportMaxImportRows = 10000
+)
+
+// exportRoutes 是导出和导入接口的路由, 路径相对于实体的路由前缀
+var exportRoutes = []crudRoute{
+	{Name: "export", Method: "Get", Path: "/export", Handler: "Export"},
+	{Name: "import", Method: "Post", Path: "/import", Handler: "Import"},
+}
+
+// filterableTypes 是导出时可以按等值筛选的字段类型
+var filterableTypes = map[string]bool{
+	"string": true, "bool": true,
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true,
+	"float32": true, "float64": true,
+}
+
+// renderImportDTO 生成所有实体共享的导入结果 DTO, 只在首次生成或使用 -F 时渲染
+func renderImportDTO(info *EntityInfo, paths PathConfig) {
+	if _, err := os.Stat(filepath.Join(paths.dtoDir(), "import.go")); os.IsNotExist(err) || forceGenerate {
+		renderTask(FileGenerationTask{TemplatePath: "tmpl/generate/export/dto.go.tmpl", OutputDir: paths.dtoDir(), FileName: "import", IsSingular: true, Layer: "dto"}, info.EntityName, info)
+	}
+}
+
+// generateExportAPI 为实体生成导出/导入处理器, 注册到 DI 容器并挂载路由, 同时为旧项目补充 export 配置
+func generateExportAPI(info *EntityInfo, paths PathConfig) error {
+	if info.Skips("service") || info.Skips("dto") {
+		return fmt.Errorf("导出/导入接口依赖生成的 Service 和 DTO, 已跳过 %s 的导出/导入接口生成", info.EntityName)
+	}
+
+	handlerTemplate := "tmpl/generate/export/handler.go.tmpl"
+	if paths.IsDDD {
+		handlerTemplate = "tmpl/generate/export/handler.go.ddd.tmpl"
+	}
+	renderTask(FileGenerationTask{TemplatePath: handlerTemplate, OutputDir: strings.TrimPrefix(paths.HandlerPackagePath, "/"), Suffix: "_export_handler", Layer: "handler"}, info.EntityName, info)
+
+	importPath := info.ProjectModule + paths.HandlerPackagePath
+	handlerName := info.EntityName + "ExportHandler"
+	if err := addExtraProvidersToDI(paths, importPath,
+		diProvider{Comment: info.EntityName + " Export Handler", Expr: "handler.New" + handlerName},
+	); err != nil {
+		return fmt.Errorf("自动修改 %s 失败: %w", paths.DIFile, err)
+	}
+	if err := injectRouterDependency(paths.RouterFile, handlerName, "*handler."+handlerName, importPath); err != nil {
+		return fmt.Errorf("自动修改 %s 失败: %w", paths.RouterFile, err)
+	}
+	if err := addRoutesBeforeEntityRoutes(info, paths, "export", handlerName, exportRoutes); err != nil {
+		return fmt.Errorf("自动添加导出/导入路由到 %s 失败: %w", paths.RouterFile, err)
+	}
+	return ensureConfigSection(configSection{
+		Field:    "Export",
+		Type:     "ExportConfig",
+		Key:      "export",
+		TypeDecl: "// ExportConfig holds settings for the generated export/import endpoints.\ntype ExportConfig struct {\n\tBatchSize     int `mapstructure:\"batch_size\"`\n\tMaxImportRows int `mapstructure:\"max_import_rows\"`\n}\n",
+		YAML:     fmt.Sprintf("export:\n  batch_size: %d\n  max_import_rows: %d\n", defaultExportBatchSize, defaultExportMaxImportRows),
+	})
+}
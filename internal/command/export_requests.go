@@ -0,0 +1,423 @@
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	exportFormats string
+	exportOutDir  string
+	exportHost    string
+)
+
+var exportRequestsCmd = &cobra.Command{
+	Use:   "export-requests",
+	Short: "根据路由导出 .http 文件和 Postman v2.1 集合, 用于手动调试接口",
+	Long: `此命令解析 router.go 中的路由, 按 handler 的 @Tags (没有时按 Handler) 分组导出请求集合:
+  - requests.http: VS Code REST Client / JetBrains HTTP Client 可直接执行的请求文件;
+  - <项目名>.postman_collection.json 和 <项目名>.postman_environment.json: Postman v2.1 集合与环境。
+
+请求体是根据对应 DTO 结构体生成的示例 JSON (支持 example tag)。
+服务地址、端口 (取自 config.yaml 的 server.port) 和 JWT Bearer token 均放在变量中, 导入后填写 token 即可调用受保护的接口。
+生成的文件会在每次运行时被覆盖。
+
+示例:
+  goprojectstarter export-requests
+  goprojectstarter export-requests --format postman --out-dir docs/postman`,
+	Run: runExportRequests,
+}
+
+func init() {
+	rootCmd.AddCommand(exportRequestsCmd)
+	exportRequestsCmd.Flags().StringVar(&exportFormats, "format", "http,postman", "导出格式, 逗号分隔: http, postman")
+	exportRequestsCmd.Flags().StringVar(&exportOutDir, "out-dir", "api/requests", "输出目录")
+	exportRequestsCmd.Flags().StringVar(&exportHost, "host", "localhost", "服务地址中的主机名")
+}
+
+func runExportRequests(cmd *cobra.Command, args []string) {
+	var wantHTTP, wantPostman bool
+	for _, f := range strings.Split(exportFormats, ",") {
+		switch strings.TrimSpace(f) {
+		case "http":
+			wantHTTP = true
+		case "postman":
+			wantPostman = true
+		default:
+			fmt.Printf("❌ 错误: 不支持的导出格式 %q, 可选值为 http, postman\n", f)
+			return
+		}
+	}
+
+	fmt.Printf("🔍 正在解析路由和 DTO...\n")
+	model, err := loadAPIModel()
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	if len(model.Groups) == 0 {
+		fmt.Println("⚠️ 没有找到可以导出的路由。")
+		return
+	}
+
+	port := readServerPort("config.yaml")
+	name := "api"
+	if module, err := getProjectModule(); err == nil {
+		name = path.Base(module)
+	}
+	folders := groupRequestsByTag(model)
+
+	if wantHTTP {
+		if err := writeGeneratedFile(filepath.Join(exportOutDir, "requests.http"), renderHTTPFile(model, folders, port)); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return
+		}
+	}
+	if wantPostman {
+		collection, environment, err := renderPostman(model, folders, name, port)
+		if err == nil {
+			err = writeGeneratedFile(filepath.Join(exportOutDir, name+".postman_collection.json"), collection)
+		}
+		if err == nil {
+			err = writeGeneratedFile(filepath.Join(exportOutDir, name+".postman_environment.json"), environment)
+		}
+		if err != nil {
+			fmt.Printf("❌ 导出 Postman 集合失败: %v\n", err)
+			return
+		}
+	}
+	fmt.Println("✅ 请求集合导出完成!")
+}
+
+// readServerPort 读取 config.yaml 中的 server.port, 读取失败时使用 8080
+func readServerPort(file string) string {
+	var cfg struct {
+		Server struct {
+			Port any `yaml:"port"`
+		} `yaml:"server"`
+	}
+	content, err := os.ReadFile(file)
+	if err == nil {
+		err = yaml.Unmarshal(content, &cfg)
+	}
+	if err != nil || cfg.Server.Port == nil {
+		fmt.Printf("   ⚠️ 未能从 %s 读取 server.port, 使用默认端口 8080\n", file)
+		return "8080"
+	}
+	return fmt.Sprint(cfg.Server.Port)
+}
+
+// requestFolder 是导出集合中的一个分组
+type requestFolder struct {
+	Name      string
+	Endpoints []*apiEndpoint
+}
+
+// groupRequestsByTag 按 @Tags 分组, 没有 @Tags 的接口按 Handler 分组, 分组顺序与首次出现的顺序一致
+func groupRequestsByTag(model *apiModel) []*requestFolder {
+	var folders []*requestFolder
+	index := make(map[string]*requestFolder)
+	for _, g := range model.Groups {
+		for _, e := range g.Endpoints {
+			name := e.Tag
+			if name == "" {
+				name = e.Group
+			}
+			folder, ok := index[name]
+			if !ok {
+				folder = &requestFolder{Name: name}
+				index[name] = folder
+				folders = append(folders, folder)
+			}
+			folder.Endpoints = append(folder.Endpoints, e)
+		}
+	}
+	return folders
+}
+
+// requestTitle 返回请求的显示名称, 优先使用 @Summary
+func requestTitle(e *apiEndpoint) string {
+	if e.Summary != "" {
+		return e.Summary
+	}
+	return camelCaseToWords(e.Name)
+}
+
+// pathParamExample 返回路径参数的示例值
+func pathParamExample(p apiParam) string {
+	if p.Type.Kind == "builtin" && p.Type.Name != "string" {
+		return "1"
+	}
+	return p.Name
+}
+
+// exampleBody 返回请求体的示例 JSON, 没有请求体时返回空字符串
+func exampleBody(model *apiModel, e *apiEndpoint) (string, error) {
+	if e.Body == nil {
+		return "", nil
+	}
+	data, err := json.MarshalIndent(exampleValue(model, e.Body, map[string]bool{}), "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// orderedObject 按字段声明顺序输出的 JSON 对象
+type orderedObject []orderedField
+
+type orderedField struct {
+	Key   string
+	Value any
+}
+
+func (o orderedObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(f.Key)
+		value, err := json.Marshal(f.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// exampleValue 根据类型生成示例值, visiting 用于避免相互引用的 DTO 无限展开
+func exampleValue(model *apiModel, t *apiType, visiting map[string]bool) any {
+	switch t.Kind {
+	case "named":
+		var decl *apiStruct
+		for _, s := range model.Types {
+			if s.Name == t.Name {
+				decl = s
+			}
+		}
+		if decl == nil || visiting[t.Name] {
+			return nil
+		}
+		if decl.Underlying != nil {
+			return exampleValue(model, decl.Underlying, visiting)
+		}
+		visiting[t.Name] = true
+		defer delete(visiting, t.Name)
+		obj := orderedObject{}
+		for _, f := range decl.Fields {
+			value := exampleValue(model, f.Type, visiting)
+			if f.Embedded {
+				if embedded, ok := value.(orderedObject); ok {
+					obj = append(obj, embedded...)
+				}
+				continue
+			}
+			if f.Example != "" {
+				value = parseExample(f.Example, value)
+			}
+			obj = append(obj, orderedField{Key: f.JSONName, Value: value})
+		}
+		return obj
+	case "builtin":
+		switch t.Name {
+		case "string", "[]byte":
+			return "string"
+		case "bool":
+			return false
+		case "any":
+			return nil
+		}
+		return 0
+	case "pointer":
+		return exampleValue(model, t.Elem, visiting)
+	case "slice":
+		if item := exampleValue(model, t.Elem, visiting); item != nil {
+			return []any{item}
+		}
+		return []any{}
+	case "map":
+		return orderedObject{}
+	case "external":
+		switch t.Name {
+		case "time.Time":
+			return "2025-01-01T00:00:00Z"
+		case "uuid.UUID":
+			return "00000000-0000-0000-0000-000000000000"
+		case "decimal.Decimal":
+			return "0"
+		}
+	}
+	return nil
+}
+
+// parseExample 将 example tag 转换为与字段默认示例相同的 JSON 类型
+func parseExample(example string, fallback any) any {
+	switch fallback.(type) {
+	case int:
+		if v, err := strconv.ParseFloat(example, 64); err == nil {
+			return v
+		}
+	case bool:
+		if v, err := strconv.ParseBool(example); err == nil {
+			return v
+		}
+	}
+	return example
+}
+
+// ---------------------------------------------------------------- .http
+
+func renderHTTPFile(model *apiModel, folders []*requestFolder, port string) []byte {
+	var b strings.Builder
+	b.WriteString("# 由 goprojectstarter export-requests 根据路由生成, 重新运行会覆盖此文件。\n")
+	b.WriteString("# 适用于 VS Code REST Client 和 JetBrains HTTP Client, 调用受保护的接口前请先填写 token。\n\n")
+	fmt.Fprintf(&b, "@host = %s\n", exportHost)
+	fmt.Fprintf(&b, "@port = %s\n", port)
+	b.WriteString("@baseUrl = http://{{host}}:{{port}}\n")
+	b.WriteString("@token = \n")
+
+	for _, folder := range folders {
+		fmt.Fprintf(&b, "\n# ======================== %s ========================\n", folder.Name)
+		for _, e := range folder.Endpoints {
+			url := e.Path
+			for _, p := range e.PathParams {
+				url = strings.Replace(url, ":"+p.Name, pathParamExample(p), 1)
+			}
+			fmt.Fprintf(&b, "\n### %s / %s\n", folder.Name, requestTitle(e))
+			fmt.Fprintf(&b, "%s {{baseUrl}}%s\n", e.Method, strings.ReplaceAll(url, "?", ""))
+			b.WriteString("Authorization: Bearer {{token}}\n")
+			body, err := exampleBody(model, e)
+			if err != nil || body == "" {
+				continue
+			}
+			b.WriteString("Content-Type: application/json\n\n")
+			b.WriteString(body + "\n")
+		}
+	}
+	return []byte(b.String())
+}
+
+// ---------------------------------------------------------------- Postman
+
+const postmanSchema = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+type postmanVariable struct {
+	Key     string `json:"key"`
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Enabled *bool  `json:"enabled,omitempty"`
+}
+
+type postmanItem struct {
+	Name    string          `json:"name"`
+	Item    []*postmanItem  `json:"item,omitempty"`
+	Request *postmanRequest `json:"request,omitempty"`
+}
+
+type postmanRequest struct {
+	Method string            `json:"method"`
+	Header []postmanVariable `json:"header"`
+	Body   *postmanBody      `json:"body,omitempty"`
+	URL    postmanURL        `json:"url"`
+}
+
+type postmanBody struct {
+	Mode    string         `json:"mode"`
+	Raw     string         `json:"raw"`
+	Options map[string]any `json:"options"`
+}
+
+type postmanURL struct {
+	Raw      string            `json:"raw"`
+	Host     []string          `json:"host"`
+	Path     []string          `json:"path"`
+	Variable []postmanVariable `json:"variable,omitempty"`
+}
+
+func renderPostman(model *apiModel, folders []*requestFolder, name, port string) ([]byte, []byte, error) {
+	collection := map[string]any{
+		"info": map[string]any{
+			"name":   name,
+			"schema": postmanSchema,
+		},
+		"auth": map[string]any{
+			"type":   "bearer",
+			"bearer": []postmanVariable{{Key: "token", Value: "{{token}}", Type: "string"}},
+		},
+		"variable": []postmanVariable{
+			{Key: "port", Value: port},
+			{Key: "baseUrl", Value: "http://" + exportHost + ":{{port}}"},
+			{Key: "token", Value: ""},
+		},
+	}
+
+	var items []*postmanItem
+	for _, folder := range folders {
+		group := &postmanItem{Name: folder.Name}
+		for _, e := range folder.Endpoints {
+			request := &postmanRequest{
+				Method: e.Method,
+				Header: []postmanVariable{},
+				URL: postmanURL{
+					Raw:  "{{baseUrl}}" + e.Path,
+					Host: []string{"{{baseUrl}}"},
+					Path: strings.Split(strings.Trim(e.Path, "/"), "/"),
+				},
+			}
+			for _, p := range e.PathParams {
+				request.URL.Variable = append(request.URL.Variable, postmanVariable{Key: p.Name, Value: pathParamExample(p)})
+			}
+			body, err := exampleBody(model, e)
+			if err != nil {
+				return nil, nil, err
+			}
+			if body != "" {
+				request.Header = append(request.Header, postmanVariable{Key: "Content-Type", Value: "application/json"})
+				request.Body = &postmanBody{
+					Mode:    "raw",
+					Raw:     body,
+					Options: map[string]any{"raw": map[string]string{"language": "json"}},
+				}
+			}
+			group.Item = append(group.Item, &postmanItem{Name: requestTitle(e), Request: request})
+		}
+		items = append(items, group)
+	}
+	collection["item"] = items
+
+	enabled := true
+	environment := map[string]any{
+		"name": name + " (local)",
+		"values": []postmanVariable{
+			{Key: "port", Value: port, Enabled: &enabled},
+			{Key: "baseUrl", Value: "http://" + exportHost + ":{{port}}", Enabled: &enabled},
+			{Key: "token", Value: "", Type: "secret", Enabled: &enabled},
+		},
+		"_postman_variable_scope": "environment",
+	}
+
+	collectionJSON, err := json.MarshalIndent(collection, "", "  ")
+	if err != nil {
+		return nil, nil, err
+	}
+	environmentJSON, err := json.MarshalIndent(environment, "", "  ")
+	if err != nil {
+		return nil, nil, err
+	}
+	return append(collectionJSON, '\n'), append(environmentJSON, '\n'), nil
+}
@@ -0,0 +1,35 @@
+package command
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestExampleBody(t *testing.T) {
+	str := &apiType{Kind: "builtin", Name: "string"}
+	model := &apiModel{Types: []*apiStruct{
+		{Name: "Base", Fields: []apiField{{GoName: "Note", JSONName: "note", Type: str}}},
+		{Name: "CreateSongRequest", Fields: []apiField{
+			{GoName: "Title", JSONName: "title", Type: str, Example: "Hey Jude"},
+			{GoName: "Plays", JSONName: "plays", Type: &apiType{Kind: "pointer", Elem: &apiType{Kind: "builtin", Name: "int"}}, Example: "3"},
+			{GoName: "Base", Type: &apiType{Kind: "named", Name: "Base"}, Embedded: true},
+			{GoName: "Parent", JSONName: "parent", Type: &apiType{Kind: "pointer", Elem: &apiType{Kind: "named", Name: "CreateSongRequest"}}},
+			{GoName: "CreatedAt", JSONName: "createdAt", Type: &apiType{Kind: "external", Name: "time.Time"}},
+		}},
+	}}
+	endpoint := &apiEndpoint{Body: &apiType{Kind: "named", Name: "CreateSongRequest"}}
+
+	body, err := exampleBody(model, endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var compact json.RawMessage
+	if err := json.Unmarshal([]byte(body), &compact); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(compact)
+	want := `{"title":"Hey Jude","plays":3,"note":"string","parent":null,"createdAt":"2025-01-01T00:00:00Z"}`
+	if string(data) != want {
+		t.Errorf("exampleBody() = %s, want %s", data, want)
+	}
+}
@@ -0,0 +1,577 @@
+package command
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Skyenought/goprojectstarter/internal/common"
+
+	"github.com/spf13/cobra"
+)
+
+// migrationsDir 是 add-field/remove-field 生成迁移脚本的目录
+const migrationsDir = "migrations"
+
+var (
+	fieldTags     string
+	fieldReadOnly bool
+)
+
+var addFieldCmd = &cobra.Command{
+	Use:   "add-field <Entity> <Name> <Type>",
+	Short: "为实体新增字段, 并同步到 DTO、mapper 和过滤白名单",
+	Long: `通过 AST 在实体结构体中插入新字段, 然后依次更新:
+  - 响应 DTO 以及创建/更新请求 DTO (使用 --readonly 时只更新响应 DTO);
+  - DDD 结构下 mapper 的 ToEntity, UpdateEntityFromDTO 和 ToResponse 方法;
+  - 仓库实现中的 <entity>FilterWhitelist (如果存在);
+最后在 migrations/ 目录下生成对应的 SQL 迁移脚本。
+
+示例:
+  goprojectstarter add-field Song Duration int
+  goprojectstarter add-field Song ISRC string --tags 'gorm:"column:isrc;size:12"'
+  goprojectstarter add-field Song PlayCount int --readonly`,
+	Args: cobra.ExactArgs(3),
+	Run:  runAddField,
+}
+
+var removeFieldCmd = &cobra.Command{
+	Use:   "remove-field <Entity> <Name>",
+	Short: "从实体及其 DTO、mapper 和过滤白名单中移除字段",
+	Long:  `add-field 的逆操作: 从实体、DTO、mapper 和过滤白名单中移除字段, 并生成删除列的 SQL 迁移脚本。`,
+	Args:  cobra.ExactArgs(2),
+	Run:   runRemoveField,
+}
+
+func init() {
+	rootCmd.AddCommand(addFieldCmd)
+	rootCmd.AddCommand(removeFieldCmd)
+	addFieldCmd.Flags().StringVar(&fieldTags, "tags", "", "字段的 struct tag (不含反引号), 默认为 gorm:\"column:<snake_name>\"")
+	addFieldCmd.Flags().BoolVar(&fieldReadOnly, "readonly", false, "为字段添加 //gps:readonly 指令, 字段只出现在响应 DTO 中, 不能通过请求写入")
+}
+
+// fieldChange 描述一次 add-field/remove-field 操作涉及的上下文
+type fieldChange struct {
+	Info       *EntityInfo
+	EntityPath string
+	Paths      common.ProjectPathConfig
+	Field      FieldInfo
+	Tags       string
+}
+
+func runAddField(cmd *cobra.Command, args []string) {
+	if err := addField(args[0], args[1], args[2], fieldTags, fieldReadOnly); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	finishFieldChange()
+}
+
+func runRemoveField(cmd *cobra.Command, args []string) {
+	if err := removeField(args[0], args[1]); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	finishFieldChange()
+}
+
+// addField 为实体新增字段并同步到 DTO、mapper、过滤白名单和迁移脚本。tags 为空时使用 gorm:"column:<snake_name>"。
+// readOnly 为 true 时字段带有 //gps:readonly 指令, 不加入请求 DTO, mapper 也不从请求中读取它
+func addField(entityName, fieldName, fieldType, tags string, readOnly bool) error {
+	if !token.IsExported(fieldName) {
+		return fmt.Errorf("字段名 %s 必须以大写字母开头", fieldName)
+	}
+	if _, err := parser.ParseExpr(fieldType); err != nil {
+		return fmt.Errorf("无效的字段类型 %s: %w", fieldType, err)
+	}
+	if isAssociationType(fieldType) {
+		// 关联字段的响应 DTO 需要嵌套 mapper 的转换, 请求 DTO 通常只接收外键, 无法机械地生成
+		return fmt.Errorf("add-field 不支持关联类型 %s: 请手动添加关联字段, 或添加外键字段 (例如 %sID uint)", fieldType, fieldBaseType(fieldType))
+	}
+
+	change, err := loadFieldChange(entityName)
+	if err != nil {
+		return err
+	}
+	for _, f := range change.Info.Fields {
+		if f.Name == fieldName {
+			return fmt.Errorf("实体 %s 中已存在字段 %s", entityName, fieldName)
+		}
+	}
+
+	change.Tags = tags
+	column := common.ToSnakeCase(fieldName)
+	if m := regexp.MustCompile(`column:(\w+)`).FindStringSubmatch(tags); m != nil {
+		column = m[1]
+	}
+	if change.Tags == "" {
+		change.Tags = fmt.Sprintf(`gorm:"column:%s"`, column)
+	}
+	change.Field = FieldInfo{
+		Name:      fieldName,
+		Type:      fieldType,
+		GormName:  column,
+		LowerName: toLowerCamel(fieldName),
+		DTOType:   convertToDTOType(fieldType),
+		ReadOnly:  readOnly,
+	}
+
+	fmt.Printf("🚀 正在为实体 %s 添加字段 %s %s...\n", entityName, fieldName, fieldType)
+	return runFieldSteps(change, []func(*fieldChange) error{
+		addFieldToEntity,
+		addFieldToDTOs,
+		addFieldToMapper,
+		addFieldToFilterWhitelist,
+		writeAddFieldMigration,
+	})
+}
+
+// removeField 是 addField 的逆操作, 主键字段不能移除
+func removeField(entityName, fieldName string) error {
+	change, err := loadFieldChange(entityName)
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, f := range change.Info.Fields {
+		if f.Name == fieldName {
+			change.Field = f
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("实体 %s 中不存在字段 %s", entityName, fieldName)
+	}
+	if change.Field.Name == change.Info.PrimaryKey.Name {
+		return fmt.Errorf("不能移除主键字段 %s", fieldName)
+	}
+
+	fmt.Printf("🚀 正在从实体 %s 中移除字段 %s...\n", entityName, fieldName)
+	return runFieldSteps(change, []func(*fieldChange) error{
+		removeFieldFromEntity,
+		removeFieldFromDTOs,
+		removeFieldFromMapper,
+		removeFieldFromFilterWhitelist,
+		writeRemoveFieldMigration,
+	})
+}
+
+func loadFieldChange(entityName string) (*fieldChange, error) {
+	module, err := getProjectModule()
+	if err != nil {
+		return nil, fmt.Errorf("获取项目 module 失败: %w", err)
+	}
+	paths, err := common.GetProjectPaths()
+	if err != nil {
+		return nil, err
+	}
+	_, entityPath, err := findEntityContent(entityName)
+	if err != nil {
+		return nil, err
+	}
+	info, err := parseEntityFile(entityPath, module)
+	if err != nil {
+		return nil, fmt.Errorf("解析实体文件 %s 失败: %w", entityPath, err)
+	}
+	return &fieldChange{Info: info, EntityPath: entityPath, Paths: paths}, nil
+}
+
+// runFieldSteps 依次执行各个步骤。实体文件修改失败时立即停止并返回错误, 其余步骤失败只输出警告。
+func runFieldSteps(change *fieldChange, steps []func(*fieldChange) error) error {
+	for i, step := range steps {
+		if err := step(change); err != nil {
+			if i == 0 {
+				return fmt.Errorf("修改实体文件失败: %w", err)
+			}
+			fmt.Printf("   ⚠️ %v\n", err)
+		}
+	}
+	return nil
+}
+
+// finishFieldChange 整理导入并格式化项目
+func finishFieldChange() {
+	_ = common.FormatImport()
+	_ = common.FormatFile()
+	fmt.Println("🎉 操作完成! 请检查改动, 并运行 'swag init' 更新 Swagger 文档。")
+}
+
+func addFieldToEntity(c *fieldChange) error {
+	lines := []string{fmt.Sprintf("%s %s `%s`", c.Field.Name, c.Field.Type, c.Tags)}
+	if c.Field.ReadOnly {
+		lines = append([]string{directivePrefix + "readonly"}, lines...)
+	}
+	return editGoFile(c.EntityPath, func(src []byte, fset *token.FileSet, file *ast.File) ([]sourceEdit, error) {
+		st := findStruct(file, c.Info.EntityName)
+		if st == nil {
+			return nil, fmt.Errorf("未找到结构体 %s", c.Info.EntityName)
+		}
+		return []sourceEdit{insertBeforeClosing(src, fset.Position(st.Fields.Closing).Offset, lines, "")}, nil
+	})
+}
+
+func removeFieldFromEntity(c *fieldChange) error {
+	return editGoFile(c.EntityPath, func(src []byte, fset *token.FileSet, file *ast.File) ([]sourceEdit, error) {
+		st := findStruct(file, c.Info.EntityName)
+		if st == nil {
+			return nil, fmt.Errorf("未找到结构体 %s", c.Info.EntityName)
+		}
+		return removeStructField(src, fset, st, c.Field.Name, true), nil
+	})
+}
+
+// dtoFieldLines 返回需要加入每个 DTO 结构体的字段定义, 只读字段不加入请求 DTO
+func dtoFieldLines(c *fieldChange) map[string]string {
+	e, f := c.Info.EntityName, c.Field
+	lines := map[string]string{
+		e + "Response": fmt.Sprintf("%s %s `json:\"%s\"`", f.Name, f.DTOType, f.LowerName),
+	}
+	if !f.ReadOnly {
+		lines["Create"+e+"Request"] = fmt.Sprintf("%s %s `json:\"%s\"`", f.Name, f.Type, f.LowerName)
+		lines["Update"+e+"Request"] = fmt.Sprintf("%s %s `json:\"%s,omitempty\"`", f.Name, f.UpdateType(), f.LowerName)
+	}
+	return lines
+}
+
+func addFieldToDTOs(c *fieldChange) error {
+	dtoPath := filepath.Join(c.Paths.DTODir, common.ToSnakeCase(c.Info.EntityName)+".go")
+	return editGoFile(dtoPath, func(src []byte, fset *token.FileSet, file *ast.File) ([]sourceEdit, error) {
+		var edits []sourceEdit
+		for structName, line := range dtoFieldLines(c) {
+			st := findStruct(file, structName)
+			if st == nil {
+				fmt.Printf("   ⚠️ %s 中未找到 %s, 已跳过\n", dtoPath, structName)
+				continue
+			}
+			edits = append(edits, insertBeforeClosing(src, fset.Position(st.Fields.Closing).Offset, []string{line}, ""))
+		}
+		return edits, nil
+	})
+}
+
+func removeFieldFromDTOs(c *fieldChange) error {
+	dtoPath := filepath.Join(c.Paths.DTODir, common.ToSnakeCase(c.Info.EntityName)+".go")
+	return editGoFile(dtoPath, func(src []byte, fset *token.FileSet, file *ast.File) ([]sourceEdit, error) {
+		var edits []sourceEdit
+		for structName := range dtoFieldLines(c) {
+			if st := findStruct(file, structName); st != nil {
+				// DTO 中字段上方的注释通常是模板生成的 TODO 提示, 保留它们
+				edits = append(edits, removeStructField(src, fset, st, c.Field.Name, false)...)
+			}
+		}
+		return edits, nil
+	})
+}
+
+func addFieldToMapper(c *fieldChange) error {
+	mapperPath := filepath.Join(c.Paths.DTODir, common.ToSnakeCase(c.Info.EntityName)+"_mapper.go")
+	if _, err := os.Stat(mapperPath); os.IsNotExist(err) {
+		return nil // 标准结构没有 mapper
+	}
+	name := c.Field.Name
+	return editGoFile(mapperPath, func(src []byte, fset *token.FileSet, file *ast.File) ([]sourceEdit, error) {
+		var edits []sourceEdit
+		mappings := []struct{ method, value string }{{"ToResponse", "e." + name}}
+		if !c.Field.ReadOnly {
+			mappings = append(mappings, struct{ method, value string }{"ToEntity", "req." + name})
+		}
+		for _, fn := range mappings {
+			lit := findReturnedLit(findMethod(file, fn.method))
+			if lit == nil {
+				fmt.Printf("   ⚠️ %s 中未找到 %s 返回的结构体字面量, 已跳过\n", mapperPath, fn.method)
+				continue
+			}
+			edits = append(edits, insertBeforeClosing(src, fset.Position(lit.Rbrace).Offset, []string{name + ": " + fn.value}, ","))
+		}
+		if update := findMethod(file, "UpdateEntityFromDTO"); update != nil && update.Body != nil && !c.Field.ReadOnly {
+			value := "*req." + name
+			if c.Field.IsPointer() {
+				value = "req." + name
+			}
+			stmt := fmt.Sprintf("if req.%s != nil {\n\t\te.%s = %s\n\t}", name, name, value)
+			edits = append(edits, insertBeforeClosing(src, fset.Position(update.Body.Rbrace).Offset, []string{stmt}, ""))
+		}
+		return edits, nil
+	})
+}
+
+func removeFieldFromMapper(c *fieldChange) error {
+	mapperPath := filepath.Join(c.Paths.DTODir, common.ToSnakeCase(c.Info.EntityName)+"_mapper.go")
+	if _, err := os.Stat(mapperPath); os.IsNotExist(err) {
+		return nil
+	}
+	name := c.Field.Name
+	return editGoFile(mapperPath, func(src []byte, fset *token.FileSet, file *ast.File) ([]sourceEdit, error) {
+		var edits []sourceEdit
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Body == nil {
+				continue
+			}
+			// 复合字面量中以该字段为键的元素
+			ast.Inspect(fn.Body, func(n ast.Node) bool {
+				if kv, ok := n.(*ast.KeyValueExpr); ok {
+					if ident, ok := kv.Key.(*ast.Ident); ok && ident.Name == name {
+						edits = append(edits, removalEdit(src, fset.Position(kv.Pos()).Offset, fset.Position(kv.End()).Offset))
+					}
+				}
+				return true
+			})
+			// UpdateEntityFromDTO 中引用了该字段的语句
+			if fn.Name.Name == "UpdateEntityFromDTO" {
+				for _, stmt := range fn.Body.List {
+					if referencesField(stmt, name) {
+						edits = append(edits, removalEdit(src, fset.Position(stmt.Pos()).Offset, fset.Position(stmt.End()).Offset))
+					}
+				}
+			}
+		}
+		return edits, nil
+	})
+}
+
+func addFieldToFilterWhitelist(c *fieldChange) error {
+	return editFilterWhitelist(c, func(src []byte, fset *token.FileSet, lit *ast.CompositeLit) []sourceEdit {
+		entry := strconv.Quote(c.Field.GormName)
+		if _, isMap := lit.Type.(*ast.MapType); isMap {
+			entry = fmt.Sprintf("%s: %s", strconv.Quote(c.Field.LowerName), entry)
+		}
+		return []sourceEdit{insertBeforeClosing(src, fset.Position(lit.Rbrace).Offset, []string{entry}, ",")}
+	})
+}
+
+func removeFieldFromFilterWhitelist(c *fieldChange) error {
+	return editFilterWhitelist(c, func(src []byte, fset *token.FileSet, lit *ast.CompositeLit) []sourceEdit {
+		var edits []sourceEdit
+		for _, elt := range lit.Elts {
+			target := elt
+			if kv, ok := elt.(*ast.KeyValueExpr); ok {
+				target = kv.Value
+			}
+			if bl, ok := target.(*ast.BasicLit); ok && bl.Value == strconv.Quote(c.Field.GormName) {
+				edits = append(edits, removalEdit(src, fset.Position(elt.Pos()).Offset, fset.Position(elt.End()).Offset))
+			}
+		}
+		return edits
+	})
+}
+
+// editFilterWhitelist 在仓库实现目录中查找 <entity>FilterWhitelist 变量, 找不到时什么也不做
+func editFilterWhitelist(c *fieldChange, edit func(src []byte, fset *token.FileSet, lit *ast.CompositeLit) []sourceEdit) error {
+	varName := c.Info.LowerEntityName + "FilterWhitelist"
+	files, _ := filepath.Glob(filepath.Join(c.Paths.RepoImplDir, "*.go"))
+	for _, path := range files {
+		content, err := os.ReadFile(path)
+		if err != nil || !strings.Contains(string(content), varName) {
+			continue
+		}
+		return editGoFile(path, func(src []byte, fset *token.FileSet, file *ast.File) ([]sourceEdit, error) {
+			var edits []sourceEdit
+			ast.Inspect(file, func(n ast.Node) bool {
+				vs, ok := n.(*ast.ValueSpec)
+				if !ok {
+					return true
+				}
+				for i, ident := range vs.Names {
+					if ident.Name != varName || i >= len(vs.Values) {
+						continue
+					}
+					if lit, ok := vs.Values[i].(*ast.CompositeLit); ok {
+						edits = append(edits, edit(src, fset, lit)...)
+					}
+				}
+				return false
+			})
+			return edits, nil
+		})
+	}
+	return nil
+}
+
+func writeAddFieldMigration(c *fieldChange) error {
+	up := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s;", c.Info.TableName, c.Field.GormName, sqlColumnType(c.Field.Type))
+	down := fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s;", c.Info.TableName, c.Field.GormName)
+	return writeMigration(fmt.Sprintf("add_%s_to_%s", c.Field.GormName, c.Info.TableName), c, up, down)
+}
+
+func writeRemoveFieldMigration(c *fieldChange) error {
+	up := fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s;", c.Info.TableName, c.Field.GormName)
+	down := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s;", c.Info.TableName, c.Field.GormName, sqlColumnType(c.Field.Type))
+	return writeMigration(fmt.Sprintf("remove_%s_from_%s", c.Field.GormName, c.Info.TableName), c, up, down)
+}
+
+func writeMigration(name string, c *fieldChange, up, down string) error {
+	if err := os.MkdirAll(migrationsDir, 0o755); err != nil {
+		return fmt.Errorf("创建目录 %s 失败: %w", migrationsDir, err)
+	}
+	path := filepath.Join(migrationsDir, time.Now().Format("20060102150405")+"_"+name+".sql")
+	content := fmt.Sprintf(`-- %s.%s (%s)
+-- 自动生成的迁移脚本, 执行前请根据所使用的数据库核对列类型。
+
+-- +migrate Up
+%s
+
+-- +migrate Down
+%s
+`, c.Info.EntityName, c.Field.Name, c.Field.Type, up, down)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		return fmt.Errorf("写入迁移脚本 %s 失败: %w", path, err)
+	}
+	fmt.Printf("  -> 已生成迁移脚本 %s\n", path)
+	return nil
+}
+
+// sqlColumnType 将 Go 类型粗略映射为 SQL 列类型
+func sqlColumnType(goType string) string {
+	switch strings.TrimPrefix(goType, "*") {
+	case "string":
+		return "VARCHAR(255)"
+	case "bool":
+		return "BOOLEAN"
+	case "int8", "int16", "int32", "uint8", "uint16", "rune", "byte":
+		return "INTEGER"
+	case "int", "int64", "uint", "uint32", "uint64":
+		return "BIGINT"
+	case "float32":
+		return "REAL"
+	case "float64":
+		return "DOUBLE PRECISION"
+	case "time.Time":
+		return "TIMESTAMP"
+	case "[]byte":
+		return "BLOB"
+	default:
+		return "TEXT /* TODO: 请确认 " + goType + " 对应的列类型 */"
+	}
+}
+
+// editGoFile 解析文件, 应用 collect 返回的文本编辑并格式化后写回
+func editGoFile(path string, collect func(src []byte, fset *token.FileSet, file *ast.File) ([]sourceEdit, error)) error {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("读取 %s 失败: %w", path, err)
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+	if err != nil {
+		return fmt.Errorf("无法解析文件 %s: %w", path, err)
+	}
+	edits, err := collect(src, fset, file)
+	if err != nil {
+		return fmt.Errorf("修改 %s 失败: %w", path, err)
+	}
+	if len(edits) == 0 {
+		return nil
+	}
+
+	fmt.Printf("  -> 正在修改 %s...\n", path)
+	out := applyEdits(src, edits)
+	if formatted, err := format.Source(out); err == nil {
+		out = formatted
+	} else {
+		fmt.Printf("    ⚠️ 格式化 %s 失败: %v, 将写入未格式化的代码\n", path, err)
+	}
+	return os.WriteFile(path, out, 0o644)
+}
+
+// findStruct 返回文件中名为 name 的结构体类型
+func findStruct(file *ast.File, name string) *ast.StructType {
+	for _, decl := range file.Decls {
+		if st, ok := collectStructs(decl)[name]; ok {
+			return st
+		}
+	}
+	return nil
+}
+
+// findMethod 返回文件中名为 name 的第一个方法或函数
+func findMethod(file *ast.File, name string) *ast.FuncDecl {
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Name.Name == name {
+			return fn
+		}
+	}
+	return nil
+}
+
+// findReturnedLit 返回函数 return 语句中的结构体字面量 (允许带 & 前缀)
+func findReturnedLit(fn *ast.FuncDecl) *ast.CompositeLit {
+	if fn == nil || fn.Body == nil {
+		return nil
+	}
+	var lit *ast.CompositeLit
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		ret, ok := n.(*ast.ReturnStmt)
+		if !ok || lit != nil {
+			return lit == nil
+		}
+		for _, r := range ret.Results {
+			if ue, ok := r.(*ast.UnaryExpr); ok {
+				r = ue.X
+			}
+			if cl, ok := r.(*ast.CompositeLit); ok {
+				lit = cl
+			}
+		}
+		return true
+	})
+	return lit
+}
+
+// removeStructField 生成删除结构体中名为 name 的字段的编辑, withDoc 为 true 时连同文档注释一起删除
+func removeStructField(src []byte, fset *token.FileSet, st *ast.StructType, name string, withDoc bool) []sourceEdit {
+	for _, f := range st.Fields.List {
+		if len(f.Names) != 1 || f.Names[0].Name != name {
+			continue
+		}
+		start := f.Pos()
+		if withDoc && f.Doc != nil {
+			start = f.Doc.Pos()
+		}
+		return []sourceEdit{removalEdit(src, fset.Position(start).Offset, fset.Position(f.End()).Offset)}
+	}
+	return nil
+}
+
+// referencesField 判断语句中是否以 x.name 的形式引用了字段
+func referencesField(stmt ast.Stmt, name string) bool {
+	found := false
+	ast.Inspect(stmt, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok && sel.Sel.Name == name {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// removalEdit 删除 [start, end) 处的代码。若它独占一行 (可带结尾逗号和行尾注释), 则删除整行。
+func removalEdit(src []byte, start, end int) sourceEdit {
+	lineStart := strings.LastIndexByte(string(src[:start]), '\n') + 1
+	lineEnd := len(src)
+	if i := strings.IndexByte(string(src[end:]), '\n'); i >= 0 {
+		lineEnd = end + i
+	}
+	rest := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(string(src[end:lineEnd])), ","))
+	if strings.TrimSpace(string(src[lineStart:start])) == "" && (rest == "" || strings.HasPrefix(rest, "//")) {
+		if lineEnd < len(src) {
+			lineEnd++
+		}
+		return sourceEdit{Start: lineStart, End: lineEnd}
+	}
+	// 与其他元素在同一行: 连同其后的逗号和空白一起删除
+	after := end
+	for after < len(src) && (src[after] == ',' || src[after] == ' ') {
+		after++
+	}
+	return sourceEdit{Start: start, End: after}
+}
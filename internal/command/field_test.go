@@ -0,0 +1,126 @@
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fieldFixtureFiles 是 add-field/remove-field 会修改的文件
+var fieldFixtureFiles = []string{
+	"internal/domain/entity/song.go",
+	"internal/interfaces/dto/song.go",
+	"internal/interfaces/dto/song_mapper.go",
+	"internal/infrastructure/persistence/song_repository_impl.go",
+}
+
+// checkFieldChange 检查修改后的文件与 golden 一致且符合 gofmt, 并把唯一的迁移脚本与 migration.sql.golden 比较
+func checkFieldChange(t *testing.T, goldenDir string) {
+	t.Helper()
+	for _, path := range fieldFixtureFiles {
+		assertGofmt(t, path)
+	}
+	checkGoldenFiles(t, goldenDir, fieldFixtureFiles...)
+
+	migrations, _ := filepath.Glob(filepath.Join(migrationsDir, "*.sql"))
+	if len(migrations) != 1 {
+		t.Fatalf("migrations = %v, want exactly one script", migrations)
+	}
+	if err := os.Rename(migrations[0], "migration.sql"); err != nil {
+		t.Fatal(err)
+	}
+	checkGoldenFiles(t, goldenDir, "migration.sql")
+}
+
+func TestAddField(t *testing.T) {
+	testdata := copyFixture(t, "field")
+	if err := addField("Song", "Duration", "int", "", false); err != nil {
+		t.Fatalf("addField() error = %v", err)
+	}
+	checkFieldChange(t, filepath.Join(testdata, "golden", "add"))
+	typeCheckClean(t)
+}
+
+func TestAddFieldWithTags(t *testing.T) {
+	copyFixture(t, "field")
+	if err := addField("Song", "ISRC", "*string", `gorm:"column:isrc;size:12"`, false); err != nil {
+		t.Fatalf("addField() error = %v", err)
+	}
+	entity, _ := os.ReadFile("internal/domain/entity/song.go")
+	if !strings.Contains(string(entity), "ISRC      *string `gorm:\"column:isrc;size:12\"`") {
+		t.Errorf("entity does not contain the tagged field:\n%s", entity)
+	}
+	migrations, _ := filepath.Glob(filepath.Join(migrationsDir, "*_add_isrc_to_songs.sql"))
+	if len(migrations) != 1 {
+		t.Errorf("migrations = %v, want the column name from the tags", migrations)
+	}
+
+	// 指针字段在更新请求中不能再包一层指针, mapper 直接赋值指针
+	dto, _ := os.ReadFile("internal/interfaces/dto/song.go")
+	if !strings.Contains(string(dto), "ISRC   *string `json:\"iSRC,omitempty\"`") || strings.Contains(string(dto), "**string") {
+		t.Errorf("update DTO does not contain a single pointer field:\n%s", dto)
+	}
+	mapper, _ := os.ReadFile("internal/interfaces/dto/song_mapper.go")
+	if !strings.Contains(string(mapper), "e.ISRC = req.ISRC") {
+		t.Errorf("mapper does not assign the pointer field:\n%s", mapper)
+	}
+	typeCheckClean(t)
+}
+
+func TestAddReadOnlyField(t *testing.T) {
+	copyFixture(t, "field")
+	if err := addField("Song", "PlayCount", "int", "", true); err != nil {
+		t.Fatalf("addField() error = %v", err)
+	}
+	assertFileContains(t, "internal/domain/entity/song.go", []string{"//gps:readonly\n\tPlayCount int"}, nil)
+	// 只读字段只加入响应 DTO, mapper 只在 ToResponse 中读取它
+	assertFileContains(t, "internal/interfaces/dto/song.go", []string{"PlayCount int       `json:\"playCount\"`"}, []string{"playCount,omitempty", "PlayCount int `json"})
+	assertFileContains(t, "internal/interfaces/dto/song_mapper.go", []string{"PlayCount: e.PlayCount"}, []string{"req.PlayCount"})
+	typeCheckClean(t)
+}
+
+func TestRemoveField(t *testing.T) {
+	testdata := copyFixture(t, "field")
+	if err := removeField("Song", "Artist"); err != nil {
+		t.Fatalf("removeField() error = %v", err)
+	}
+	checkFieldChange(t, filepath.Join(testdata, "golden", "remove"))
+}
+
+func TestFieldChangeErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		run     func() error
+		wantErr string
+	}{
+		{"duplicate field", func() error { return addField("Song", "Title", "string", "", false) }, "已存在字段 Title"},
+		{"unexported name", func() error { return addField("Song", "duration", "int", "", false) }, "必须以大写字母开头"},
+		{"invalid type", func() error { return addField("Song", "Duration", "map[", "", false) }, "无效的字段类型"},
+		// 关联字段需要嵌套 mapper 的转换, 不能直接赋值
+		{"association", func() error { return addField("Song", "Album", "*Album", "", false) }, "不支持关联类型 *Album"},
+		{"association slice", func() error { return addField("Song", "Tags", "[]Tag", "", false) }, "不支持关联类型 []Tag"},
+		{"missing entity", func() error { return addField("Album", "Duration", "int", "", false) }, "未找到实体文件"},
+		{"missing field", func() error { return removeField("Song", "Lyrics") }, "不存在字段 Lyrics"},
+		{"primary key", func() error { return removeField("Song", "ID") }, "不能移除主键字段 ID"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testdata := copyFixture(t, "field")
+			err := tt.run()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want it to mention %q", err, tt.wantErr)
+			}
+			// 失败时不修改任何文件, 也不生成迁移脚本
+			for _, path := range fieldFixtureFiles {
+				want, _ := os.ReadFile(filepath.Join(testdata, "project", path))
+				if got, _ := os.ReadFile(path); string(got) != string(want) {
+					t.Errorf("%s was modified", path)
+				}
+			}
+			if _, err := os.Stat(migrationsDir); !os.IsNotExist(err) {
+				t.Errorf("a migration was written")
+			}
+		})
+	}
+}
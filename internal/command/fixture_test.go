@@ -0,0 +1,113 @@
+package command
+
+import (
+	"bytes"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFixture 在 dir 下写入 files (相对路径到内容), 并切换到 dir
+func writeFixture(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(dir)
+}
+
+// copyFixture 把 testdata 下的示例项目复制到临时目录并切换过去, 返回 testdata/<name> 的绝对路径
+func copyFixture(t *testing.T, name string) string {
+	t.Helper()
+	testdata, err := filepath.Abs(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.CopyFS(dir, os.DirFS(filepath.Join(testdata, "project"))); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+	return testdata
+}
+
+// assertParses 检查 Go 源文件可以被解析
+func assertParses(t *testing.T, path string) {
+	t.Helper()
+	if _, err := parser.ParseFile(token.NewFileSet(), path, nil, 0); err != nil {
+		t.Errorf("%s 无法解析: %v", path, err)
+	}
+}
+
+// assertGofmt 检查 Go 源文件已经是 gofmt 格式
+func assertGofmt(t *testing.T, path string) {
+	t.Helper()
+	src, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	formatted, err := format.Source(src)
+	if err != nil {
+		t.Errorf("%s 无法解析: %v", path, err)
+		return
+	}
+	if !bytes.Equal(src, formatted) {
+		t.Errorf("%s 不是 gofmt 格式:\n%s", path, src)
+	}
+}
+
+// checkGoldenFiles 比较当前目录下的 files 和 goldenDir 中的同名 .golden 文件, -update 时改为覆盖 golden 文件
+func checkGoldenFiles(t *testing.T, goldenDir string, files ...string) {
+	t.Helper()
+	for _, rel := range files {
+		got, err := os.ReadFile(rel)
+		if err != nil {
+			t.Errorf("%s: %v", rel, err)
+			continue
+		}
+		golden := filepath.Join(goldenDir, filepath.FromSlash(rel)+".golden")
+		if *updateGolden {
+			if err := os.MkdirAll(filepath.Dir(golden), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(golden, got, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		want, err := os.ReadFile(golden)
+		if err != nil {
+			t.Errorf("%s: %v (使用 -update 生成 golden 文件)", rel, err)
+			continue
+		}
+		if string(got) != string(want) {
+			t.Errorf("%s 与 %s 不一致:\n--- got ---\n%s\n--- want ---\n%s", rel, golden, got, want)
+		}
+	}
+}
+
+// typeCheckClean 对当前目录的模块做类型检查, 要求没有任何诊断
+func typeCheckClean(t *testing.T) {
+	t.Helper()
+	diags, err := newSourceOverlay().typeCheck()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diags) > 0 {
+		lines := make([]string, len(diags))
+		for i, d := range diags {
+			lines[i] = d.String()
+		}
+		t.Errorf("类型检查失败:\n%s", strings.Join(lines, "\n"))
+	}
+}
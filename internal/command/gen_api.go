@@ -4,6 +4,7 @@ import (
 	"bytes"
 	_ "embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"go/ast"
 	"go/parser"
@@ -17,6 +18,7 @@ import (
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/Skyenought/goprojectstarter/internal/common"
+	"github.com/Skyenought/goprojectstarter/internal/llm"
 	"github.com/spf13/cobra"
 )
 
@@ -30,18 +32,22 @@ var (
 	apiPath          string
 	userPrompt       string
 	saveToMarkdown   bool
+	llmProfile       string // .goprojectstarter.yaml 中 llm.profiles 的名称
 )
 
+// LLMCodeSnippets 是 gen-api 要求 LLM 返回的 JSON 对象, description 会写入传给模型的 JSON schema
 type LLMCodeSnippets struct {
-	RepoInterfaceMethod string `json:"repo_interface_method"`
-	RepoImplMethod      string `json:"repo_impl_method"`
-	ServiceInterface    string `json:"service_interface_method"`
-	ServiceImplMethod   string `json:"service_impl_method"`
-	HandlerMethod       string `json:"handler_method"`
-	RouterLine          string `json:"router_line"`
-	MapperFullContent   string `json:"mapper_full_content"`
+	RepoInterfaceMethod string `json:"repo_interface_method" description:"仓库接口中新方法的签名"`
+	RepoImplMethod      string `json:"repo_impl_method" description:"仓库实现层新方法的完整 Go 代码"`
+	ServiceInterface    string `json:"service_interface_method" description:"服务接口中新方法的签名"`
+	ServiceImplMethod   string `json:"service_impl_method" description:"服务实现层新方法的完整 Go 代码"`
+	HandlerMethod       string `json:"handler_method" description:"处理器新方法的完整 Go 代码, 包含 Swagger 注释"`
+	RouterLine          string `json:"router_line" description:"注册新路由的一行代码"`
+	MapperFullContent   string `json:"mapper_full_content" description:"需要修改 Mapper 时为其完整的文件内容, 否则为空字符串"`
 }
 
+var apiSnippetsSchema = llm.SchemaFor("api_code_snippets", LLMCodeSnippets{})
+
 var genApiCmd = &cobra.Command{
 	Use:   "gen-api [EntityName] [MethodName]",
 	Short: "为已存在的实体创建新的 API 接口",
@@ -82,6 +88,13 @@ func init() {
 	genApiCmd.Flags().StringVar(&apiPath, "path", "", "指定 API 路径 (e.g., /:id/promote)")
 	genApiCmd.Flags().StringVarP(&userPrompt, "prompt", "p", "", "用自然语言描述新 API 的功能、参数和业务流程")
 	genApiCmd.Flags().BoolVar(&saveToMarkdown, "markdown", false, "将 AI prompt 保存到本地 markdown 文件用于调试或后续使用")
+	genApiCmd.Flags().StringVar(&llmProfile, "llm", "default", "使用 .goprojectstarter.yaml 中 llm.profiles 下的哪个配置 (如 default, fast, review)")
+	genApiCmd.Flags().IntVar(&maxLLMAttempts, "max-attempts", 3, "生成的代码无法通过类型检查时, 携带编译诊断请求 LLM 的最多次数")
+	genApiCmd.Flags().BoolVar(&common.NoLLMCache, "no-cache", false, "不读取也不写入本地的 LLM 回复缓存, 总是重新请求模型")
+	genApiCmd.Flags().BoolVar(&common.NoLLMStream, "no-stream", false, "不使用流式请求, 等待完整回复后再显示")
+	genApiCmd.Flags().IntVar(&common.StreamPreviewLines, "preview-lines", 6, "流式生成时在进度下方预览最近收到的几行回复, 0 表示只显示进度")
+	genApiCmd.Flags().IntVar(&contextTopK, "context-k", 8, "沿类型引用自动检索并放入 prompt 的相关声明数量, 0 表示关闭")
+	genApiCmd.Flags().StringSliceVar(&contextPicks, "context", nil, "无论排名如何都放入 prompt 的声明 (如 dto.CreateSongRequest, ErrNotFound)")
 }
 
 func runGenApi(cmd *cobra.Command, args []string) {
@@ -114,19 +127,45 @@ func runGenApi(cmd *cobra.Command, args []string) {
 		return
 	}
 
-	fmt.Println("\n🤖 正在请求 LLM 生成代码骨架...")
-	snippets, err := generateCodeWithLLM(info, userPrompt)
+	finalPrompt, err := buildApiPrompt(info, userPrompt)
 	if err != nil {
-		fmt.Printf("❌ LLM 代码生成失败: %v\n", err)
+		fmt.Printf("❌ 构建 Prompt 失败: %v\n", err)
 		return
 	}
-
 	if saveToMarkdown {
+		filename := fmt.Sprintf("gen-api-prompt-%s-%s.md", info.EntityName, info.MethodName)
+		if err := os.WriteFile(filename, []byte(finalPrompt), 0o644); err != nil {
+			fmt.Printf("⚠️ 警告：保存 prompt 到 markdown 文件失败: %v\n", err)
+		} else {
+			fmt.Printf("✅ Prompt 已保存至 %s。程序将在此终止。\n", filename)
+		}
 		return
 	}
-	fmt.Println("   ✓ LLM 代码生成成功！")
 
-	if err := injectGeneratedCode(info, snippets); err != nil {
+	fmt.Println("\n🤖 正在请求 LLM 生成代码骨架...")
+	overlay, err := generateUntilCompiles(finalPrompt, apiSnippetsSchema, func(response string, overlay *sourceOverlay) error {
+		snippets, err := parseApiSnippets(response)
+		if err != nil {
+			return err
+		}
+		return injectGeneratedCode(info, snippets, overlay)
+	})
+	var compileErr *compileCheckError
+	if errors.As(err, &compileErr) {
+		fmt.Printf("❌ %v, 未写入任何文件。\n", compileErr)
+		compileErr.report()
+		saveDebugFile(compileErr.Response)
+		return
+	}
+	if errors.Is(err, common.ErrLLMCanceled) {
+		fmt.Println("⏹️ 已取消, 未写入任何文件。")
+		return
+	}
+	if err != nil {
+		fmt.Printf("❌ LLM 代码生成失败: %v\n", err)
+		return
+	}
+	if err := overlay.flush(); err != nil {
 		fmt.Printf("❌ 代码注入失败: %v\n", err)
 		return
 	}
@@ -308,10 +347,11 @@ func runHistoryMode() (common.ApiInfo, error) {
 	return info, nil
 }
 
-func generateCodeWithLLM(info common.ApiInfo, userPrompt string) (*LLMCodeSnippets, error) {
+// buildApiPrompt 读取实体和 Mapper 的上下文并渲染 gen-api 的 LLM prompt
+func buildApiPrompt(info common.ApiInfo, userPrompt string) (string, error) {
 	entityContent, entityPath, err := findEntityContent(info.EntityName)
 	if err != nil {
-		return nil, fmt.Errorf("无法找到并读取实体 '%s' 的文件: %w", info.EntityName, err)
+		return "", fmt.Errorf("无法找到并读取实体 '%s' 的文件: %w", info.EntityName, err)
 	}
 
 	mapperContent, mapperPath, err := findMapperContent(info.EntityName)
@@ -320,9 +360,11 @@ func generateCodeWithLLM(info common.ApiInfo, userPrompt string) (*LLMCodeSnippe
 		mapperContent = ""
 	}
 
+	related := retrieveRelatedContext(info.EntityName, []string{info.EntityName + "Repository", info.EntityName + "Service"}, nil, []string{entityPath, mapperPath})
+
 	tmpl, err := template.New("llm_prompt").Parse(promptTemplate)
 	if err != nil {
-		return nil, fmt.Errorf("解析 LLM prompt 模板失败: %w", err)
+		return "", fmt.Errorf("解析 LLM prompt 模板失败: %w", err)
 	}
 
 	templateData := map[string]interface{}{
@@ -331,41 +373,27 @@ func generateCodeWithLLM(info common.ApiInfo, userPrompt string) (*LLMCodeSnippe
 		"FullApiPath": info.FullApiPath, "UserPrompt": userPrompt,
 		"EntityContent": entityContent, "EntityPath": entityPath,
 		"MapperContent": mapperContent, "MapperPath": mapperPath,
+		"RelatedDecls": related,
 	}
 
 	var promptBuf bytes.Buffer
 	if err := tmpl.Execute(&promptBuf, templateData); err != nil {
-		return nil, fmt.Errorf("渲染 LLM prompt 模板失败: %w", err)
-	}
-	finalPrompt := promptBuf.String()
-
-	if saveToMarkdown {
-		filename := fmt.Sprintf("gen-api-prompt-%s-%s.md", info.EntityName, info.MethodName)
-		if err := os.WriteFile(filename, []byte(finalPrompt), 0o644); err != nil {
-			fmt.Printf("⚠️ 警告：保存 prompt 到 markdown 文件失败: %v\n", err)
-		} else {
-			fmt.Printf("✅ Prompt 已保存至 %s。程序将在此终止。\n", filename)
-		}
-		return nil, nil
-	}
-
-	llmResponse, err := common.GenWithDefaultLLM(finalPrompt)
-	if err != nil {
-		return nil, fmt.Errorf("LLM API 调用失败: %w", err)
+		return "", fmt.Errorf("渲染 LLM prompt 模板失败: %w", err)
 	}
+	return promptBuf.String(), nil
+}
 
+// parseApiSnippets 把已经通过 schema 校验的 JSON 响应解析为各层的代码片段
+func parseApiSnippets(llmResponse string) (*LLMCodeSnippets, error) {
 	var snippets LLMCodeSnippets
-	cleanedResponse := strings.TrimSpace(llmResponse)
-	cleanedResponse = strings.TrimPrefix(cleanedResponse, "```json")
-	cleanedResponse = strings.TrimSuffix(cleanedResponse, "```")
-	if err := json.Unmarshal([]byte(cleanedResponse), &snippets); err != nil {
-		return nil, fmt.Errorf("无法将 LLM 的响应解析为 JSON。原始响应:\n%s\n错误详情: %w", llmResponse, err)
+	if err := json.Unmarshal([]byte(llmResponse), &snippets); err != nil {
+		return nil, fmt.Errorf("无法将 LLM 的响应解析为 JSON: %w", err)
 	}
-
 	return &snippets, nil
 }
 
-func injectGeneratedCode(info common.ApiInfo, snippets *LLMCodeSnippets) error {
+// injectGeneratedCode 把代码片段注入到覆盖层中, 不会直接修改磁盘上的文件
+func injectGeneratedCode(info common.ApiInfo, snippets *LLMCodeSnippets, overlay *sourceOverlay) error {
 	paths, err := common.GetProjectPaths()
 	if err != nil {
 		return err
@@ -376,16 +404,14 @@ func injectGeneratedCode(info common.ApiInfo, snippets *LLMCodeSnippets) error {
 		mapperDir := "internal/interfaces/dto" // 假设 DDD 结构
 		mapperPath := filepath.Join(mapperDir, common.ToSnakeCase(info.EntityName)+"_mapper.go")
 		fmt.Printf("  -> 正在覆盖/创建 Mapper 文件 %s...\n", mapperPath)
-		if err := os.MkdirAll(filepath.Dir(mapperPath), 0o755); err != nil {
-			return fmt.Errorf("创建 Mapper 目录 %s 失败: %w", filepath.Dir(mapperPath), err)
-		}
-		if err := os.WriteFile(mapperPath, []byte(snippets.MapperFullContent), 0o644); err != nil {
+		if err := overlay.WriteFile(mapperPath, []byte(snippets.MapperFullContent)); err != nil {
 			return fmt.Errorf("写入 Mapper 文件 %s 失败: %w", mapperPath, err)
 		}
 	}
 
 	// 步骤2: 处理其他文件的代码追加
-	if err := ensureRouteGroupExists(paths.RouterFile, info); err != nil {
+	groupDefinition, err := ensureRouteGroupExists(paths.RouterFile, info, overlay)
+	if err != nil {
 		return fmt.Errorf("确保路由组存在失败: %w", err)
 	}
 
@@ -400,7 +426,7 @@ func injectGeneratedCode(info common.ApiInfo, snippets *LLMCodeSnippets) error {
 		{filePathTmpl: paths.ServiceDir + "/%s_service.go", codeSnippet: "\n\t" + snippets.ServiceInterface, anchor: "type {{.EntityName}}Service interface", mode: common.InsertAfterBrace},
 		{filePathTmpl: paths.ServiceDir + "/%s_service.go", codeSnippet: "\n" + snippets.ServiceImplMethod, anchor: "", mode: common.AppendToEnd},
 		{filePathTmpl: paths.HandlerDir + "/%s_handler.go", codeSnippet: "\n" + snippets.HandlerMethod, anchor: "", mode: common.AppendToEnd},
-		{filePathTmpl: paths.RouterFile, codeSnippet: "\n\t" + snippets.RouterLine, anchor: fmt.Sprintf(`%sRoutes := apiV1.Group("/%s")`, info.LowerEntityName, info.TableName), mode: common.InsertAfterLine},
+		{filePathTmpl: paths.RouterFile, codeSnippet: "\n\t" + snippets.RouterLine, anchor: groupDefinition, mode: common.InsertAfterLine},
 	}
 
 	for _, task := range tasks {
@@ -411,30 +437,33 @@ func injectGeneratedCode(info common.ApiInfo, snippets *LLMCodeSnippets) error {
 			filePath = task.filePathTmpl
 		}
 		fmt.Printf("  -> 正在修改 %s...\n", filePath)
-		if err := appendToFile(filePath, task.codeSnippet, info, task.anchor, task.mode); err != nil {
+		if err := appendToFile(overlay, filePath, task.codeSnippet, info, task.anchor, task.mode); err != nil {
 			return fmt.Errorf("修改文件 %s 失败: %w", filePath, err)
 		}
 	}
 	return nil
 }
 
-func ensureRouteGroupExists(routerPath string, info common.ApiInfo) error {
-	content, err := os.ReadFile(routerPath)
+// ensureRouteGroupExists 确保路由文件中存在实体的路由组并返回其定义语句。
+// 已存在的路由组可能通过 //gps:prefix 使用了自定义前缀, 因此按变量名而不是路径匹配。
+func ensureRouteGroupExists(routerPath string, info common.ApiInfo, overlay *sourceOverlay) (string, error) {
+	content, err := overlay.ReadFile(routerPath)
 	if err != nil {
-		return err
+		return "", err
 	}
-	groupDefinition := fmt.Sprintf(`%sRoutes := apiV1.Group("/%s")`, info.LowerEntityName, info.TableName)
-	if bytes.Contains(content, []byte(groupDefinition)) {
-		return nil
+	groupPattern := regexp.MustCompile(regexp.QuoteMeta(info.LowerEntityName+"Routes := apiV1.Group(") + `"[^"]*"\)`)
+	if existing := groupPattern.Find(content); existing != nil {
+		return string(existing), nil
 	}
+	groupDefinition := fmt.Sprintf(`%sRoutes := apiV1.Group("/%s")`, info.LowerEntityName, info.TableName)
 	fmt.Printf("  -> 在 %s 中未找到路由组，正在创建...\n", routerPath)
 	creationCode := fmt.Sprintf("\n\t// %s routes\n\t%s", info.EntityName, groupDefinition)
 	anchor := `apiV1 := r.App.Group("/api/v1")`
-	return appendToFile(routerPath, creationCode, info, anchor, common.InsertAfterLine)
+	return groupDefinition, appendToFile(overlay, routerPath, creationCode, info, anchor, common.InsertAfterLine)
 }
 
-func appendToFile(filePath, codeSnippet string, info common.ApiInfo, anchorTmplStr string, mode common.InsertionMode) error {
-	content, err := os.ReadFile(filePath)
+func appendToFile(overlay *sourceOverlay, filePath, codeSnippet string, info common.ApiInfo, anchorTmplStr string, mode common.InsertionMode) error {
+	content, err := overlay.ReadFile(filePath)
 	if err != nil {
 		if mode != common.AppendToEnd && !os.IsNotExist(err) {
 			return err
@@ -483,7 +512,7 @@ func appendToFile(filePath, codeSnippet string, info common.ApiInfo, anchorTmplS
 		finalContent.Write(content[insertionPoint:])
 		newContent = finalContent.Bytes()
 	}
-	return os.WriteFile(filePath, newContent, 0o644)
+	return overlay.WriteFile(filePath, newContent)
 }
 
 func runGenApiRevert(cmd *cobra.Command, args []string) {
@@ -0,0 +1,391 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	clientLang    string
+	clientOut     string
+	clientPackage string
+)
+
+// 客户端默认的输出位置, Go 客户端放在 pkg 下以便其他模块导入
+var defaultClientOut = map[string]string{
+	"ts": "sdk/typescript/client.ts",
+	"go": "pkg/client/client.go",
+}
+
+var genClientCmd = &cobra.Command{
+	Use:   "gen-client",
+	Short: "根据路由和 DTO 生成带类型的 TypeScript 或 Go 客户端",
+	Long: `此命令解析 router.go 中的路由、handler 上的 swagger 注释以及 dto 包中的结构体, 生成带类型的 API 客户端:
+  - 每个 DTO 对应一个 TypeScript interface 或 Go 结构体;
+  - 每个路由对应一个方法, 按 Handler 分组 (client.song.getByID / client.Song.GetByID);
+  - 路径参数 (如 :id) 作为方法参数并自动转义;
+  - 自动解开 pkg/response 的统一返回结构, 包括 SuccessFlat 等平铺格式, 业务码非 0 时返回 APIError。
+
+生成的文件完全由本命令管理, 路由或 DTO 变更后重新运行即可。
+
+示例:
+  goprojectstarter gen-client --lang ts
+  goprojectstarter gen-client --lang go --out pkg/apiclient/client.go`,
+	Run: runGenClient,
+}
+
+func init() {
+	rootCmd.AddCommand(genClientCmd)
+	genClientCmd.Flags().StringVar(&clientLang, "lang", "", "客户端语言: ts 或 go")
+	genClientCmd.Flags().StringVarP(&clientOut, "out", "o", "", "输出文件, 默认 ts 为 sdk/typescript/client.ts, go 为 pkg/client/client.go")
+	genClientCmd.Flags().StringVar(&clientPackage, "package", "", "Go 客户端的包名, 默认为输出目录名")
+	_ = genClientCmd.MarkFlagRequired("lang")
+}
+
+func runGenClient(cmd *cobra.Command, args []string) {
+	out := clientOut
+	if out == "" {
+		out = defaultClientOut[clientLang]
+	}
+	if out == "" {
+		fmt.Printf("❌ 错误: 不支持的 --lang %q, 可选值为 ts, go\n", clientLang)
+		return
+	}
+
+	fmt.Printf("🔍 正在解析路由和 DTO...\n")
+	model, err := loadAPIModel()
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	if len(model.Groups) == 0 {
+		fmt.Println("⚠️ 没有找到可以生成客户端的路由。")
+		return
+	}
+	count := 0
+	for _, g := range model.Groups {
+		count += len(g.Endpoints)
+	}
+	fmt.Printf("   - 共 %d 个接口, %d 个 DTO 类型\n", count, len(model.Types))
+
+	var content []byte
+	switch clientLang {
+	case "ts":
+		content, err = renderTSClient(model)
+	case "go":
+		pkg := clientPackage
+		if pkg == "" {
+			pkg = filepath.Base(filepath.Dir(out))
+		}
+		if !token.IsIdentifier(pkg) {
+			fmt.Printf("❌ 错误: %q 不是合法的 Go 包名, 请使用 --package 指定\n", pkg)
+			return
+		}
+		content, err = renderGoClient(model, pkg)
+	}
+	if err != nil {
+		fmt.Printf("❌ 生成客户端失败: %v\n", err)
+		return
+	}
+	if err := writeGeneratedFile(out, content); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	fmt.Println("✅ 客户端生成完成! 路由或 DTO 变更后重新运行此命令即可更新。")
+}
+
+// clientReserved 是生成的方法参数不能使用的名字 (Go/TypeScript 关键字以及生成代码中的局部变量)
+var clientReserved = map[string]bool{
+	"ctx": true, "req": true, "out": true, "err": true, "a": true,
+	"delete": true, "new": true, "class": true, "function": true, "this": true, "in": true,
+	"default": true, "export": true, "enum": true, "void": true, "with": true, "typeof": true,
+}
+
+// clientParamName 将路径参数名转换为方法参数名
+func clientParamName(name string) string {
+	name = toLowerCamel(name)
+	if token.IsKeyword(name) || clientReserved[name] {
+		return name + "Param"
+	}
+	return name
+}
+
+// ---------------------------------------------------------------- Go
+
+// goClientType 渲染 Go 客户端中的类型, 并记录需要的导入
+func goClientType(t *apiType, imports map[string]bool) string {
+	if t == nil {
+		return "any"
+	}
+	switch t.Kind {
+	case "named":
+		return t.Name
+	case "builtin":
+		return t.Name
+	case "slice":
+		return "[]" + goClientType(t.Elem, imports)
+	case "map":
+		return "map[string]" + goClientType(t.Elem, imports)
+	case "pointer":
+		return "*" + goClientType(t.Elem, imports)
+	case "external":
+		imports[t.Import] = true
+		return t.Name
+	}
+	return "json.RawMessage"
+}
+
+func renderGoClient(model *apiModel, pkg string) ([]byte, error) {
+	imports := make(map[string]bool)
+	typ := func(t *apiType) string { return goClientType(t, imports) }
+	isStruct := func(t *apiType) bool {
+		if t == nil || t.Kind != "named" {
+			return false
+		}
+		for _, s := range model.Types {
+			if s.Name == t.Name {
+				return s.Underlying == nil
+			}
+		}
+		return false
+	}
+
+	funcs := template.FuncMap{
+		"comment": func(s string) string {
+			lines := strings.Split(s, "\n")
+			for i, line := range lines {
+				lines[i] = strings.TrimRight("// "+line, " ")
+			}
+			return strings.Join(lines, "\n")
+		},
+		"decl": func(s *apiStruct) string {
+			if s.Underlying != nil {
+				return fmt.Sprintf("type %s %s", s.Name, typ(s.Underlying))
+			}
+			var b strings.Builder
+			for _, f := range s.Fields {
+				if f.Embedded {
+					if f.Type.Kind != "unknown" {
+						fmt.Fprintf(&b, "\t%s\n", typ(f.Type))
+					}
+					continue
+				}
+				tag := f.JSONName
+				if f.OmitEmpty {
+					tag += ",omitempty"
+				}
+				fmt.Fprintf(&b, "\t%s %s `json:%q`\n", f.GoName, typ(f.Type), tag)
+			}
+			if b.Len() == 0 {
+				return fmt.Sprintf("type %s struct{}", s.Name)
+			}
+			return fmt.Sprintf("type %s struct {\n%s}", s.Name, b.String())
+		},
+		"params": func(e *apiEndpoint) string {
+			params := []string{"ctx context.Context"}
+			for _, p := range e.PathParams {
+				params = append(params, clientParamName(p.Name)+" "+typ(p.Type))
+			}
+			if e.Body != nil {
+				if isStruct(e.Body) {
+					params = append(params, "req *"+typ(e.Body))
+				} else {
+					params = append(params, "req "+typ(e.Body))
+				}
+			}
+			return strings.Join(params, ", ")
+		},
+		"body": func(e *apiEndpoint) string {
+			if e.Body == nil {
+				return "nil"
+			}
+			return "req"
+		},
+		"path": func(e *apiEndpoint) string {
+			var parts []string
+			last := 0
+			for _, loc := range pathParamRe.FindAllStringSubmatchIndex(e.Path, -1) {
+				if lit := e.Path[last:loc[0]]; lit != "" {
+					parts = append(parts, fmt.Sprintf("%q", lit))
+				}
+				parts = append(parts, "escape("+clientParamName(e.Path[loc[2]:loc[3]])+")")
+				last = loc[1]
+			}
+			if last < len(e.Path) || len(parts) == 0 {
+				parts = append(parts, fmt.Sprintf("%q", e.Path[last:]))
+			}
+			return strings.Join(parts, " + ")
+		},
+		"mode": func(e *apiEndpoint) string {
+			return "mode" + strings.ToUpper(e.Mode[:1]) + e.Mode[1:]
+		},
+		"type":     typ,
+		"isStruct": isStruct,
+	}
+
+	tmpl, err := template.New("client.go.tmpl").Funcs(funcs).ParseFS(generateTemplates, "tmpl/generate/client/client.go.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	// 先渲染一次收集类型用到的导入, 再把导入写入文件头
+	var body bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&body, "decls", model); err != nil {
+		return nil, err
+	}
+	var paths []string
+	for path := range imports {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, map[string]any{"Package": pkg, "Imports": paths, "Groups": model.Groups, "Decls": body.String()}); err != nil {
+		return nil, err
+	}
+	formatted, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("格式化生成的 Go 代码失败: %w", err)
+	}
+	return formatted, nil
+}
+
+// ---------------------------------------------------------------- TypeScript
+
+// tsExternalTypes 是常见外部类型在 JSON 中的表示
+var tsExternalTypes = map[string]string{
+	"time.Time":       "string",
+	"time.Duration":   "number",
+	"uuid.UUID":       "string",
+	"decimal.Decimal": "string",
+	"gorm.DeletedAt":  "string | null",
+	"json.RawMessage": "unknown",
+}
+
+var tsIdentRe = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// tsClientType 渲染 TypeScript 类型
+func tsClientType(t *apiType) string {
+	if t == nil {
+		return "unknown"
+	}
+	switch t.Kind {
+	case "named":
+		return t.Name
+	case "builtin":
+		switch t.Name {
+		case "string", "[]byte":
+			return "string"
+		case "bool":
+			return "boolean"
+		case "any":
+			return "unknown"
+		}
+		return "number"
+	case "slice":
+		elem := tsClientType(t.Elem)
+		if strings.Contains(elem, " ") {
+			elem = "(" + elem + ")"
+		}
+		return elem + "[]"
+	case "map":
+		return "Record<string, " + tsClientType(t.Elem) + ">"
+	case "pointer":
+		return tsClientType(t.Elem) + " | null"
+	case "external":
+		if ts, ok := tsExternalTypes[t.Name]; ok {
+			return ts
+		}
+	}
+	return "unknown"
+}
+
+func renderTSClient(model *apiModel) ([]byte, error) {
+	funcs := template.FuncMap{
+		"decl": func(s *apiStruct) string {
+			if s.Underlying != nil {
+				return fmt.Sprintf("export type %s = %s;", s.Name, tsClientType(s.Underlying))
+			}
+			var extends []string
+			var b strings.Builder
+			for _, f := range s.Fields {
+				if f.Embedded {
+					t := f.Type
+					if t.Kind == "pointer" {
+						t = t.Elem
+					}
+					if t.Kind == "named" {
+						extends = append(extends, t.Name)
+					}
+					continue
+				}
+				name := f.JSONName
+				if !tsIdentRe.MatchString(name) {
+					name = fmt.Sprintf("%q", name)
+				}
+				t := f.Type
+				if f.OmitEmpty {
+					name += "?"
+					if t.Kind == "pointer" {
+						t = t.Elem
+					}
+				}
+				fmt.Fprintf(&b, "  %s: %s;\n", name, tsClientType(t))
+			}
+			head := "export interface " + s.Name
+			if len(extends) > 0 {
+				head += " extends " + strings.Join(extends, ", ")
+			}
+			if b.Len() == 0 {
+				return head + " {}"
+			}
+			return head + " {\n" + b.String() + "}"
+		},
+		"params": func(e *apiEndpoint) string {
+			var params []string
+			for _, p := range e.PathParams {
+				params = append(params, clientParamName(p.Name)+": "+tsClientType(p.Type))
+			}
+			if e.Body != nil {
+				params = append(params, "req: "+tsClientType(e.Body))
+			}
+			return strings.Join(params, ", ")
+		},
+		"result": func(e *apiEndpoint) string {
+			if e.Mode == responseNone {
+				return "void"
+			}
+			return tsClientType(e.Result)
+		},
+		"path": func(e *apiEndpoint) string {
+			path := pathParamRe.ReplaceAllStringFunc(e.Path, func(m string) string {
+				name := pathParamRe.FindStringSubmatch(m)[1]
+				return "${encodeURIComponent(String(" + clientParamName(name) + "))}"
+			})
+			return "`" + path + "`"
+		},
+		"method": toLowerCamel,
+		"field":  toLowerCamel,
+		"comment": func(s string) string {
+			return strings.ReplaceAll(s, "\n", "\n * ")
+		},
+	}
+
+	tmpl, err := template.New("client.ts.tmpl").Funcs(funcs).ParseFS(generateTemplates, "tmpl/generate/client/client.ts.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, model); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,65 @@
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// clientFixtureFiles 是 gen-client 在示例项目中生成的文件
+var clientFixtureFiles = []string{"pkg/client/client.go", "sdk/typescript/client.ts"}
+
+func TestGenClient(t *testing.T) {
+	testdata := copyFixture(t, "client")
+
+	model, err := loadAPIModel()
+	if err != nil {
+		t.Fatal(err)
+	}
+	goSrc, err := renderGoClient(model, "client")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tsSrc, err := renderTSClient(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, content := range map[string][]byte{
+		clientFixtureFiles[0]: goSrc,
+		clientFixtureFiles[1]: tsSrc,
+	} {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, content, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	assertGofmt(t, clientFixtureFiles[0])
+	checkGoldenFiles(t, filepath.Join(testdata, "golden"), clientFixtureFiles...)
+	typeCheckClean(t)
+
+	// 多个路径参数按顺序成为方法参数, 并各自转义后拼接到路径中
+	for _, want := range []string{
+		`func (a *AlbumAPI) GetSong(ctx context.Context, albumId int, songId string)`,
+		`func (a *AlbumAPI) MoveTrack(ctx context.Context, albumId int, position int, req *MoveTrackRequest)`,
+	} {
+		if !strings.Contains(string(goSrc), want) {
+			t.Errorf("Go 客户端中缺少 %q", want)
+		}
+	}
+	for _, want := range []string{
+		"`/api/v1/albums/${encodeURIComponent(String(albumId))}/songs/${encodeURIComponent(String(songId))}`",
+		"`/api/v1/albums/${encodeURIComponent(String(albumId))}/tracks/${encodeURIComponent(String(position))}`",
+	} {
+		if !strings.Contains(string(tsSrc), want) {
+			t.Errorf("TypeScript 客户端中缺少 %q", want)
+		}
+	}
+	// 非 JSON 接口不生成方法
+	if strings.Contains(string(goSrc), "Cover") || strings.Contains(string(tsSrc), "cover") {
+		t.Error("非 JSON 接口不应该出现在客户端中")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	_ "embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"go/ast"
 	"go/format"
@@ -22,6 +23,7 @@ import (
 	"golang.org/x/tools/go/ast/astutil"
 
 	"github.com/Skyenought/goprojectstarter/internal/common"
+	"github.com/Skyenought/goprojectstarter/internal/llm"
 	"github.com/spf13/cobra"
 )
 
@@ -65,16 +67,22 @@ type LogicAdditionInfo struct {
 	ExampleServiceCode       string
 	ExampleRepoCode          string
 	AdditionalContexts       []AdditionalContext
+	// RetrievedContext 是沿类型引用自动检索到的相关声明, 以及通过 --context 手动指定的声明
+	RetrievedContext []RelatedDecl
+	// OmittedContext 记录因 prompt 预算被精简或省略的上下文, prompt 会把它们告知 LLM
+	OmittedContext []string
 }
 
 // ModifiedCodeSnippets 解析LLM的JSON响应
 type ModifiedCodeSnippets struct {
-	ModifiedHandlerMethod     string `json:"modified_handler_method"`
-	ModifiedServiceImplMethod string `json:"modified_service_impl_method"`
-	ModifiedRepoImplMethod    string `json:"modified_repo_impl_method"`
-	NewRepoInterfaceMethod    string `json:"new_repo_interface_method"`
+	ModifiedHandlerMethod     string `json:"modified_handler_method" description:"处理器方法的完整、全新的 Go 代码"`
+	ModifiedServiceImplMethod string `json:"modified_service_impl_method" description:"服务实现层方法 (以及新增辅助方法) 的完整 Go 代码"`
+	ModifiedRepoImplMethod    string `json:"modified_repo_impl_method" description:"仓库实现层方法 (以及新增辅助方法) 的完整 Go 代码"`
+	NewRepoInterfaceMethod    string `json:"new_repo_interface_method" description:"需要新增的仓库接口方法签名, 不需要时为空字符串"`
 }
 
+var modifiedSnippetsSchema = llm.SchemaFor("modified_code_snippets", ModifiedCodeSnippets{})
+
 var genLogicCmd = &cobra.Command{
 	Use:   "gen-logic",
 	Short: "为已存在的接口交互式地添加业务逻辑",
@@ -87,6 +95,14 @@ func init() {
 	genLogicCmd.Flags().BoolVar(&historyMode, "history", false, "从历史记录中选择并重新执行一次 `gen-logic` 操作")
 	genLogicCmd.Flags().StringVar(&fromMarkdownFile, "from-markdown", "", "从一个 markdown prompt 文件生成逻辑")
 	genLogicCmd.Flags().BoolVar(&saveToMarkdown, "markdown", false, "将 AI prompt 保存到本地 markdown 文件用于调试或后续使用")
+	genLogicCmd.Flags().StringVar(&llmProfile, "llm", "default", "使用 .goprojectstarter.yaml 中 llm.profiles 下的哪个配置 (如 default, fast, review)")
+	genLogicCmd.Flags().BoolVar(&showBudget, "show-budget", false, "打印 prompt 各部分上下文的 token 预算明细")
+	genLogicCmd.Flags().IntVar(&maxLLMAttempts, "max-attempts", 3, "生成的代码无法通过类型检查时, 携带编译诊断请求 LLM 的最多次数")
+	genLogicCmd.Flags().BoolVar(&common.NoLLMCache, "no-cache", false, "不读取也不写入本地的 LLM 回复缓存, 总是重新请求模型")
+	genLogicCmd.Flags().BoolVar(&common.NoLLMStream, "no-stream", false, "不使用流式请求, 等待完整回复后再显示")
+	genLogicCmd.Flags().IntVar(&common.StreamPreviewLines, "preview-lines", 6, "流式生成时在进度下方预览最近收到的几行回复, 0 表示只显示进度")
+	genLogicCmd.Flags().IntVar(&contextTopK, "context-k", 8, "沿类型引用自动检索并放入 prompt 的相关声明数量, 0 表示关闭")
+	genLogicCmd.Flags().StringSliceVar(&contextPicks, "context", nil, "无论排名如何都放入 prompt 的声明 (如 dto.CreateSongRequest, ErrNotFound)")
 }
 
 func runGenLogic(cmd *cobra.Command, args []string) {
@@ -116,6 +132,23 @@ func runGenLogic(cmd *cobra.Command, args []string) {
 		return
 	}
 
+	budget, err := common.ResolvePromptBudget(llmProfile)
+	if err != nil {
+		fmt.Printf("❌ 计算 prompt 预算失败: %v\n", err)
+		return
+	}
+	report, err := fitLogicContextToBudget(info, budget)
+	if err != nil {
+		fmt.Printf("❌ 构建 Prompt 失败: %v\n", err)
+		return
+	}
+	if showBudget {
+		report.print()
+	}
+	if len(info.OmittedContext) > 0 {
+		fmt.Printf("   ℹ️ 上下文超出 %d tokens 的预算, %d 项已精简或省略 (使用 --show-budget 查看明细)。\n", budget.Tokens, len(info.OmittedContext))
+	}
+
 	finalPrompt, err := buildPromptFromInfo(info)
 	if err != nil {
 		fmt.Printf("❌ 构建 Prompt 失败: %v\n", err)
@@ -133,19 +166,30 @@ func runGenLogic(cmd *cobra.Command, args []string) {
 	}
 
 	fmt.Println("\n🤖 正在请求 LLM 生成增强逻辑后的代码...")
-	snippets, rawLLMResponse, err := generateModifiedCodeWithLLM(finalPrompt)
+	overlay, err := generateUntilCompiles(finalPrompt, modifiedSnippetsSchema, func(response string, overlay *sourceOverlay) error {
+		snippets, err := parseModifiedCodeSnippets(response)
+		if err != nil {
+			return err
+		}
+		return applyGeneratedCode(info, snippets, overlay)
+	})
+	var compileErr *compileCheckError
+	if errors.As(err, &compileErr) {
+		fmt.Printf("❌ %v, 未写入任何文件。\n", compileErr)
+		compileErr.report()
+		saveDebugFile(compileErr.Response)
+		return
+	}
+	if errors.Is(err, common.ErrLLMCanceled) {
+		fmt.Println("⏹️ 已取消, 未写入任何文件。")
+		return
+	}
 	if err != nil {
 		fmt.Printf("❌ LLM 代码生成失败: %v\n", err)
-		if rawLLMResponse != "" {
-			saveDebugFile(rawLLMResponse)
-		}
 		return
 	}
-	fmt.Println("   ✓ LLM 代码生成成功！")
-
-	if err := applyGeneratedCode(info, snippets); err != nil {
+	if err := overlay.flush(); err != nil {
 		fmt.Printf("❌ 代码注入失败: %v\n", err)
-		saveDebugFile(rawLLMResponse)
 		return
 	}
 
@@ -174,20 +218,13 @@ func buildPromptFromInfo(info *LogicAdditionInfo) (string, error) {
 	return promptBuf.String(), nil
 }
 
-// generateModifiedCodeWithLLM 现在返回原始响应字符串
-func generateModifiedCodeWithLLM(prompt string) (*ModifiedCodeSnippets, string, error) {
-	llmResponse, err := common.GenWithDefaultLLM(prompt)
-	if err != nil {
-		return nil, "", fmt.Errorf("LLM API调用失败: %w", err)
-	}
+// parseModifiedCodeSnippets 把已经通过 schema 校验的 JSON 响应解析为各层修改后的方法
+func parseModifiedCodeSnippets(llmResponse string) (*ModifiedCodeSnippets, error) {
 	var snippets ModifiedCodeSnippets
-	cleanedResponse := strings.TrimSpace(llmResponse)
-	cleanedResponse = strings.TrimPrefix(cleanedResponse, "```json")
-	cleanedResponse = strings.TrimSuffix(cleanedResponse, "```")
-	if err := json.Unmarshal([]byte(cleanedResponse), &snippets); err != nil {
-		return nil, llmResponse, fmt.Errorf("无法将LLM响应解析为JSON: %w", err)
+	if err := json.Unmarshal([]byte(llmResponse), &snippets); err != nil {
+		return nil, fmt.Errorf("无法将LLM响应解析为JSON: %w", err)
 	}
-	return &snippets, llmResponse, nil
+	return &snippets, nil
 }
 
 // saveDebugFile 将内容保存到带时间戳的文件中
@@ -392,11 +429,25 @@ func buildLogicAdditionInfo(entityName, methodName, userPrompt, exampleMethodNam
 			info.AdditionalContexts = append(info.AdditionalContexts, addCtx)
 		}
 	}
+
+	// 手动选择的附加实体已经完整放进 prompt, 自动检索只补充其余的相关声明
+	var seedMethods []methodRef
+	for _, name := range []string{methodName, exampleMethodName} {
+		if name != "" {
+			seedMethods = append(seedMethods, methodRef{info.HandlerStructName, name}, methodRef{info.ServiceImplStructName, name}, methodRef{info.RepoImplStructName, name})
+		}
+	}
+	included := []string{info.EntityPath, info.MapperPath, info.RepoInterfacePath}
+	for _, add := range info.AdditionalContexts {
+		included = append(included, add.EntityPath, add.MapperPath, add.RepoInterfacePath)
+	}
+	info.RetrievedContext = retrieveRelatedContext(entityName, nil, seedMethods, included)
 	fmt.Println("   ✓ 上下文提取完成。")
 	return info, nil
 }
 
-func applyGeneratedCode(info *LogicAdditionInfo, snippets *ModifiedCodeSnippets) error {
+// applyGeneratedCode 把修改后的方法合并到覆盖层中, 不会直接修改磁盘上的文件
+func applyGeneratedCode(info *LogicAdditionInfo, snippets *ModifiedCodeSnippets, overlay *sourceOverlay) error {
 	tasks := []struct {
 		filePath   string
 		newCode    string
@@ -412,7 +463,7 @@ func applyGeneratedCode(info *LogicAdditionInfo, snippets *ModifiedCodeSnippets)
 		if task.newCode != "" {
 			fmt.Printf("  -> 正在智能更新 %s...\n", task.filePath)
 			// Pass the target method name to the smart replacement function
-			if err := smartReplaceOrAddMethods(task.filePath, task.newCode, task.structName); err != nil {
+			if err := smartReplaceOrAddMethods(overlay, task.filePath, task.newCode, task.structName); err != nil {
 				return err
 			}
 		}
@@ -421,7 +472,7 @@ func applyGeneratedCode(info *LogicAdditionInfo, snippets *ModifiedCodeSnippets)
 	if snippets.NewRepoInterfaceMethod != "" {
 		fmt.Printf("  -> 正在向接口 %s 添加新方法...\n", info.RepoInterfacePath)
 		anchor := fmt.Sprintf("type %sRepository interface", info.EntityName)
-		err := appendToFile(info.RepoInterfacePath, "\n\t"+snippets.NewRepoInterfaceMethod, common.ApiInfo{EntityName: info.EntityName}, anchor, common.InsertAfterBrace)
+		err := appendToFile(overlay, info.RepoInterfacePath, "\n\t"+snippets.NewRepoInterfaceMethod, common.ApiInfo{EntityName: info.EntityName}, anchor, common.InsertAfterBrace)
 		if err != nil {
 			return fmt.Errorf("向仓库接口添加方法失败: %w", err)
 		}
@@ -444,7 +495,7 @@ func hasSwaggerAnnotations(doc *ast.CommentGroup) bool {
 }
 
 // smartReplaceOrAddMethods 使用基于 AST 的智能合并策略来更新或添加方法。
-func smartReplaceOrAddMethods(filePath, codeSnippet, targetStructName string) error {
+func smartReplaceOrAddMethods(overlay *sourceOverlay, filePath, codeSnippet, targetStructName string) error {
 	if strings.TrimSpace(codeSnippet) == "" {
 		return nil
 	}
@@ -458,13 +509,16 @@ func smartReplaceOrAddMethods(filePath, codeSnippet, targetStructName string) er
 	if !ok {
 		return fmt.Errorf("LLM响应中未找到有效的函数声明")
 	}
+	if newMethod.Body == nil {
+		return fmt.Errorf("LLM 生成的方法 %s 没有函数体", newMethod.Name.Name)
+	}
 	methodName := newMethod.Name.Name
 
 	fsetTarget := token.NewFileSet()
 	var originalContent []byte
 	var fileExists bool
-	if _, statErr := os.Stat(filePath); statErr == nil {
-		originalContent, _ = os.ReadFile(filePath)
+	if content, readErr := overlay.ReadFile(filePath); readErr == nil {
+		originalContent = content
 		fileExists = true
 	}
 
@@ -490,33 +544,57 @@ func smartReplaceOrAddMethods(filePath, codeSnippet, targetStructName string) er
 		return true
 	}, nil)
 
+	// 按源码文本拼接而不是在两个 FileSet 之间移动 AST 节点, 否则节点的位置错乱, 格式化后的代码会被拆成多行
+	snippetSrc := []byte("package temp\n" + codeSnippet)
+	snippetText := func(from, to token.Pos) []byte {
+		return snippetSrc[fsetSnippet.Position(from).Offset:fsetSnippet.Position(to).Offset]
+	}
+	targetOffset := func(p token.Pos) int { return fsetTarget.Position(p).Offset }
+	var buf bytes.Buffer
 	if oldMethod != nil {
 		fmt.Printf("     - 找到现有方法 '%s', 正在智能合并...\n", methodName)
-		finalDoc := newMethod.Doc
+		// 保留原有的签名, 只替换函数体; 原注释带有 Swagger 注解而新注释没有时保留原注释
+		docStart, docEnd := targetOffset(oldMethod.Pos()), targetOffset(oldMethod.Pos())
+		if oldMethod.Doc != nil {
+			docStart, docEnd = targetOffset(oldMethod.Doc.Pos()), targetOffset(oldMethod.Doc.End())+1
+		}
+		doc := originalContent[docStart:docEnd]
 		if hasSwaggerAnnotations(oldMethod.Doc) && !hasSwaggerAnnotations(newMethod.Doc) {
 			fmt.Println("       -> 检测到并保留了现有的 Swagger 注释。")
-			finalDoc = oldMethod.Doc
+		} else if newMethod.Doc != nil {
+			doc = append(bytes.Clone(snippetText(newMethod.Doc.Pos(), newMethod.Doc.End())), '\n')
+		} else {
+			doc = nil
 		}
-		oldMethod.Doc = finalDoc
-		oldMethod.Body = newMethod.Body // 只替换函数体
+		buf.Write(originalContent[:docStart])
+		buf.Write(doc)
+		buf.Write(originalContent[docEnd:targetOffset(oldMethod.Body.Lbrace)])
+		buf.Write(snippetText(newMethod.Body.Lbrace, newMethod.Body.End()))
+		buf.Write(originalContent[targetOffset(oldMethod.Body.End()):])
 	} else {
 		fmt.Printf("     - 未找到方法 '%s', 将其作为新方法添加。\n", methodName)
-		targetNode.Decls = append(targetNode.Decls, newMethod)
-	}
-
-	var buf bytes.Buffer
-	if err := format.Node(&buf, fsetTarget, targetNode); err != nil {
-		return fmt.Errorf("格式化 AST 到 buffer 失败: %w", err)
+		if fileExists {
+			buf.Write(originalContent)
+		} else {
+			fmt.Fprintf(&buf, "package %s\n", targetNode.Name.Name)
+		}
+		start := newMethod.Pos()
+		if newMethod.Doc != nil {
+			start = newMethod.Doc.Pos()
+		}
+		buf.WriteString("\n")
+		buf.Write(snippetText(start, newMethod.End()))
+		buf.WriteString("\n")
 	}
 
 	formattedContent, err := format.Source(buf.Bytes())
 	if err != nil {
-		fmt.Printf("   ⚠️ 警告: format.Source 最终格式化失败: %v。将写入未经 import 整理的代码。\n", err)
-		return os.WriteFile(filePath, buf.Bytes(), 0o644)
+		fmt.Printf("   ⚠️ 警告: format.Source 最终格式化失败: %v。将写入未经格式化的代码。\n", err)
+		return overlay.WriteFile(filePath, buf.Bytes())
 	}
 
-	// 6. 将最终的、完全格式化好的代码写回文件
-	return os.WriteFile(filePath, formattedContent, 0o644)
+	// 6. 将最终的、完全格式化好的代码写回覆盖层
+	return overlay.WriteFile(filePath, formattedContent)
 }
 
 func getReceiverTypeName(recv *ast.FieldList) string {
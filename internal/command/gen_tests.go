@@ -0,0 +1,219 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Skyenought/goprojectstarter/internal/common"
+)
+
+var noTestFiles bool
+
+// MockMethod 描述 mock 需要实现的单个接口方法
+type MockMethod struct {
+	Name    string
+	Params  string // 带参数名的形参列表, e.g. "p0 context.Context, p1 uint"
+	Args    string // 调用时的实参列表, e.g. "p0, p1"
+	Results string // 带名字的返回值列表, e.g. "(r0 *entity.Song, r1 error)"
+	Returns string // 返回值名列表, e.g. "r0, r1"
+}
+
+// MockInfo 是 repository_mock.go.tmpl 的渲染数据
+type MockInfo struct {
+	*EntityInfo
+	InterfacePackage string
+	StdImports       []string // 标准库的导入, 包括 mock 自身使用的 sync
+	Imports          []string // 其他导入, 包括接口所在的包
+	Methods          []MockMethod
+}
+
+// generateTestCode 为实体生成仓库 mock 以及 service/handler/repository 三层的测试文件。
+// mock 是在仓库接口文件生成之后, 直接从接口的 AST 推导出来的, 因此会包含后续手动添加的方法。
+func generateTestCode(info *EntityInfo, paths PathConfig) {
+	if noTestFiles || info.NoCrudMethods || info.Skips("tests") {
+		return
+	}
+
+	repoInterfaceDir, implDir, serviceDir, handlerDir := "internal/domain/ports", "internal/adapter/repository", "internal/usecase/service", "internal/adapter/handler"
+	suffix := ".tmpl"
+	if paths.IsDDD {
+		repoInterfaceDir, implDir, serviceDir, handlerDir = "internal/domain/repository", "internal/infrastructure/persistence", "internal/application/service", "internal/interfaces/handler"
+		suffix = ".ddd.tmpl"
+	}
+
+	tasks := []FileGenerationTask{
+		{TemplatePath: "tmpl/generate/service_test.go" + suffix, OutputDir: serviceDir, Suffix: "_service_test", Layer: "service"},
+		{TemplatePath: "tmpl/generate/handler_test.go" + suffix, OutputDir: handlerDir, Suffix: "_handler_test", Layer: "handler"},
+		{TemplatePath: "tmpl/generate/repository_impl_test.go" + suffix, OutputDir: implDir, Suffix: "_repository_impl_test", Layer: "repository"},
+	}
+
+	interfacePath := filepath.Join(repoInterfaceDir, common.ToSnakeCase(info.EntityName)+"_repository.go")
+	mockInfo, err := buildMockInfo(info, interfacePath, info.EntityName+"Repository")
+	if err != nil {
+		// 没有 mock 时 service 测试无法编译, 只生成 handler 测试
+		fmt.Printf("  ⚠️ 无法从 %s 推导仓库 mock, 已跳过 service/repository 测试生成: %v\n", interfacePath, err)
+		tasks = tasks[1:2]
+	} else {
+		renderTask(FileGenerationTask{TemplatePath: "tmpl/generate/repository_mock.go.tmpl", OutputDir: filepath.Join(repoInterfaceDir, "mocks"), Suffix: "_repository_mock"}, info.EntityName, mockInfo)
+	}
+
+	for _, task := range tasks {
+		if info.Skips(task.Layer) {
+			continue
+		}
+		renderTask(task, info.EntityName, info)
+	}
+}
+
+// buildMockInfo 解析仓库接口文件, 提取 interfaceName 的全部方法签名
+func buildMockInfo(info *EntityInfo, interfacePath, interfaceName string) (*MockInfo, error) {
+	fset := token.NewFileSet()
+	node, err := parser.ParseFile(fset, interfacePath, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	mockInfo := &MockInfo{EntityInfo: info, InterfacePackage: node.Name.Name}
+
+	var iface *ast.InterfaceType
+	ast.Inspect(node, func(n ast.Node) bool {
+		if ts, ok := n.(*ast.TypeSpec); ok && ts.Name.Name == interfaceName {
+			iface, _ = ts.Type.(*ast.InterfaceType)
+			return false
+		}
+		return true
+	})
+	if iface == nil {
+		return nil, fmt.Errorf("未找到接口 %s", interfaceName)
+	}
+
+	// 只保留方法签名中引用到的导入, 接口文件中其他声明使用的导入会让 mock 无法编译
+	used := make(map[string]bool)
+	ast.Inspect(iface, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if pkg, ok := sel.X.(*ast.Ident); ok {
+				used[pkg.Name] = true
+			}
+		}
+		return true
+	})
+	// 按 gofmt 的规则分为标准库和其他两组, 组内按路径排序
+	importPath := info.ProjectModule + "/" + filepath.ToSlash(filepath.Dir(interfacePath))
+	std := map[string]string{"sync": `"sync"`}
+	others := map[string]string{importPath: strconv.Quote(importPath)}
+	for _, imp := range node.Imports {
+		path, _ := strconv.Unquote(imp.Path.Value)
+		name := importName(path)
+		spec := imp.Path.Value
+		if imp.Name != nil {
+			name = imp.Name.Name
+			spec = name + " " + spec
+		}
+		if !used[name] {
+			continue
+		}
+		if first, _, _ := strings.Cut(path, "/"); strings.Contains(first, ".") {
+			others[path] = spec
+		} else {
+			std[path] = spec
+		}
+	}
+	mockInfo.StdImports = sortedValues(std)
+	mockInfo.Imports = sortedValues(others)
+
+	for _, m := range iface.Methods.List {
+		fn, ok := m.Type.(*ast.FuncType)
+		if !ok || len(m.Names) == 0 {
+			continue // 嵌入的接口暂不支持
+		}
+		method := MockMethod{Name: m.Names[0].Name}
+
+		var params, args []string
+		idx := 0
+		for _, p := range fn.Params.List {
+			typ := exprString(fset, p.Type)
+			count := len(p.Names)
+			if count == 0 {
+				count = 1
+			}
+			for i := 0; i < count; i++ {
+				name := fmt.Sprintf("p%d", idx)
+				params = append(params, name+" "+typ)
+				if strings.HasPrefix(typ, "...") {
+					name += "..."
+				}
+				args = append(args, name)
+				idx++
+			}
+		}
+		method.Params = strings.Join(params, ", ")
+		method.Args = strings.Join(args, ", ")
+
+		if fn.Results != nil {
+			var results, returns []string
+			idx = 0
+			for _, r := range fn.Results.List {
+				typ := exprString(fset, r.Type)
+				count := len(r.Names)
+				if count == 0 {
+					count = 1
+				}
+				for i := 0; i < count; i++ {
+					results = append(results, fmt.Sprintf("r%d %s", idx, typ))
+					returns = append(returns, fmt.Sprintf("r%d", idx))
+					idx++
+				}
+			}
+			method.Results = "(" + strings.Join(results, ", ") + ")"
+			method.Returns = strings.Join(returns, ", ")
+		}
+		mockInfo.Methods = append(mockInfo.Methods, method)
+	}
+	return mockInfo, nil
+}
+
+// importName 按惯例从导入路径推断包名: 取最后一段, 跳过 /v2 这样的主版本后缀, 去掉 go- 前缀和 -go 后缀
+func importName(importPath string) string {
+	base := path.Base(importPath)
+	if len(base) > 1 && base[0] == 'v' && strings.Trim(base[1:], "0123456789") == "" {
+		base = path.Base(path.Dir(importPath))
+	}
+	base = strings.TrimPrefix(base, "go-")
+	base = strings.TrimSuffix(strings.TrimSuffix(base, "-go"), ".go")
+	if i := strings.IndexAny(base, ".-"); i > 0 {
+		base = base[:i]
+	}
+	return base
+}
+
+// sortedValues 按键的顺序返回 m 的值
+func sortedValues(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	values := make([]string, len(keys))
+	for i, k := range keys {
+		values[i] = m[k]
+	}
+	return values
+}
+
+// exprString 将类型表达式还原为源码文本
+func exprString(fset *token.FileSet, expr ast.Expr) string {
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, expr); err != nil {
+		return ""
+	}
+	return buf.String()
+}
@@ -0,0 +1,83 @@
+package command
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestImportName(t *testing.T) {
+	tests := map[string]string{
+		"context":                           "context",
+		"example.com/shop/internal/domain":  "domain",
+		"github.com/sashabaranov/go-openai": "openai",
+		"gopkg.in/yaml.v3":                  "yaml",
+		"github.com/gofiber/fiber/v3":       "fiber",
+	}
+	for path, want := range tests {
+		if got := importName(path); got != want {
+			t.Errorf("importName(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestGenerateTestCode(t *testing.T) {
+	writeFixture(t, t.TempDir(), map[string]string{
+		"go.mod":                          "module example.com/shop\n\ngo 1.21\n",
+		"internal/domain/entity/order.go": "package entity\n\ntype Order struct {\n\tID    uint   `gorm:\"primaryKey\"`\n\tTitle string\n}\n",
+		"internal/domain/query/query.go":  "package query\n\ntype Page struct{ Size int }\n",
+		// 接口文件带有别名导入, 以及只被接口之外的声明使用的 errors 和 time
+		"internal/domain/repository/order_repository.go": `package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	ent "example.com/shop/internal/domain/entity"
+	"example.com/shop/internal/domain/query"
+)
+
+var ErrOrderNotFound = errors.New("order not found")
+
+const orderTTL = time.Minute
+
+type OrderRepository interface {
+	Create(ctx context.Context, order *ent.Order) error
+	FindByID(ctx context.Context, id uint) (*ent.Order, error)
+	FindPage(ctx context.Context, page query.Page, ids ...uint) ([]*ent.Order, int64, error)
+}
+`,
+	})
+	info, err := parseEntityFile(filepath.Join("internal", "domain", "entity", "order.go"), "example.com/shop")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	generateTestCode(info, PathConfig{IsDDD: true})
+
+	mock := filepath.Join("internal", "domain", "repository", "mocks", "order_repository_mock.go")
+	generated := []string{
+		mock,
+		filepath.Join("internal", "application", "service", "order_service_test.go"),
+		filepath.Join("internal", "interfaces", "handler", "order_handler_test.go"),
+		filepath.Join("internal", "infrastructure", "persistence", "order_repository_impl_test.go"),
+	}
+	// 生成的文件由命令最后的 FormatFile 统一格式化, 这里只要求可以解析
+	for _, path := range generated {
+		assertParses(t, path)
+	}
+	// 只有 mock 属于非测试包, 类型检查不依赖 gorm 等外部模块
+	typeCheckClean(t)
+
+	mockInfo, err := buildMockInfo(info, filepath.Join("internal", "domain", "repository", "order_repository.go"), "OrderRepository")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(append(mockInfo.StdImports, mockInfo.Imports...), " "); got != `"context" "sync" ent "example.com/shop/internal/domain/entity" "example.com/shop/internal/domain/query" "example.com/shop/internal/domain/repository"` {
+		t.Errorf("Imports = %s, want only the imports used by the method signatures, aliases kept", got)
+	}
+	if m := mockInfo.Methods[2]; m.Params != "p0 context.Context, p1 query.Page, p2 ...uint" || m.Args != "p0, p1, p2..." || m.Results != "(r0 []*ent.Order, r1 int64, r2 error)" {
+		t.Errorf("FindPage = %+v", m)
+	}
+}
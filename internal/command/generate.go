@@ -33,10 +33,16 @@ type PathConfig struct {
 	IsDDD              bool
 	DIFile             string
 	RouterFile         string
-	DIImports          []string
+	DIImports          []diImport
 	HandlerPackagePath string
 }
 
+// diImport 是 DI 容器需要导入的一个代码层的包, Path 相对于项目 module
+type diImport struct {
+	Layer string
+	Path  string
+}
+
 // FileGenerationTask 定义了单个文件的生成任务
 type FileGenerationTask struct {
 	TemplatePath string
@@ -44,6 +50,7 @@ type FileGenerationTask struct {
 	FileName     string
 	Suffix       string // e.g., "_repository", "_service"
 	IsSingular   bool   // for files like dto.go that don't have a suffix
+	Layer        string // 所属代码层, 用于 //gps:skip
 }
 
 type FieldInfo struct {
@@ -55,22 +62,158 @@ type FieldInfo struct {
 	IsAssociation bool
 	IsSlice       bool
 	BaseType      string
+	Hidden        bool // //gps:hidden, 不出现在响应 DTO 中
+	ReadOnly      bool // //gps:readonly, 不允许通过请求 DTO 写入
+}
+
+// UpdateType 返回更新请求 DTO 中的字段类型。字段都是指针, 未提供的字段不更新; 已经是指针的类型不再重复包装
+func (f FieldInfo) UpdateType() string {
+	return "*" + strings.TrimPrefix(f.Type, "*")
+}
+
+// IsPointer 判断实体字段本身是否是指针类型
+func (f FieldInfo) IsPointer() bool {
+	return strings.HasPrefix(f.Type, "*")
 }
 
 type EntityInfo struct {
-	ProjectModule   string
-	EntityName      string
-	LowerEntityName string
-	TableName       string
-	PrimaryKey      FieldInfo
-	Fields          []FieldInfo
-	NoCrudMethods   bool
+	ProjectModule      string
+	EntityName         string
+	LowerEntityName    string
+	TableName          string
+	PrimaryKey         FieldInfo
+	Fields             []FieldInfo
+	NoCrudMethods      bool
+	Routes             map[string]bool // //gps:routes, 为 nil 时启用全部 CRUD 路由
+	RoutePrefix        string          // //gps:prefix, 为空时使用 "/" + TableName
+	SkipLayers         map[string]bool // //gps:skip
+	Bulk               bool            // --bulk 或 //gps:bulk, 生成批量创建/更新/删除接口
+	Export             bool            // --export 或 //gps:export, 生成 CSV/XLSX 导出和导入接口
+	Cache              bool            // --cache 或 //gps:cache, 生成仓库的缓存装饰器
+	Events             bool            // --events 或 //gps:events, 服务在实体变更时发布领域事件 (仅 DDD)
+	Tenant             *FieldInfo      // 实体的 TenantID 字段, 非空时仓库按请求上下文中的租户隔离数据 (仅 DDD)
+	CreatedBy          *FieldInfo      // 实体的 CreatedBy 字段, 非空时服务在创建时填入当前用户 (仅 DDD)
+	UpdatedBy          *FieldInfo      // 实体的 UpdatedBy 字段, 非空时服务在创建和更新时填入当前用户 (仅 DDD)
+	Audit              bool            // --audit 或 //gps:audit, 记录变更历史并生成历史查询接口 (仅 DDD)
+	TableFromDirective bool
+	HasTableNameMethod bool
+}
+
+// HasRoute 判断指定的 CRUD 路由 (create, list, get, update, delete) 是否启用
+func (e *EntityInfo) HasRoute(name string) bool {
+	return e.Routes == nil || e.Routes[name]
+}
+
+// Skips 判断指定的代码层是否被 //gps:skip 跳过
+func (e *EntityInfo) Skips(layer string) bool {
+	return e.SkipLayers[layer]
+}
+
+// HasAuthorFields 判断服务是否需要填充 CreatedBy/UpdatedBy
+func (e *EntityInfo) HasAuthorFields() bool {
+	return e.CreatedBy != nil || e.UpdatedBy != nil
+}
+
+// markReadOnly 将指定字段标记为只读, 使其不出现在请求 DTO 中
+func (e *EntityInfo) markReadOnly(name string) {
+	for i := range e.Fields {
+		if e.Fields[i].Name == name {
+			e.Fields[i].ReadOnly = true
+		}
+	}
+}
+
+// RoutePath 返回实体路由组相对于 /api/v1 的路径
+func (e *EntityInfo) RoutePath() string {
+	if e.RoutePrefix != "" {
+		return e.RoutePrefix
+	}
+	return "/" + e.TableName
+}
+
+// FilterFields 返回导出时可以用于等值筛选的字段, 即响应 DTO 中的基本类型字段
+func (e *EntityInfo) FilterFields() []FieldInfo {
+	var fields []FieldInfo
+	for _, f := range e.ResponseFields() {
+		if filterableTypes[f.Type] {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// ResponseFields 返回需要出现在响应 DTO 中的字段
+func (e *EntityInfo) ResponseFields() []FieldInfo {
+	var fields []FieldInfo
+	for _, f := range e.Fields {
+		if !f.Hidden {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// WritableFields 返回可以通过创建/更新请求写入的字段 (排除主键、关联以及只读字段)
+func (e *EntityInfo) WritableFields() []FieldInfo {
+	var fields []FieldInfo
+	for _, f := range e.Fields {
+		if !f.ReadOnly && !f.IsAssociation && f.Name != e.PrimaryKey.Name {
+			fields = append(fields, f)
+		}
+	}
+	return fields
 }
 
 var generateCmd = &cobra.Command{
-	Use:     "generate [entity-file-path]",
-	Short:   "根据实体文件自动生成 Repository, Service, 和 Handler",
-	Long:    `根据检测到的项目结构 (标准或DDD), 读取指定的Go实体文件, 解析其结构, 并自动生成对应的CRUD代码层。`,
+	Use:   "generate [entity-file-path]",
+	Short: "根据实体文件自动生成 Repository, Service, 和 Handler",
+	Long: `根据检测到的项目结构 (标准或DDD), 读取指定的Go实体文件, 解析其结构, 并自动生成对应的CRUD代码层。
+默认还会生成仓库 mock、service/handler 的表驱动测试以及基于内存 SQLite 的仓库集成测试。
+
+实体文件中可以使用指令注释按实体调整生成结果:
+  结构体上: //gps:routes=get,list  //gps:readonly  //gps:prefix=/admin/users
+            //gps:skip=handler,grpc,graphql,tests  //gps:table=sys_users  //gps:bulk  //gps:export  //gps:cache  //gps:events  //gps:audit
+  字段上:   //gps:hidden (不出现在响应中)  //gps:readonly (不可通过请求写入)
+
+使用 --transport grpc|both 时额外生成 api/proto/<entity>.proto、protobuf/gRPC 的 Go 代码以及复用 Service 的 gRPC 服务,
+并注册到 DI 容器、在 main.go 中启动 gRPC 监听 (端口见 config.yaml 中的 grpc.port)。
+.proto 中的字段编号在重新生成时保持不变, 被删除字段的编号会被 reserved。
+
+使用 --graphql 时额外生成 api/graphql/<entity>.graphql 和复用 Service 的 GraphQL 解析器,
+输入类型取自 Create/Update 请求 DTO, 并在路由中挂载 /graphql。belongs-to 关联字段通过 GetByIDs 批量加载, 避免 N+1 查询。
+
+使用 --bulk (或在结构体上使用 //gps:bulk) 时额外生成 POST/PATCH/DELETE <路由前缀>/bulk 批量接口:
+仓库在事务中使用 CreateInBatches 和 WHERE id IN ? 写入, 响应中按请求下标逐条报告成功或失败,
+单次请求的条目上限和分批大小见 config.yaml 中的 bulk.max_items 与 bulk.batch_size。
+
+使用 --export (或在结构体上使用 //gps:export) 时额外生成 GET <路由前缀>/export?format=csv|xlsx 和 POST <路由前缀>/import:
+导出按主键游标分批读取并流式写出, 支持按响应 DTO 中标量字段等值筛选, 表头取自响应 DTO 的 json 名称;
+导入的表头对应创建请求 DTO 的 json 名称, 每行按 binding 规则校验, 存在错误时不导入任何数据并返回各行的错误。
+
+使用 --cache (或在结构体上使用 //gps:cache) 时额外生成实现同一 Repository 接口的缓存装饰器, 并在 DI 容器中替换原有的仓库:
+FindByID 优先读取缓存, Create/Update/Delete 及批量写入成功后使对应主键的缓存失效。
+缓存后端 (memory 为进程内 LRU, 或 redis) 与过期时间见 config.yaml 中的 cache 段, 可按表名覆盖过期时间。
+
+使用 --events (或在结构体上使用 //gps:events) 时, 服务在创建/更新/删除 (包括批量接口和导入) 时产生
+<Entity>Created/Updated/Deleted 领域事件 (internal/domain/event), 仓库在同一个事务中把事件写入 outbox_messages 表;
+main.go 中启动的 outbox relay 按顺序投递未发送的事件, 失败时退避重试, 投递语义为至少一次。
+发布者可以是进程内事件总线 (从 DI 容器获取 *outbox.Bus 订阅) 或 webhook, 见 config.yaml 中的 outbox 段。目前只支持 DDD 项目。
+
+实体包含 TenantID 字段时自动开启多租户 (仅 DDD 项目): 仓库的每个查询都限定在请求上下文中的租户内,
+创建时由上下文填充 TenantID (请求 DTO 不再接受该字段), 访问其他租户的记录返回 404。
+标准项目的仓库不接收 context, 无法按租户隔离, 包含 TenantID 的实体会被跳过并报错。
+生成的租户中间件挂载在实体的路由前缀上, 优先读取 JWT claims 中的租户, 其次读取请求头, 见 config.yaml 中的 tenant 段;
+使用 --transport grpc|both 时还会生成 gRPC 拦截器, 从与 tenant.header 同名的 metadata 读取租户, 缺少租户时返回 Unauthenticated。
+GraphQL 或后台任务调用服务时需要通过 tenant.WithTenant 传入租户, 否则查询返回 tenant.ErrMissing。
+
+实体包含 CreatedBy/UpdatedBy 字段时 (仅 DDD 项目), 服务在创建和更新时把当前用户写入这些字段 (请求 DTO 不再接受它们):
+当前用户取自 jwt 中间件放在 c.Locals("user") 中的 claims 的 sub, 后台任务可以通过 audit.WithActor 指定。
+标准项目的服务不接收 context, 包含这些字段的实体会被跳过并报错, 需要手动赋值时请给字段换一个名字。
+使用 --audit (或在结构体上使用 //gps:audit) 时 (仅 DDD 项目), 仓库通过 GORM 回调把每次创建、更新和删除的
+字段前后值以 JSON 写入 audit_logs 表 (与数据变更在同一个事务中), 并生成 GET <路由前缀>/:id/history 查询记录的变更历史。
+
+使用 -F 重新生成时, 生成器会根据 .goprojectstarter/generated.json 中记录的指纹识别手动修改过的声明:
+未修改的声明被替换, 新字段会合并进 DTO 和 mapper, 手动修改过的声明保持不变并在输出中列出。`,
 	Aliases: []string{"gen"},
 	Args:    cobra.ExactArgs(1),
 	Run:     runGenerate,
@@ -78,8 +221,17 @@ var generateCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(generateCmd)
-	generateCmd.Flags().BoolVarP(&forceGenerate, "force", "F", false, "强制覆盖已存在的文件")
+	generateCmd.Flags().BoolVarP(&forceGenerate, "force", "F", false, "重新生成已存在的文件, 只替换未被手动修改过的声明")
+	generateCmd.Flags().BoolVar(&overwriteGenerated, "overwrite", false, "与 -F 一起使用时整文件覆盖, 丢弃所有手动修改")
 	generateCmd.Flags().BoolVar(&noCrudMethods, "no-crud", false, "不要生成 CRUD 模板方法")
+	generateCmd.Flags().BoolVar(&noTestFiles, "no-tests", false, "不要生成仓库 mock 和各层的测试文件")
+	generateCmd.Flags().StringVar(&transport, "transport", "rest", "生成的接口类型: rest, grpc 或 both")
+	generateCmd.Flags().BoolVar(&generateGraphQLAPI, "graphql", false, "额外生成 GraphQL schema 和解析器, 挂载在 /graphql")
+	generateCmd.Flags().BoolVar(&generateBulk, "bulk", false, "额外生成批量创建/更新/删除接口 (<路由前缀>/bulk)")
+	generateCmd.Flags().BoolVar(&generateExport, "export", false, "额外生成 CSV/XLSX 导出和导入接口 (<路由前缀>/export, <路由前缀>/import)")
+	generateCmd.Flags().BoolVar(&generateCache, "cache", false, "额外生成仓库的缓存装饰器, 缓存 FindByID 并在写入后失效")
+	generateCmd.Flags().BoolVar(&generateEvents, "events", false, "服务在实体变更时发布领域事件, 通过 outbox 表可靠投递 (仅 DDD 项目)")
+	generateCmd.Flags().BoolVar(&generateAudit, "audit", false, "把实体的变更前后值记录到 audit_logs 表, 并生成 <路由前缀>/:id/history 接口 (仅 DDD 项目)")
 }
 
 // isDDDProject 通过检查关键目录是否存在来判断项目结构
@@ -90,6 +242,10 @@ func isDDDProject() bool {
 
 func runGenerate(cmd *cobra.Command, args []string) {
 	inputPath := args[0]
+	if !wantsREST() && !wantsGRPC() {
+		fmt.Printf("❌ 错误: 不支持的 --transport %q, 可选值为 rest, grpc, both\n", transport)
+		return
+	}
 
 	// 1. 获取所有需要处理的文件列表
 	filesToProcess, err := getFilesToProcess(inputPath)
@@ -109,10 +265,10 @@ func runGenerate(cmd *cobra.Command, args []string) {
 			DIFile:             "internal/di/container.go",
 			RouterFile:         "internal/infrastructure/router/router.go",
 			HandlerPackagePath: "/internal/interfaces/handler",
-			DIImports: []string{
-				"/internal/infrastructure/persistence",
-				"/internal/application/service",
-				"/internal/interfaces/handler",
+			DIImports: []diImport{
+				{Layer: "repository", Path: "/internal/infrastructure/persistence"},
+				{Layer: "service", Path: "/internal/application/service"},
+				{Layer: "handler", Path: "/internal/interfaces/handler"},
 			},
 		}
 	} else {
@@ -122,10 +278,10 @@ func runGenerate(cmd *cobra.Command, args []string) {
 			DIFile:             "internal/di/container.go",
 			RouterFile:         "internal/adapter/router/router.go",
 			HandlerPackagePath: "/internal/adapter/handler",
-			DIImports: []string{
-				"/internal/adapter/repository",
-				"/internal/usecase/service",
-				"/internal/adapter/handler",
+			DIImports: []diImport{
+				{Layer: "repository", Path: "/internal/adapter/repository"},
+				{Layer: "service", Path: "/internal/usecase/service"},
+				{Layer: "handler", Path: "/internal/adapter/handler"},
 			},
 		}
 	}
@@ -147,14 +303,93 @@ func runGenerate(cmd *cobra.Command, args []string) {
 			continue // 跳过这个文件，继续处理下一个
 		}
 		info.NoCrudMethods = noCrudMethods
+		info.Bulk = (info.Bulk || generateBulk) && !info.NoCrudMethods
+		info.Export = (info.Export || generateExport) && !info.NoCrudMethods
+		info.Cache = (info.Cache || generateCache) && !info.NoCrudMethods
+		info.Events = (info.Events || generateEvents) && !info.NoCrudMethods
+		if info.Events {
+			if err := checkEventsSupported(info, paths); err != nil {
+				fmt.Printf("   ⚠️ %v\n", err)
+				info.Events = false
+			}
+		}
+		if info.Tenant != nil && !info.NoCrudMethods {
+			if err := checkTenantSupported(info, paths); err != nil {
+				fmt.Printf("   ❌ %v\n", err)
+				continue
+			}
+			// 租户只能来自请求上下文, 不允许通过请求 DTO 写入
+			info.Tenant.ReadOnly = true
+			info.markReadOnly(tenantField)
+		} else {
+			info.Tenant = nil
+		}
+		if info.HasAuthorFields() && !info.NoCrudMethods {
+			if err := checkAuthorFieldsSupported(info, paths); err != nil {
+				fmt.Printf("   ❌ %v\n", err)
+				continue
+			}
+			// 操作者只能来自请求上下文, 不允许通过请求 DTO 写入
+			info.markReadOnly(createdByField)
+			info.markReadOnly(updatedByField)
+		} else {
+			info.CreatedBy, info.UpdatedBy = nil, nil
+		}
+		info.Audit = (info.Audit || generateAudit) && !info.NoCrudMethods
+		if info.Audit {
+			if err := checkAuditSupported(info, paths); err != nil {
+				fmt.Printf("   ⚠️ %v\n", err)
+				info.Audit = false
+			}
+		}
+		if !wantsREST() {
+			// 只生成 gRPC 时不需要 Fiber handler 和路由
+			if info.SkipLayers == nil {
+				info.SkipLayers = make(map[string]bool)
+			}
+			info.SkipLayers["handler"] = true
+		}
 		fmt.Printf(" ✓ 解析成功! 实体: %s, 表名: %s\n", info.EntityName, info.TableName)
+		printEffectiveSettings(info)
+		if err := ensureTableNameMethod(entityFilePath, info); err != nil {
+			fmt.Printf("   ⚠️ %v\n", err)
+		}
 
 		generateCode(info, paths)
+		generateTestCode(info, paths)
 
 		if err := addProviderToDI(info, paths); err != nil {
 			fmt.Printf("   ⚠️ 自动修改 %s 失败: %v\n", paths.DIFile, err)
 			continue
 		}
+		if info.Cache {
+			if err := generateCacheDecorator(info, paths); err != nil {
+				fmt.Printf("   ⚠️ %v\n", err)
+			}
+		}
+		if info.Events {
+			if err := generateEventRelay(info, paths); err != nil {
+				fmt.Printf("   ⚠️ %v\n", err)
+			}
+		}
+		if wantsGRPC() {
+			if err := generateGRPC(info, paths); err != nil {
+				fmt.Printf("   ⚠️ %v\n", err)
+			} else if info.Tenant != nil && !info.Skips("grpc") {
+				if err := generateTenantInterceptor(info, paths); err != nil {
+					fmt.Printf("   ⚠️ %v\n", err)
+				}
+			}
+		}
+		if generateGraphQLAPI {
+			if err := generateGraphQL(info, paths); err != nil {
+				fmt.Printf("   ⚠️ %v\n", err)
+			}
+		}
+		if info.Skips("handler") {
+			successfulEntities = append(successfulEntities, info)
+			continue
+		}
 		if err := addHandlerToRouter(info, paths); err != nil {
 			fmt.Printf("   ⚠️ 自动修改 %s 失败: %v\n", paths.RouterFile, err)
 			continue
@@ -165,6 +400,26 @@ func runGenerate(cmd *cobra.Command, args []string) {
 				continue
 			}
 		}
+		if info.Tenant != nil {
+			if err := generateTenantMiddleware(info, paths); err != nil {
+				fmt.Printf("   ⚠️ %v\n", err)
+			}
+		}
+		if info.Bulk {
+			if err := generateBulkAPI(info, paths); err != nil {
+				fmt.Printf("   ⚠️ %v\n", err)
+			}
+		}
+		if info.Export {
+			if err := generateExportAPI(info, paths); err != nil {
+				fmt.Printf("   ⚠️ %v\n", err)
+			}
+		}
+		if info.Audit {
+			if err := generateAuditAPI(info, paths); err != nil {
+				fmt.Printf("   ⚠️ %v\n", err)
+			}
+		}
 		successfulEntities = append(successfulEntities, info)
 	}
 
@@ -183,71 +438,116 @@ func generateCode(info *EntityInfo, paths PathConfig) {
 	var tasks []FileGenerationTask
 	if paths.IsDDD {
 		tasks = []FileGenerationTask{
-			{TemplatePath: "tmpl/generate/dto.go.ddd.tmpl", OutputDir: "internal/interfaces/dto", FileName: common.ToSnakeCase(info.EntityName), IsSingular: true},
-			{TemplatePath: "tmpl/generate/mapper.go.ddd.tmpl", OutputDir: "internal/interfaces/dto", Suffix: "_mapper"},
-			{TemplatePath: "tmpl/generate/repository_interface.go.ddd.tmpl", OutputDir: "internal/domain/repository", Suffix: "_repository"},
-			{TemplatePath: "tmpl/generate/repository_impl.go.ddd.tmpl", OutputDir: "internal/infrastructure/persistence", Suffix: "_repository_impl"},
-			{TemplatePath: "tmpl/generate/service.go.ddd.tmpl", OutputDir: "internal/application/service", Suffix: "_service"},
-			{TemplatePath: "tmpl/generate/handler.go.ddd.tmpl", OutputDir: "internal/interfaces/handler", Suffix: "_handler"},
+			{TemplatePath: "tmpl/generate/dto.go.ddd.tmpl", OutputDir: "internal/interfaces/dto", FileName: common.ToSnakeCase(info.EntityName), IsSingular: true, Layer: "dto"},
+			{TemplatePath: "tmpl/generate/mapper.go.ddd.tmpl", OutputDir: "internal/interfaces/dto", Suffix: "_mapper", Layer: "mapper"},
+			{TemplatePath: "tmpl/generate/repository_interface.go.ddd.tmpl", OutputDir: "internal/domain/repository", Suffix: "_repository", Layer: "repository"},
+			{TemplatePath: "tmpl/generate/repository_impl.go.ddd.tmpl", OutputDir: "internal/infrastructure/persistence", Suffix: "_repository_impl", Layer: "repository"},
+			{TemplatePath: "tmpl/generate/service.go.ddd.tmpl", OutputDir: "internal/application/service", Suffix: "_service", Layer: "service"},
+			{TemplatePath: "tmpl/generate/handler.go.ddd.tmpl", OutputDir: "internal/interfaces/handler", Suffix: "_handler", Layer: "handler"},
 		}
 	} else {
 		tasks = []FileGenerationTask{
-			{TemplatePath: "tmpl/generate/dto.go.tmpl", OutputDir: "internal/adapter/dto", FileName: common.ToSnakeCase(info.EntityName), IsSingular: true},
-			{TemplatePath: "tmpl/generate/repository_interface.go.tmpl", OutputDir: "internal/domain/ports", Suffix: "_repository"},
-			{TemplatePath: "tmpl/generate/repository_impl.go.tmpl", OutputDir: "internal/adapter/repository", Suffix: "_repository_impl"},
-			{TemplatePath: "tmpl/generate/service.go.tmpl", OutputDir: "internal/usecase/service", Suffix: "_service"},
-			{TemplatePath: "tmpl/generate/handler.go.tmpl", OutputDir: "internal/adapter/handler", Suffix: "_handler"},
+			{TemplatePath: "tmpl/generate/dto.go.tmpl", OutputDir: "internal/adapter/dto", FileName: common.ToSnakeCase(info.EntityName), IsSingular: true, Layer: "dto"},
+			{TemplatePath: "tmpl/generate/repository_interface.go.tmpl", OutputDir: "internal/domain/ports", Suffix: "_repository", Layer: "repository"},
+			{TemplatePath: "tmpl/generate/repository_impl.go.tmpl", OutputDir: "internal/adapter/repository", Suffix: "_repository_impl", Layer: "repository"},
+			{TemplatePath: "tmpl/generate/service.go.tmpl", OutputDir: "internal/usecase/service", Suffix: "_service", Layer: "service"},
+			{TemplatePath: "tmpl/generate/handler.go.tmpl", OutputDir: "internal/adapter/handler", Suffix: "_handler", Layer: "handler"},
 		}
 	}
 
 	for _, task := range tasks {
-		fileName := task.FileName
-		if !task.IsSingular {
-			fileName = common.ToSnakeCase(info.EntityName) + task.Suffix
+		if info.Skips(task.Layer) {
+			fmt.Printf("   ⏭️ //gps:skip=%s, 跳过 %s\n", task.Layer, task.TemplatePath)
+			continue
 		}
-		fullPath := filepath.Join(task.OutputDir, fileName+".go")
+		renderTask(task, info.EntityName, info)
+	}
+	if info.Events {
+		renderEventFiles(info)
+	}
+	if info.Bulk && !info.Skips("dto") {
+		renderBulkDTO(info, paths)
+	}
+	if info.Export && !info.Skips("dto") {
+		renderImportDTO(info, paths)
+	}
+}
 
-		fmt.Printf("  -> 正在处理 %s...\n", fullPath)
+// renderTask 渲染单个模板任务并写入目标文件, data 为模板的渲染数据
+func renderTask(task FileGenerationTask, entityName string, data any) {
+	fileName := task.FileName
+	if !task.IsSingular {
+		fileName = common.ToSnakeCase(entityName) + task.Suffix
+	}
+	fullPath := filepath.Join(task.OutputDir, fileName+".go")
 
-		if _, err := os.Stat(fullPath); err == nil {
-			if !forceGenerate {
-				fmt.Printf("     文件已存在, 跳过生成。请使用 -F 或 --force 选项来覆盖。\n")
-				continue
-			}
-			fmt.Printf("     文件已存在, 正在强制覆盖...\n")
-		} else if !os.IsNotExist(err) {
-			fmt.Printf("     检查文件 %s 状态时出错: %v\n", fullPath, err)
-			continue
-		}
+	fmt.Printf("  -> 正在处理 %s...\n", fullPath)
 
-		if err := os.MkdirAll(task.OutputDir, 0o755); err != nil {
-			fmt.Printf("     创建目录 %s 失败: %v\n", task.OutputDir, err)
-			continue
+	fileExists := false
+	if _, err := os.Stat(fullPath); err == nil {
+		if !forceGenerate {
+			fmt.Printf("     文件已存在, 跳过生成。请使用 -F 或 --force 选项来重新生成。\n")
+			return
 		}
+		fileExists = true
+	} else if !os.IsNotExist(err) {
+		fmt.Printf("     检查文件 %s 状态时出错: %v\n", fullPath, err)
+		return
+	}
 
-		// 为模板添加自定义函数
-		funcMap := template.FuncMap{
-			"toLowerCamel": toLowerCamel,
-		}
+	if err := os.MkdirAll(task.OutputDir, 0o755); err != nil {
+		fmt.Printf("     创建目录 %s 失败: %v\n", task.OutputDir, err)
+		return
+	}
+
+	// 为模板添加自定义函数
+	funcMap := template.FuncMap{
+		"toLowerCamel": toLowerCamel,
+	}
+
+	tmpl, err := template.New(filepath.Base(task.TemplatePath)).Funcs(funcMap).ParseFS(generateTemplates, task.TemplatePath)
+	if err != nil {
+		fmt.Printf("     读取嵌入的模板 %s 失败: %v\n", task.TemplatePath, err)
+		return
+	}
 
-		tmpl, err := template.New(filepath.Base(task.TemplatePath)).Funcs(funcMap).ParseFS(generateTemplates, task.TemplatePath)
+	var tpl bytes.Buffer
+	if err := tmpl.Execute(&tpl, data); err != nil {
+		fmt.Printf("     渲染模板 %s 失败: %v\n", task.TemplatePath, err)
+		return
+	}
+
+	output := tpl.Bytes()
+	hashes, err := hashDecls(output)
+	if err != nil {
+		fmt.Printf("     解析模板 %s 的渲染结果失败: %v\n", task.TemplatePath, err)
+		return
+	}
+
+	if fileExists && overwriteGenerated {
+		fmt.Printf("     文件已存在, 正在强制覆盖...\n")
+	} else if fileExists {
+		fmt.Printf("     文件已存在, 正在安全地重新生成...\n")
+		existing, err := os.ReadFile(fullPath)
 		if err != nil {
-			fmt.Printf("     读取嵌入的模板 %s 失败: %v\n", task.TemplatePath, err)
-			continue
+			fmt.Printf("     读取文件 %s 失败: %v\n", fullPath, err)
+			return
 		}
-
-		var tpl bytes.Buffer
-		if err := tmpl.Execute(&tpl, info); err != nil {
-			fmt.Printf("     渲染模板 %s 失败: %v\n", task.TemplatePath, err)
-			continue
+		var report *regenReport
+		output, hashes, report, err = mergeGenerated(existing, output, recordedHashes(fullPath))
+		if err != nil {
+			fmt.Printf("     合并 %s 失败, 文件保持不变: %v\n", fullPath, err)
+			return
 		}
+		printRegenReport(report)
+	}
 
-		if err := os.WriteFile(fullPath, tpl.Bytes(), 0o644); err != nil {
-			fmt.Printf("     写入文件 %s 失败: %v\n", fullPath, err)
-		} else {
-			fmt.Printf("     成功生成文件: %s\n", fullPath)
-		}
+	if err := os.WriteFile(fullPath, output, 0o644); err != nil {
+		fmt.Printf("     写入文件 %s 失败: %v\n", fullPath, err)
+		return
 	}
+	recordGenerated(fullPath, hashes)
+	fmt.Printf("     成功生成文件: %s\n", fullPath)
 }
 
 func getProjectModule() (string, error) {
@@ -274,6 +574,17 @@ func isKnownType(typeName string) bool {
 	return knownTypes[typeName]
 }
 
+// fieldBaseType 去掉字段类型的 [] 和 * 前缀
+func fieldBaseType(fieldType string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(fieldType, "[]"), "*")
+}
+
+// isAssociationType 判断字段类型是否引用了其他实体 (例如 *Album 或 []Song)
+func isAssociationType(fieldType string) bool {
+	baseType := fieldBaseType(fieldType)
+	return baseType != "" && !isKnownType(baseType) && unicode.IsUpper([]rune(baseType)[0])
+}
+
 // convertToDTOType 将实体类型转换为 DTO 响应类型
 func convertToDTOType(entityType string) string {
 	// 正则表达式匹配可选的 `[]` 或 `*` 前缀和一个大写字母开头的单词
@@ -296,7 +607,7 @@ func convertToDTOType(entityType string) string {
 
 func parseEntityFile(filePath, projectModule string) (*EntityInfo, error) {
 	fset := token.NewFileSet()
-	node, err := parser.ParseFile(fset, filePath, nil, 0)
+	node, err := parser.ParseFile(fset, filePath, nil, parser.ParseComments)
 	if err != nil {
 		return nil, err
 	}
@@ -305,12 +616,25 @@ func parseEntityFile(filePath, projectModule string) (*EntityInfo, error) {
 		ProjectModule: projectModule,
 	}
 
+	var directiveErr error
+	var lastGenDecl *ast.GenDecl
 	ast.Inspect(node, func(n ast.Node) bool {
+		if genDecl, ok := n.(*ast.GenDecl); ok {
+			lastGenDecl = genDecl
+		}
 		if typeSpec, ok := n.(*ast.TypeSpec); ok {
 			if structType, ok := typeSpec.Type.(*ast.StructType); ok {
 				info.EntityName = typeSpec.Name.Name
 				info.LowerEntityName = toLowerCamel(info.EntityName)
 
+				structDoc := typeSpec.Doc
+				if structDoc == nil && lastGenDecl != nil {
+					structDoc = lastGenDecl.Doc
+				}
+				if err := applyEntityDirectives(info, parseDirectives(structDoc)); err != nil && directiveErr == nil {
+					directiveErr = err
+				}
+
 				for _, field := range structType.Fields.List {
 					if len(field.Names) == 0 {
 						continue
@@ -343,8 +667,8 @@ func parseEntityFile(filePath, projectModule string) (*EntityInfo, error) {
 
 					// --- 新增的元数据解析逻辑 ---
 					isSlice := strings.HasPrefix(fieldType, "[]")
-					baseType := strings.TrimPrefix(strings.TrimPrefix(fieldType, "[]"), "*")
-					isAssociation := !isKnownType(baseType) && unicode.IsUpper([]rune(baseType)[0])
+					baseType := fieldBaseType(fieldType)
+					isAssociation := isAssociationType(fieldType)
 
 					fieldInfo := FieldInfo{
 						Name:          fieldName,
@@ -356,7 +680,21 @@ func parseEntityFile(filePath, projectModule string) (*EntityInfo, error) {
 						IsSlice:       isSlice,
 						BaseType:      baseType,
 					}
+					if err := applyFieldDirectives(&fieldInfo, parseDirectives(field.Doc, field.Comment)); err != nil && directiveErr == nil {
+						directiveErr = err
+					}
 					info.Fields = append(info.Fields, fieldInfo)
+					switch fieldName {
+					case tenantField:
+						tenant := fieldInfo
+						info.Tenant = &tenant
+					case createdByField:
+						createdBy := fieldInfo
+						info.CreatedBy = &createdBy
+					case updatedByField:
+						updatedBy := fieldInfo
+						info.UpdatedBy = &updatedBy
+					}
 
 					if isPrimaryKey {
 						info.PrimaryKey = fieldInfo
@@ -366,11 +704,17 @@ func parseEntityFile(filePath, projectModule string) (*EntityInfo, error) {
 		}
 
 		if fn, ok := n.(*ast.FuncDecl); ok && fn.Name.Name == "TableName" {
+			info.HasTableNameMethod = true
 			if len(fn.Body.List) > 0 {
 				if retStmt, ok := fn.Body.List[0].(*ast.ReturnStmt); ok {
 					if len(retStmt.Results) > 0 {
 						if basicLit, ok := retStmt.Results[0].(*ast.BasicLit); ok {
-							info.TableName = strings.Trim(basicLit.Value, `"`)
+							tableName := strings.Trim(basicLit.Value, `"`)
+							if !info.TableFromDirective {
+								info.TableName = tableName
+							} else if tableName != info.TableName {
+								fmt.Printf("   ⚠️ //gps:table=%s 与 TableName() 返回的 %s 不一致, 将以指令为准\n", info.TableName, tableName)
+							}
 						}
 					}
 				}
@@ -379,6 +723,9 @@ func parseEntityFile(filePath, projectModule string) (*EntityInfo, error) {
 		return true
 	})
 
+	if directiveErr != nil {
+		return nil, directiveErr
+	}
 	if info.EntityName == "" {
 		return nil, fmt.Errorf("在文件中未找到任何 struct 定义")
 	}
@@ -386,13 +733,18 @@ func parseEntityFile(filePath, projectModule string) (*EntityInfo, error) {
 		return nil, fmt.Errorf("未找到 gorm:\"primaryKey\" 标签，请在主键字段上明确添加")
 	}
 	if info.TableName == "" {
-		info.TableName = common.ToSnakeCase(info.EntityName) + "s"
+		info.TableName = defaultTableName(info.EntityName)
 		fmt.Printf("   未找到 TableName() 方法, 将使用默认表名: %s\n", info.TableName)
 	}
 
 	return info, nil
 }
 
+// defaultTableName 返回没有 TableName() 方法和 //gps:table 指令时使用的表名, 也是默认的路由路径
+func defaultTableName(entityName string) string {
+	return common.ToSnakeCase(entityName) + "s"
+}
+
 func printNextSteps(info *EntityInfo) {
 	cmd := exec.Command("goimports", "-l", "-w", ".")
 	cmd.Run()
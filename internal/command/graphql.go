@@ -0,0 +1,346 @@
+package command
+
+import (
+	"fmt"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/Skyenought/goprojectstarter/internal/common"
+)
+
+const graphqlSchemaDir = "api/graphql"
+
+// generateGraphQLAPI 控制 generate 是否额外生成 GraphQL schema 和解析器
+var generateGraphQLAPI bool
+
+// gqlField 描述 GraphQL 对象类型或输入类型中的一个字段
+type gqlField struct {
+	Name    string // GraphQL 字段名
+	GoType  string // graphql-go 中的类型表达式
+	SDLType string // SDL 中的类型
+	Value   string // 输出字段从 src (响应 DTO) 中取值的表达式
+}
+
+// gqlRelation 描述实体的关联字段
+type gqlRelation struct {
+	Name      string
+	Target    string
+	GoName    string // has-many: 响应 DTO 中的字段名
+	FK        string // belongs-to: 外键字段名
+	FKPointer bool
+}
+
+type gqlNames struct {
+	One, Many, Create, Update, Delete string
+}
+
+// graphqlData 是 GraphQL 解析器和 SDL 模板的渲染数据
+type graphqlData struct {
+	*EntityInfo
+	Names       gqlNames
+	Fields      []gqlField
+	BelongsTo   []gqlRelation
+	HasMany     []gqlRelation
+	CreateInput []gqlField
+	UpdateInput []gqlField
+}
+
+// gqlScalarTypes 是 Go 类型到 GraphQL 标量的映射
+var gqlScalarTypes = map[string]string{
+	"string": "String",
+	"bool":   "Boolean",
+	"int":    "Int", "int8": "Int", "int16": "Int", "int32": "Int", "int64": "Int", "rune": "Int",
+	"uint": "Int", "uint8": "Int", "uint16": "Int", "uint32": "Int", "uint64": "Int", "byte": "Int",
+	"float32":   "Float",
+	"float64":   "Float",
+	"time.Time": "DateTime",
+	"uuid.UUID": "String",
+}
+
+// gqlDir 返回 GraphQL 解析器所在的目录
+func (p PathConfig) gqlDir() string {
+	if p.IsDDD {
+		return "internal/interfaces/gql"
+	}
+	return "internal/adapter/gql"
+}
+
+// dtoDir 返回 DTO 所在的目录
+func (p PathConfig) dtoDir() string {
+	if p.IsDDD {
+		return "internal/interfaces/dto"
+	}
+	return "internal/adapter/dto"
+}
+
+// generateGraphQL 为实体生成 SDL 和基于 Service 的解析器, 并把 /graphql 端点挂载到路由上
+func generateGraphQL(info *EntityInfo, paths PathConfig) error {
+	if info.Skips("graphql") {
+		fmt.Println("   ⏭️ //gps:skip=graphql, 跳过 GraphQL 代码生成")
+		return nil
+	}
+	if info.NoCrudMethods || info.Skips("service") || info.Skips("dto") {
+		return fmt.Errorf("GraphQL 解析器依赖生成的 CRUD Service 和 DTO, 已跳过 %s 的 GraphQL 代码生成", info.EntityName)
+	}
+	if _, ok := gqlScalarTypes[info.PrimaryKey.Type]; !ok || info.PrimaryKey.Type == "uuid.UUID" {
+		return fmt.Errorf("暂不支持类型为 %s 的主键生成 GraphQL 解析器", info.PrimaryKey.Type)
+	}
+
+	fmt.Printf("  -> 正在为 %s 生成 GraphQL 解析器...\n", info.EntityName)
+	if err := renderGraphQL(info, paths); err != nil {
+		return err
+	}
+
+	importPath := info.ProjectModule + "/" + paths.gqlDir()
+	if err := addExtraProvidersToDI(paths, importPath,
+		diProvider{Comment: "GraphQL", Expr: "gql.NewHandler"},
+		diProvider{Comment: info.EntityName + " GraphQL Providers", Expr: "gql.New" + info.EntityName + "Resolver"},
+	); err != nil {
+		return fmt.Errorf("自动修改 %s 失败: %w", paths.DIFile, err)
+	}
+	return addGraphQLRoute(paths, importPath)
+}
+
+// renderGraphQL 渲染实体的 SDL 和解析器, 以及所有实体共享的 schema.graphql 和 schema.go
+func renderGraphQL(info *EntityInfo, paths PathConfig) error {
+	data, err := buildGraphQLData(info, paths)
+	if err != nil {
+		return err
+	}
+
+	sdl, err := renderEmbedded("tmpl/generate/graphql/entity.graphql.tmpl", data)
+	if err != nil {
+		return err
+	}
+	basePath := filepath.Join(graphqlSchemaDir, "schema.graphql")
+	if _, err := os.Stat(basePath); os.IsNotExist(err) {
+		base, err := renderEmbedded("tmpl/generate/graphql/schema.graphql.tmpl", nil)
+		if err != nil {
+			return err
+		}
+		if err := writeGeneratedFile(basePath, append(base, '\n')); err != nil {
+			return err
+		}
+	}
+	sdlPath := filepath.Join(graphqlSchemaDir, common.ToSnakeCase(info.EntityName)+".graphql")
+	if err := writeGeneratedFile(sdlPath, append(sdl, '\n')); err != nil {
+		return err
+	}
+
+	resolverTemplate := "tmpl/generate/graphql/resolver.go.tmpl"
+	if paths.IsDDD {
+		resolverTemplate = "tmpl/generate/graphql/resolver.go.ddd.tmpl"
+	}
+	// schema.go 由所有实体共享, 只在首次生成或使用 -F 时渲染
+	if _, err := os.Stat(filepath.Join(paths.gqlDir(), "schema.go")); os.IsNotExist(err) || forceGenerate {
+		renderTask(FileGenerationTask{TemplatePath: "tmpl/generate/graphql/schema.go.tmpl", OutputDir: paths.gqlDir(), FileName: "schema", IsSingular: true, Layer: "graphql"}, info.EntityName, info)
+	}
+	renderTask(FileGenerationTask{TemplatePath: resolverTemplate, OutputDir: paths.gqlDir(), Suffix: "_resolver", Layer: "graphql"}, info.EntityName, data)
+	return nil
+}
+
+func buildGraphQLData(info *EntityInfo, paths PathConfig) (*graphqlData, error) {
+	name := info.EntityName
+	data := &graphqlData{
+		EntityInfo: info,
+		Names: gqlNames{
+			One:    toLowerCamel(name),
+			Many:   toLowerCamel(pluralName(name)),
+			Create: "create" + name,
+			Update: "update" + name,
+			Delete: "delete" + name,
+		},
+	}
+
+	// 外键需要出现在响应 DTO 中, belongs-to 关联才能据此批量加载
+	responseFields := make(map[string]FieldInfo)
+	for _, f := range info.ResponseFields() {
+		responseFields[f.Name] = f
+	}
+	for _, f := range info.ResponseFields() {
+		if f.IsAssociation {
+			relation := gqlRelation{Name: gqlFieldName(f.Name), Target: f.BaseType, GoName: f.Name}
+			if f.IsSlice {
+				data.HasMany = append(data.HasMany, relation)
+				continue
+			}
+			fk, ok := responseFields[f.Name+"ID"]
+			if !ok {
+				fmt.Printf("     ⚠️ 关联字段 %s 缺少外键字段 %sID, 已跳过\n", f.Name, f.Name)
+				continue
+			}
+			relation.FK = fk.Name
+			relation.FKPointer = strings.HasPrefix(fk.Type, "*")
+			data.BelongsTo = append(data.BelongsTo, relation)
+			continue
+		}
+
+		field, ok := gqlOutputField(f, f.Name == info.PrimaryKey.Name)
+		if !ok {
+			fmt.Printf("     ⚠️ 字段 %s 的类型 %s 无法映射到 GraphQL, 已跳过\n", f.Name, f.Type)
+			continue
+		}
+		data.Fields = append(data.Fields, field)
+	}
+
+	dtoFile := filepath.Join(paths.dtoDir(), common.ToSnakeCase(name)+".go")
+	var err error
+	if info.HasRoute("create") {
+		if data.CreateInput, err = gqlInputFields(dtoFile, "Create"+name+"Request"); err != nil {
+			return nil, err
+		}
+	}
+	if info.HasRoute("update") {
+		if data.UpdateInput, err = gqlInputFields(dtoFile, "Update"+name+"Request"); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// gqlOutputField 根据响应 DTO 字段生成对象类型字段, 非指针字段为非空类型
+func gqlOutputField(f FieldInfo, isPrimaryKey bool) (gqlField, bool) {
+	field := gqlField{Name: gqlFieldName(f.Name), Value: "src." + f.Name}
+	if isPrimaryKey {
+		field.GoType, field.SDLType = "graphql.NewNonNull(graphql.ID)", "ID!"
+		return field, true
+	}
+	goType, sdl, ok := gqlTypeOf(f.Type, !strings.HasPrefix(f.Type, "*"))
+	if !ok || (f.Type != "uuid.UUID" && strings.Contains(f.Type, "uuid.UUID")) {
+		return field, false
+	}
+	if f.Type == "uuid.UUID" {
+		field.Value += ".String()"
+	}
+	field.GoType, field.SDLType = goType, sdl
+	return field, true
+}
+
+// gqlInputFields 从 DTO 文件中读取请求结构体的字段, 输入字段名与 json tag 保持一致,
+// 带有 binding:"required" 的字段为非空类型
+func gqlInputFields(dtoFile, structName string) ([]gqlField, error) {
+	fset := token.NewFileSet()
+	node, err := parser.ParseFile(fset, dtoFile, nil, 0)
+	if err != nil {
+		return nil, fmt.Errorf("解析 DTO 文件 %s 失败: %w", dtoFile, err)
+	}
+	st := findStruct(node, structName)
+	if st == nil {
+		return nil, fmt.Errorf("在 %s 中未找到 %s", dtoFile, structName)
+	}
+
+	var fields []gqlField
+	for _, field := range st.Fields.List {
+		if len(field.Names) == 0 {
+			continue
+		}
+		var tag reflect.StructTag
+		if field.Tag != nil {
+			value, _ := strconv.Unquote(field.Tag.Value)
+			tag = reflect.StructTag(value)
+		}
+		jsonName, _, _ := strings.Cut(tag.Get("json"), ",")
+		if jsonName == "-" {
+			continue
+		}
+		for _, ident := range field.Names {
+			if !ident.IsExported() {
+				continue
+			}
+			name := jsonName
+			if name == "" {
+				name = ident.Name
+			}
+			typ := types.ExprString(field.Type)
+			required := strings.Contains(tag.Get("binding"), "required")
+			goType, sdl, ok := gqlTypeOf(typ, required && !strings.HasPrefix(typ, "*"))
+			if !ok {
+				fmt.Printf("     ⚠️ %s.%s 的类型 %s 无法映射到 GraphQL, 已跳过\n", structName, ident.Name, typ)
+				continue
+			}
+			fields = append(fields, gqlField{Name: name, GoType: goType, SDLType: sdl})
+		}
+	}
+	return fields, nil
+}
+
+// gqlTypeOf 返回 Go 类型对应的 graphql-go 类型表达式和 SDL 类型
+func gqlTypeOf(goType string, nonNull bool) (string, string, bool) {
+	goType = strings.TrimPrefix(goType, "*")
+	isList := strings.HasPrefix(goType, "[]") && goType != "[]byte"
+	scalar, ok := gqlScalarTypes[strings.TrimPrefix(goType, "[]")]
+	if !ok {
+		return "", "", false
+	}
+
+	expr, sdl := "graphql."+scalar, scalar
+	if isList {
+		expr, sdl = "graphql.NewList(graphql.NewNonNull("+expr+"))", "["+sdl+"!]"
+	}
+	if nonNull {
+		expr, sdl = "graphql.NewNonNull("+expr+")", sdl+"!"
+	}
+	return expr, sdl, true
+}
+
+// gqlFieldName 将 Go 字段名转换为 GraphQL 字段名, 开头的缩写整体转为小写 (ID -> id, URLPath -> urlPath)
+func gqlFieldName(name string) string {
+	r := []rune(name)
+	upper := 0
+	for upper < len(r) && unicode.IsUpper(r[upper]) {
+		upper++
+	}
+	switch {
+	case upper == 0:
+		return name
+	case upper == len(r) || upper == 1:
+		upper = max(upper, 1)
+	default:
+		// 最后一个大写字母属于下一个单词
+		upper--
+	}
+	for i := 0; i < upper; i++ {
+		r[i] = unicode.ToLower(r[i])
+	}
+	return string(r)
+}
+
+// addGraphQLRoute 将 GraphQL 处理函数注入 Router 并挂载 /graphql 路由
+func addGraphQLRoute(paths PathConfig, importPath string) error {
+	content, err := os.ReadFile(paths.RouterFile)
+	if err != nil {
+		return err
+	}
+	if strings.Contains(string(content), "// GraphQL routes") {
+		return nil
+	}
+
+	fmt.Printf("  -> Modifying %s (mounting /graphql)...\n", paths.RouterFile)
+	if err := injectRouterDependency(paths.RouterFile, "GraphQLHandler", "*gql.Handler", importPath); err != nil {
+		return err
+	}
+	if content, err = os.ReadFile(paths.RouterFile); err != nil {
+		return err
+	}
+	anchor := "// [GENERATOR ANCHOR] - Don't remove this comment!"
+	if !strings.Contains(string(content), anchor) {
+		return fmt.Errorf("未在 %s 中找到锚点注释 %q", paths.RouterFile, anchor)
+	}
+	routes := "// GraphQL routes\n\tr.App.Post(\"/graphql\", r.GraphQLHandler.Serve)\n\tr.App.Get(\"/graphql\", r.GraphQLHandler.Serve)\n\n\t" + anchor
+	newContent := strings.Replace(string(content), anchor, routes, 1)
+
+	formatted, err := format.Source([]byte(newContent))
+	if err != nil {
+		fmt.Printf("    ⚠️ Code formatting failed: %v. Writing unformatted code.\n", err)
+		return os.WriteFile(paths.RouterFile, []byte(newContent), 0o644)
+	}
+	return os.WriteFile(paths.RouterFile, formatted, 0o644)
+}
@@ -0,0 +1,86 @@
+package command
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGQLFieldName(t *testing.T) {
+	tests := map[string]string{
+		"ID":        "id",
+		"AlbumID":   "albumID",
+		"URLPath":   "urlPath",
+		"Title":     "title",
+		"CreatedAt": "createdAt",
+		"name":      "name",
+	}
+	for in, want := range tests {
+		if got := gqlFieldName(in); got != want {
+			t.Errorf("gqlFieldName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// TestGraphQLAssociations 为一对互相关联的实体生成 schema 和解析器, 然后在示例项目中编译并运行
+// testdata/graphql/project/internal/interfaces/gql/resolver_test.go: 检查 SDL 可以被解析,
+// 以及 belongs-to 关联通过一次 GetByIDs 批量加载, 而不是每个父对象查询一次
+func TestGraphQLAssociations(t *testing.T) {
+	testdata := copyFixture(t, "graphql")
+	paths := PathConfig{IsDDD: true}
+	for _, name := range []string{"album", "song"} {
+		info, err := parseEntityFile(filepath.Join("internal/domain/entity", name+".go"), "example.com/music")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := renderGraphQL(info, paths); err != nil {
+			t.Fatalf("renderGraphQL(%s) error = %v", info.EntityName, err)
+		}
+	}
+	resolvers := []string{"internal/interfaces/gql/schema.go", "internal/interfaces/gql/album_resolver.go", "internal/interfaces/gql/song_resolver.go"}
+	for _, path := range resolvers {
+		assertParses(t, path)
+	}
+	checkGoldenFiles(t, filepath.Join(testdata, "golden"), append(resolvers, "api/graphql/schema.graphql", "api/graphql/album.graphql", "api/graphql/song.graphql")...)
+
+	// belongs-to 关联只登记外键, 由 Album 的加载器通过一次 GetByIDs 批量加载
+	song, _ := os.ReadFile("internal/interfaces/gql/song_resolver.go")
+	album, _ := os.ReadFile("internal/interfaces/gql/album_resolver.go")
+	if !strings.Contains(string(song), `b.Type("Album"), b.Loader("Album")`) || !strings.Contains(string(song), "return load(p.Context, src.AlbumID), nil") {
+		t.Errorf("Song.album 没有使用 Album 的批量加载器")
+	}
+	if !strings.Contains(string(album), `b.AddLoader("Album", graphqlx.KeyedLoader("Album", r.batchGet))`) || strings.Count(string(album), "r.service.GetByIDs(") != 1 {
+		t.Errorf("Album 没有注册基于 GetByIDs 的批量加载器")
+	}
+
+	// 编译并运行示例项目中的测试, 示例项目使用本仓库中的 pkg/graphqlx
+	if testing.Short() {
+		t.Skip("-short 时不编译示例项目")
+	}
+	pkgDir, err := filepath.Abs(filepath.Join(testdata, "..", "..", "..", "..", "pkg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mod, err := os.OpenFile("go.mod", os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mod.WriteString("\nreplace github.com/Skyenought/goprojectstarter/pkg => " + pkgDir + "\n"); err != nil {
+		t.Fatal(err)
+	}
+	mod.Close()
+	env := append(os.Environ(), "GOWORK=off", "GOFLAGS=-mod=mod")
+	tidy := exec.Command("go", "mod", "tidy")
+	tidy.Env = env
+	if out, err := tidy.CombinedOutput(); err != nil {
+		// 离线等无法下载依赖的环境中只做上面的检查
+		t.Skipf("无法下载示例项目的依赖, 跳过运行: %v\n%s", err, out)
+	}
+	cmd := exec.Command("go", "test", "./internal/interfaces/gql/")
+	cmd.Env = env
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("示例项目的测试失败: %v\n%s", err, out)
+	}
+}
@@ -0,0 +1,633 @@
+package command
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/format"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/Skyenought/goprojectstarter/internal/common"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+const (
+	protoDir        = "api/proto"
+	protoPackage    = "api.v1"
+	timestampProto  = "google/protobuf/timestamp.proto"
+	grpcAnchor      = "// [GRPC ANCHOR] - Don't remove this comment!"
+	defaultGRPCPort = 9090
+	// protocGenGo 是 PATH 中没有 protoc-gen-go 时通过 go run 使用的插件, 版本与本工具依赖的 protobuf 运行时一致
+	protocGenGo = "google.golang.org/protobuf/cmd/protoc-gen-go@v1.36.8"
+)
+
+// transport 控制 generate 生成的接口类型: rest, grpc 或 both
+var transport string
+
+func wantsREST() bool { return transport == "rest" || transport == "both" }
+func wantsGRPC() bool { return transport == "grpc" || transport == "both" }
+
+// protoField 描述 .proto 消息中的一个字段
+type protoField struct {
+	Name     string // snake_case 字段名
+	JSONName string // 与 DTO 的 json tag 保持一致
+	Type     string // 标量类型或完整的消息类型名
+	Label    string // "", "optional" 或 "repeated"
+	Number   int32
+}
+
+type protoMessage struct {
+	Name            string
+	Fields          []*protoField
+	ReservedNumbers []int32
+	ReservedNames   []string
+}
+
+type protoRPC struct {
+	Name     string
+	Request  string
+	Response string
+}
+
+// protoFile 同时用于渲染 .proto 文本和构造传给 protoc-gen-go 的文件描述符
+type protoFile struct {
+	Path          string
+	Package       string
+	GoPackage     string
+	GoPackageName string
+	Imports       []string
+	Messages      []*protoMessage
+	Service       string
+	FullService   string
+	LowerService  string
+	Source        string
+	RPCs          []protoRPC
+}
+
+// rpcNames 是实体各 CRUD 操作对应的 RPC 方法名
+type rpcNames struct {
+	Create, List, Get, Update, Delete string
+}
+
+// grpcServerData 是 gRPC 服务实现模板的渲染数据
+type grpcServerData struct {
+	*EntityInfo
+	RPC      rpcNames
+	PKGetter string
+}
+
+// protoScalarTypes 是 Go 类型到 protobuf 类型的映射
+var protoScalarTypes = map[string]string{
+	"string": "string",
+	"bool":   "bool",
+	"int":    "int64", "int64": "int64",
+	"int8": "int32", "int16": "int32", "int32": "int32", "rune": "int32",
+	"uint": "uint64", "uint64": "uint64",
+	"uint8": "uint32", "uint16": "uint32", "uint32": "uint32", "byte": "uint32",
+	"float32":        "float",
+	"float64":        "double",
+	"uuid.UUID":      "string",
+	"time.Time":      "google.protobuf.Timestamp",
+	"gorm.DeletedAt": "google.protobuf.Timestamp",
+	"sql.NullTime":   "google.protobuf.Timestamp",
+}
+
+var protoDescriptorTypes = map[string]descriptorpb.FieldDescriptorProto_Type{
+	"string": descriptorpb.FieldDescriptorProto_TYPE_STRING,
+	"bool":   descriptorpb.FieldDescriptorProto_TYPE_BOOL,
+	"int64":  descriptorpb.FieldDescriptorProto_TYPE_INT64,
+	"int32":  descriptorpb.FieldDescriptorProto_TYPE_INT32,
+	"uint64": descriptorpb.FieldDescriptorProto_TYPE_UINT64,
+	"uint32": descriptorpb.FieldDescriptorProto_TYPE_UINT32,
+	"float":  descriptorpb.FieldDescriptorProto_TYPE_FLOAT,
+	"double": descriptorpb.FieldDescriptorProto_TYPE_DOUBLE,
+	"bytes":  descriptorpb.FieldDescriptorProto_TYPE_BYTES,
+}
+
+// rpcDir 返回 gRPC 服务实现所在的目录
+func (p PathConfig) rpcDir() string {
+	if p.IsDDD {
+		return "internal/interfaces/rpc"
+	}
+	return "internal/adapter/rpc"
+}
+
+// generateGRPC 为实体生成 .proto, protobuf/gRPC 的 Go 代码以及基于 Service 的 gRPC 服务实现,
+// 并把服务注册到 DI 容器, 在 main.go 中启动 gRPC 监听
+func generateGRPC(info *EntityInfo, paths PathConfig) error {
+	if info.Skips("grpc") {
+		fmt.Println("   ⏭️ //gps:skip=grpc, 跳过 gRPC 代码生成")
+		return nil
+	}
+	if info.NoCrudMethods || info.Skips("service") || info.Skips("dto") {
+		return fmt.Errorf("gRPC 服务依赖生成的 CRUD Service 和 DTO, 已跳过 %s 的 gRPC 代码生成", info.EntityName)
+	}
+	if _, ok := protoScalarTypes[info.PrimaryKey.Type]; !ok || info.PrimaryKey.Type == "uuid.UUID" {
+		return fmt.Errorf("暂不支持类型为 %s 的主键生成 gRPC 服务", info.PrimaryKey.Type)
+	}
+
+	fmt.Printf("  -> 正在为 %s 生成 gRPC 服务...\n", info.EntityName)
+	names := grpcRPCNames(info)
+	file, err := buildProtoFile(info, paths, names)
+	if err != nil {
+		return err
+	}
+
+	protoText, err := renderEmbedded("tmpl/generate/grpc/entity.proto.tmpl", file)
+	if err != nil {
+		return err
+	}
+	if err := writeGeneratedFile(file.Path, protoText); err != nil {
+		return err
+	}
+
+	pbDir := filepath.Join(paths.rpcDir(), "pb")
+	base := common.ToSnakeCase(info.EntityName)
+	messages, err := generateProtoGo(file)
+	if err != nil {
+		return fmt.Errorf("生成 protobuf Go 代码失败: %w", err)
+	}
+	if err := writeGeneratedFile(filepath.Join(pbDir, base+".pb.go"), messages); err != nil {
+		return err
+	}
+	stubs, err := renderEmbedded("tmpl/generate/grpc/service_grpc.pb.go.tmpl", file)
+	if err != nil {
+		return err
+	}
+	if stubs, err = format.Source(stubs); err != nil {
+		return fmt.Errorf("格式化 gRPC 代码失败: %w", err)
+	}
+	if err := writeGeneratedFile(filepath.Join(pbDir, base+"_grpc.pb.go"), stubs); err != nil {
+		return err
+	}
+
+	serverTemplate := "tmpl/generate/grpc/server.go.tmpl"
+	if paths.IsDDD {
+		serverTemplate = "tmpl/generate/grpc/server.go.ddd.tmpl"
+	}
+	// server.go 由所有实体共享, 只在首次生成或使用 -F 时渲染
+	if _, err := os.Stat(filepath.Join(paths.rpcDir(), "server.go")); os.IsNotExist(err) || forceGenerate {
+		renderTask(FileGenerationTask{TemplatePath: "tmpl/generate/grpc/registry.go.tmpl", OutputDir: paths.rpcDir(), FileName: "server", IsSingular: true, Layer: "grpc"}, info.EntityName, info)
+	}
+	renderTask(FileGenerationTask{TemplatePath: serverTemplate, OutputDir: paths.rpcDir(), Suffix: "_server", Layer: "grpc"}, info.EntityName, &grpcServerData{
+		EntityInfo: info,
+		RPC:        names,
+		PKGetter:   "Get" + protoGoName(common.ToSnakeCase(info.PrimaryKey.Name)),
+	})
+
+	if err := addGRPCProvidersToDI(info, paths); err != nil {
+		return fmt.Errorf("自动修改 %s 失败: %w", paths.DIFile, err)
+	}
+	if err := ensureGRPCConfig(); err != nil {
+		fmt.Printf("   ⚠️ %v\n", err)
+	}
+	return ensureGRPCListener(info, paths)
+}
+
+func grpcRPCNames(info *EntityInfo) rpcNames {
+	name := info.EntityName
+	return rpcNames{
+		Create: "Create" + name,
+		List:   "List" + pluralName(name),
+		Get:    "Get" + name,
+		Update: "Update" + name,
+		Delete: "Delete" + name,
+	}
+}
+
+// pluralName 返回驼峰形式实体名的复数形式, 规则与 common.ToPluralSnakeCase 保持一致
+func pluralName(name string) string {
+	switch {
+	case strings.HasSuffix(name, "y"):
+		return strings.TrimSuffix(name, "y") + "ies"
+	case strings.HasSuffix(name, "s"):
+		return name + "es"
+	default:
+		return name + "s"
+	}
+}
+
+// protoGoName 与 protoc-gen-go 一致地将 snake_case 字段名转换为 Go 标识符
+func protoGoName(name string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(name, "_") {
+		if part == "" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+// buildProtoFile 根据实体字段构造 proto 文件模型, 已存在的 .proto 中的字段编号会被沿用
+func buildProtoFile(info *EntityInfo, paths PathConfig, names rpcNames) (*protoFile, error) {
+	base := common.ToSnakeCase(info.EntityName)
+	file := &protoFile{
+		Path:          filepath.ToSlash(filepath.Join(protoDir, base+".proto")),
+		Package:       protoPackage,
+		GoPackage:     info.ProjectModule + "/" + paths.rpcDir() + "/pb;pb",
+		GoPackageName: "pb",
+		Service:       info.EntityName + "Service",
+	}
+	file.FullService = protoPackage + "." + file.Service
+	file.LowerService = toLowerCamel(file.Service)
+	file.Source = file.Path
+
+	pk, ok := protoFieldFor(info.PrimaryKey)
+	if !ok {
+		return nil, fmt.Errorf("主键 %s 的类型 %s 无法映射到 protobuf", info.PrimaryKey.Name, info.PrimaryKey.Type)
+	}
+	pk.Label = ""
+
+	entityMsg := &protoMessage{Name: info.EntityName, Fields: protoFieldsFor(info.ResponseFields())}
+	createMsg := &protoMessage{Name: names.Create + "Request", Fields: protoFieldsFor(info.WritableFields())}
+	updateMsg := &protoMessage{Name: names.Update + "Request", Fields: []*protoField{pk}}
+	for _, f := range protoFieldsFor(info.WritableFields()) {
+		if f.Label == "" {
+			f.Label = "optional"
+		}
+		updateMsg.Fields = append(updateMsg.Fields, f)
+	}
+	idField := func() []*protoField { f := *pk; return []*protoField{&f} }
+
+	type rpcSpec struct {
+		route    string
+		name     string
+		request  *protoMessage
+		response *protoMessage
+	}
+	specs := []rpcSpec{
+		{"create", names.Create, createMsg, entityMsg},
+		{"list", names.List, &protoMessage{Name: names.List + "Request"}, &protoMessage{
+			Name:   names.List + "Response",
+			Fields: []*protoField{{Name: "items", JSONName: "items", Type: info.EntityName, Label: "repeated"}},
+		}},
+		{"get", names.Get, &protoMessage{Name: names.Get + "Request", Fields: idField()}, entityMsg},
+		{"update", names.Update, updateMsg, entityMsg},
+		{"delete", names.Delete, &protoMessage{Name: names.Delete + "Request", Fields: idField()}, &protoMessage{Name: names.Delete + "Response"}},
+	}
+
+	file.Messages = []*protoMessage{entityMsg}
+	for _, spec := range specs {
+		if !info.HasRoute(spec.route) {
+			continue
+		}
+		file.RPCs = append(file.RPCs, protoRPC{Name: spec.name, Request: spec.request.Name, Response: spec.response.Name})
+		file.Messages = append(file.Messages, spec.request)
+		if spec.response != entityMsg {
+			file.Messages = append(file.Messages, spec.response)
+		}
+	}
+
+	previous, err := parseExistingProto(file.Path)
+	if err != nil {
+		return nil, err
+	}
+	for _, msg := range file.Messages {
+		assignFieldNumbers(msg, previous[msg.Name])
+		for _, f := range msg.Fields {
+			if f.Type == "google.protobuf.Timestamp" && !containsString(file.Imports, timestampProto) {
+				file.Imports = append(file.Imports, timestampProto)
+			}
+		}
+	}
+	return file, nil
+}
+
+func protoFieldsFor(fields []FieldInfo) []*protoField {
+	var result []*protoField
+	for _, f := range fields {
+		if f.IsAssociation {
+			continue
+		}
+		pf, ok := protoFieldFor(f)
+		if !ok {
+			fmt.Printf("     ⚠️ 字段 %s 的类型 %s 无法映射到 protobuf, 已跳过\n", f.Name, f.Type)
+			continue
+		}
+		result = append(result, pf)
+	}
+	return result
+}
+
+func protoFieldFor(f FieldInfo) (*protoField, bool) {
+	goType := f.Type
+	label := ""
+	switch {
+	case goType == "[]byte":
+		return &protoField{Name: common.ToSnakeCase(f.Name), JSONName: f.LowerName, Type: "bytes"}, true
+	case strings.HasPrefix(goType, "[]"):
+		label = "repeated"
+		goType = strings.TrimPrefix(goType, "[]")
+	case strings.HasPrefix(goType, "*"):
+		label = "optional"
+		goType = strings.TrimPrefix(goType, "*")
+	}
+	typ, ok := protoScalarTypes[goType]
+	if !ok {
+		return nil, false
+	}
+	if label == "optional" && strings.Contains(typ, ".") {
+		// 消息类型本身即可区分是否赋值
+		label = ""
+	}
+	return &protoField{Name: common.ToSnakeCase(f.Name), JSONName: f.LowerName, Type: typ, Label: label}, true
+}
+
+var (
+	protoMessageRe  = regexp.MustCompile(`^\s*message\s+(\w+)\s*\{`)
+	protoFieldRe    = regexp.MustCompile(`^\s*(?:optional\s+|repeated\s+)?[\w.]+\s+(\w+)\s*=\s*(\d+)`)
+	protoReservedRe = regexp.MustCompile(`^\s*reserved\s+(.+);`)
+)
+
+// parseExistingProto 读取之前生成的 .proto 文件中每个消息的字段编号和保留编号
+func parseExistingProto(path string) (map[string]*protoMessage, error) {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取 %s 失败: %w", path, err)
+	}
+
+	messages := make(map[string]*protoMessage)
+	var current *protoMessage
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := protoMessageRe.FindStringSubmatch(line); m != nil {
+			current = &protoMessage{Name: m[1]}
+			messages[current.Name] = current
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		if strings.TrimSpace(line) == "}" {
+			current = nil
+			continue
+		}
+		if m := protoReservedRe.FindStringSubmatch(line); m != nil {
+			for _, item := range strings.Split(m[1], ",") {
+				item = strings.TrimSpace(item)
+				if name, err := strconv.Unquote(item); err == nil {
+					current.ReservedNames = append(current.ReservedNames, name)
+				} else if n, err := strconv.Atoi(item); err == nil {
+					current.ReservedNumbers = append(current.ReservedNumbers, int32(n))
+				}
+			}
+			continue
+		}
+		if m := protoFieldRe.FindStringSubmatch(line); m != nil {
+			n, _ := strconv.Atoi(m[2])
+			current.Fields = append(current.Fields, &protoField{Name: m[1], Number: int32(n)})
+		}
+	}
+	return messages, scanner.Err()
+}
+
+// assignFieldNumbers 为消息字段分配编号: 已有字段沿用原编号, 新字段使用未被占用过的编号,
+// 被删除的字段编号和名称都会被保留, 避免旧客户端误读
+func assignFieldNumbers(msg, previous *protoMessage) {
+	used := make(map[int32]bool)
+	numbers := make(map[string]int32)
+	if previous != nil {
+		for _, f := range previous.Fields {
+			numbers[f.Name] = f.Number
+			used[f.Number] = true
+		}
+		for _, n := range previous.ReservedNumbers {
+			used[n] = true
+		}
+		msg.ReservedNumbers = append(msg.ReservedNumbers, previous.ReservedNumbers...)
+		msg.ReservedNames = append(msg.ReservedNames, previous.ReservedNames...)
+	}
+
+	current := make(map[string]bool)
+	for _, f := range msg.Fields {
+		current[f.Name] = true
+		if n, ok := numbers[f.Name]; ok {
+			f.Number = n
+		}
+	}
+	next := int32(1)
+	for _, f := range msg.Fields {
+		if f.Number != 0 {
+			continue
+		}
+		for used[next] || (next >= 19000 && next <= 19999) {
+			next++
+		}
+		f.Number = next
+		used[next] = true
+	}
+
+	if previous != nil {
+		for _, f := range previous.Fields {
+			if !current[f.Name] {
+				msg.ReservedNumbers = append(msg.ReservedNumbers, f.Number)
+				msg.ReservedNames = append(msg.ReservedNames, f.Name)
+			}
+		}
+	}
+	// 重新加入的字段名不能继续处于保留状态
+	names := msg.ReservedNames[:0]
+	for _, name := range msg.ReservedNames {
+		if !current[name] && !containsString(names, name) {
+			names = append(names, name)
+		}
+	}
+	msg.ReservedNames = names
+	sort.Slice(msg.ReservedNumbers, func(i, j int) bool { return msg.ReservedNumbers[i] < msg.ReservedNumbers[j] })
+}
+
+// descriptor 将 proto 文件模型转换为 protoc 传给插件的文件描述符
+func (f *protoFile) descriptor() *descriptorpb.FileDescriptorProto {
+	fd := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String(f.Path),
+		Package:    proto.String(f.Package),
+		Dependency: f.Imports,
+		Syntax:     proto.String("proto3"),
+		Options:    &descriptorpb.FileOptions{GoPackage: proto.String(f.GoPackage)},
+	}
+	typeName := func(name string) string {
+		if strings.Contains(name, ".") {
+			return "." + name
+		}
+		return "." + f.Package + "." + name
+	}
+
+	for _, msg := range f.Messages {
+		dp := &descriptorpb.DescriptorProto{Name: proto.String(msg.Name)}
+		for _, field := range msg.Fields {
+			fdp := &descriptorpb.FieldDescriptorProto{
+				Name:     proto.String(field.Name),
+				Number:   proto.Int32(field.Number),
+				JsonName: proto.String(field.JSONName),
+				Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			}
+			if field.Label == "repeated" {
+				fdp.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+			}
+			if t, ok := protoDescriptorTypes[field.Type]; ok {
+				fdp.Type = t.Enum()
+			} else {
+				fdp.Type = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum()
+				fdp.TypeName = proto.String(typeName(field.Type))
+			}
+			if field.Label == "optional" {
+				// proto3 optional 字段需要一个合成的 oneof
+				fdp.Proto3Optional = proto.Bool(true)
+				fdp.OneofIndex = proto.Int32(int32(len(dp.OneofDecl)))
+				dp.OneofDecl = append(dp.OneofDecl, &descriptorpb.OneofDescriptorProto{Name: proto.String("_" + field.Name)})
+			}
+			dp.Field = append(dp.Field, fdp)
+		}
+		for _, n := range msg.ReservedNumbers {
+			dp.ReservedRange = append(dp.ReservedRange, &descriptorpb.DescriptorProto_ReservedRange{Start: proto.Int32(n), End: proto.Int32(n + 1)})
+		}
+		dp.ReservedName = msg.ReservedNames
+		fd.MessageType = append(fd.MessageType, dp)
+	}
+
+	service := &descriptorpb.ServiceDescriptorProto{Name: proto.String(f.Service)}
+	for _, rpc := range f.RPCs {
+		service.Method = append(service.Method, &descriptorpb.MethodDescriptorProto{
+			Name:       proto.String(rpc.Name),
+			InputType:  proto.String(typeName(rpc.Request)),
+			OutputType: proto.String(typeName(rpc.Response)),
+		})
+	}
+	fd.Service = []*descriptorpb.ServiceDescriptorProto{service}
+	return fd
+}
+
+// generateProtoGo 按 protoc 插件协议把文件描述符交给 protoc-gen-go 生成消息类型, 不需要安装 protoc
+func generateProtoGo(file *protoFile) ([]byte, error) {
+	fd := file.descriptor()
+	req := &pluginpb.CodeGeneratorRequest{
+		FileToGenerate: []string{fd.GetName()},
+		ProtoFile: []*descriptorpb.FileDescriptorProto{
+			protodesc.ToFileDescriptorProto(timestamppb.File_google_protobuf_timestamp_proto),
+			fd,
+		},
+	}
+	input, err := proto.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := protocGenGoCommand()
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("运行 protoc-gen-go 失败: %w\n%s请通过 go install %s 安装后重试", err, stderr.String(), protocGenGo)
+	}
+
+	resp := &pluginpb.CodeGeneratorResponse{}
+	if err := proto.Unmarshal(stdout.Bytes(), resp); err != nil {
+		return nil, fmt.Errorf("解析 protoc-gen-go 的输出失败: %w", err)
+	}
+	if resp.Error != nil {
+		return nil, fmt.Errorf("%s", resp.GetError())
+	}
+	if len(resp.File) == 0 {
+		return nil, fmt.Errorf("protoc-gen-go 没有生成任何文件")
+	}
+	return []byte(resp.File[0].GetContent()), nil
+}
+
+// protocGenGoCommand 优先使用 PATH 中安装的 protoc-gen-go, 否则通过 go run 运行固定版本的插件
+func protocGenGoCommand() *exec.Cmd {
+	if path, err := exec.LookPath("protoc-gen-go"); err == nil {
+		return exec.Command(path)
+	}
+	return exec.Command("go", "run", protocGenGo)
+}
+
+// renderEmbedded 渲染 tmpl/generate 下的模板
+func renderEmbedded(path string, data any) ([]byte, error) {
+	tmpl, err := template.New(filepath.Base(path)).ParseFS(generateTemplates, path)
+	if err != nil {
+		return nil, fmt.Errorf("读取嵌入的模板 %s 失败: %w", path, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("渲染模板 %s 失败: %w", path, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// writeGeneratedFile 写入完全由生成器管理的文件, 这些文件每次都会被重新生成
+func writeGeneratedFile(path string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("创建目录 %s 失败: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		return fmt.Errorf("写入文件 %s 失败: %w", path, err)
+	}
+	fmt.Printf("     成功生成文件: %s\n", path)
+	return nil
+}
+
+// addGRPCProvidersToDI 注册 gRPC Server 以及实体的 gRPC 服务
+func addGRPCProvidersToDI(info *EntityInfo, paths PathConfig) error {
+	return addExtraProvidersToDI(paths, info.ProjectModule+"/"+paths.rpcDir(),
+		diProvider{Comment: "gRPC Server", Expr: "rpc.NewServer"},
+		diProvider{Comment: info.EntityName + " gRPC Providers", Expr: "rpc.New" + info.EntityName + "Server"},
+	)
+}
+
+// ensureGRPCListener 在 main.go 中启动 gRPC 监听, 已经启动过时不做任何修改
+func ensureGRPCListener(info *EntityInfo, paths PathConfig) error {
+	listener := `// 启动 gRPC 服务
+	if err := container.Invoke(func(s *grpc.Server) {
+		grpcAddr := fmt.Sprintf(":%d", config.GRPC.Port)
+		go func() {
+			log.Printf("gRPC 服务监听于 %s", grpcAddr)
+			if err := rpc.Serve(s, grpcAddr); err != nil {
+				log.Fatalf("gRPC 服务启动失败: %v", err)
+			}
+		}()
+	}); err != nil {
+		log.Fatalf("启动 gRPC 服务失败: %v", err)
+	}
+
+	`
+	return injectIntoMain("rpc.Serve(", listener, "starting gRPC listener", "调用 rpc.Serve 启动 gRPC 服务",
+		"fmt", "log", "google.golang.org/grpc", info.ProjectModule+"/"+paths.rpcDir())
+}
+
+// ensureGRPCConfig 为旧项目补充 grpc 配置项
+func ensureGRPCConfig() error {
+	return ensureConfigSection(configSection{
+		Field:    "GRPC",
+		Type:     "GRPCConfig",
+		Key:      "grpc",
+		TypeDecl: "// GRPCConfig holds gRPC server settings.\ntype GRPCConfig struct {\n\tPort int `mapstructure:\"port\"`\n}\n",
+		YAML:     fmt.Sprintf("grpc:\n  port: %d\n", defaultGRPCPort),
+	})
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
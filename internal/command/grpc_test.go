@@ -0,0 +1,133 @@
+package command
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAssignFieldNumbers(t *testing.T) {
+	fields := func(names ...string) []*protoField {
+		var result []*protoField
+		for _, name := range names {
+			result = append(result, &protoField{Name: name})
+		}
+		return result
+	}
+	previous := &protoMessage{
+		Fields:          []*protoField{{Name: "id", Number: 1}, {Name: "title", Number: 2}, {Name: "plays", Number: 4}},
+		ReservedNumbers: []int32{3},
+		ReservedNames:   []string{"genre"},
+	}
+
+	tests := []struct {
+		name         string
+		fields       []string
+		previous     *protoMessage
+		wantNumbers  []int32
+		wantReserved []int32
+		wantNames    []string
+	}{
+		{
+			name:        "new message is numbered sequentially",
+			fields:      []string{"id", "title"},
+			wantNumbers: []int32{1, 2},
+		},
+		{
+			name:         "existing numbers are kept and new fields skip reserved numbers",
+			fields:       []string{"id", "duration", "title", "plays"},
+			previous:     previous,
+			wantNumbers:  []int32{1, 5, 2, 4},
+			wantReserved: []int32{3},
+			wantNames:    []string{"genre"},
+		},
+		{
+			name:         "removed fields are reserved and re-added names are released",
+			fields:       []string{"id", "genre"},
+			previous:     previous,
+			wantNumbers:  []int32{1, 5},
+			wantReserved: []int32{2, 3, 4},
+			wantNames:    []string{"title", "plays"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := &protoMessage{Fields: fields(tt.fields...)}
+			assignFieldNumbers(msg, tt.previous)
+
+			var numbers []int32
+			for _, f := range msg.Fields {
+				numbers = append(numbers, f.Number)
+			}
+			if !reflect.DeepEqual(numbers, tt.wantNumbers) {
+				t.Errorf("numbers = %v, want %v", numbers, tt.wantNumbers)
+			}
+			if !reflect.DeepEqual(msg.ReservedNumbers, tt.wantReserved) {
+				t.Errorf("reserved numbers = %v, want %v", msg.ReservedNumbers, tt.wantReserved)
+			}
+			if !reflect.DeepEqual(msg.ReservedNames, tt.wantNames) {
+				t.Errorf("reserved names = %v, want %v", msg.ReservedNames, tt.wantNames)
+			}
+		})
+	}
+}
+
+func TestGenerateProtoGo(t *testing.T) {
+	// 用本模块依赖的 protobuf 版本构建插件, 放到 PATH 中, 测试不需要网络
+	bin := t.TempDir()
+	build := exec.Command("go", "build", "-o", filepath.Join(bin, "protoc-gen-go"), "google.golang.org/protobuf/cmd/protoc-gen-go")
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("构建 protoc-gen-go 失败: %v\n%s", err, out)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	writeFixture(t, t.TempDir(), map[string]string{
+		"internal/domain/entity/song.go": `package entity
+
+import "time"
+
+type Song struct {
+	ID        uint   ` + "`gorm:\"primaryKey\"`" + `
+	Title     string
+	Plays     *int64
+	Tags      []string
+	CreatedAt time.Time
+}
+`,
+	})
+	info, err := parseEntityFile("internal/domain/entity/song.go", "example.com/music")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := buildProtoFile(info, PathConfig{IsDDD: true}, grpcRPCNames(info))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := generateProtoGo(file)
+	if err != nil {
+		t.Fatalf("generateProtoGo() error = %v", err)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "song.pb.go", out, 0); err != nil {
+		t.Fatalf("生成的代码无法解析: %v\n%s", err, out)
+	}
+	for _, want := range []string{
+		"package pb",
+		"type Song struct",
+		"Plays         *int64",
+		"Tags          []string",
+		"CreatedAt     *timestamppb.Timestamp",
+		"type CreateSongRequest struct",
+		"type ListSongsResponse struct",
+		"func (x *UpdateSongRequest) GetTitle() string",
+	} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("生成的代码中缺少 %q", want)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Skyenought/goprojectstarter/internal/common"
+	"github.com/spf13/cobra"
+)
+
+var llmCmd = &cobra.Command{
+	Use:   "llm",
+	Short: "管理 LLM 相关的本地数据",
+}
+
+var llmCacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "管理 LLM 回复的本地缓存",
+	Long: `gen-api 和 gen-logic 按 提供商 + 模型 + temperature + prompt 的哈希缓存 LLM 的回复,
+注入失败后重新执行 (例如 gen-api --from-markdown) 时直接复用上一次的回复, 不会再次计费。
+缓存目录、有效期和大小上限在 .goprojectstarter.yaml 的 llm.cache 中配置, 单次执行可以用 --no-cache 跳过缓存。`,
+}
+
+var llmCacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "删除所有缓存的 LLM 回复",
+	Args:  cobra.NoArgs,
+	Run:   runLLMCacheClear,
+}
+
+var llmCacheStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "显示 LLM 缓存的条目数、大小和各模型的分布",
+	Args:  cobra.NoArgs,
+	Run:   runLLMCacheStats,
+}
+
+func init() {
+	rootCmd.AddCommand(llmCmd)
+	llmCmd.AddCommand(llmCacheCmd)
+	llmCacheCmd.AddCommand(llmCacheClearCmd)
+	llmCacheCmd.AddCommand(llmCacheStatsCmd)
+}
+
+func runLLMCacheClear(cmd *cobra.Command, args []string) {
+	cache, err := common.OpenLLMCache()
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	n, err := cache.Clear()
+	if err != nil {
+		fmt.Printf("❌ 清空 LLM 缓存失败: %v\n", err)
+		return
+	}
+	fmt.Printf("✅ 已删除 %d 条缓存 (%s)\n", n, cache.Dir)
+}
+
+func runLLMCacheStats(cmd *cobra.Command, args []string) {
+	cache, err := common.OpenLLMCache()
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	stats, err := cache.Stats()
+	if err != nil {
+		fmt.Printf("❌ 读取 LLM 缓存失败: %v\n", err)
+		return
+	}
+
+	limit := "不限"
+	if cache.MaxBytes > 0 {
+		limit = formatBytes(cache.MaxBytes)
+	}
+	ttl := "永不过期"
+	if cache.TTL > 0 {
+		ttl = cache.TTL.String()
+	}
+	fmt.Printf("📦 LLM 缓存: %s\n", cache.Dir)
+	fmt.Printf("   条目: %d (已过期 %d), 大小: %s / %s, 有效期: %s\n", stats.Entries, stats.Expired, formatBytes(stats.Bytes), limit, ttl)
+	if stats.Entries == 0 {
+		return
+	}
+	fmt.Printf("   最早写入: %s, 最近写入: %s\n", stats.Oldest.Format(time.DateTime), stats.Newest.Format(time.DateTime))
+
+	models := make([]string, 0, len(stats.ByModel))
+	for m := range stats.ByModel {
+		models = append(models, m)
+	}
+	sort.Strings(models)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "   模型\t条目\n")
+	for _, m := range models {
+		fmt.Fprintf(w, "   %s\t%d\n", m, stats.ByModel[m])
+	}
+	w.Flush()
+}
+
+// formatBytes 以 B、KB、MB 或 GB 显示大小
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value, suffix := float64(n)/unit, "KB"
+	for _, s := range []string{"MB", "GB"} {
+		if value < unit {
+			break
+		}
+		value, suffix = value/unit, s
+	}
+	return fmt.Sprintf("%.1f %s", value, suffix)
+}
@@ -8,6 +8,8 @@ import (
 	"go/parser"
 	"go/token"
 	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
 	"text/template"
 
@@ -53,17 +55,29 @@ func addProviderToDI(info *EntityInfo, paths PathConfig) error {
 	// 根据模式选择不同的 Provider 模板
 	providerTemplateStr := `
 		// {{.EntityName}} Providers
+		{{- if not (.Skips "repository")}}
 		repository.New{{.EntityName}}Repository,
+		{{- end}}
+		{{- if not (.Skips "service")}}
 		service.New{{.EntityName}}Service,
+		{{- end}}
+		{{- if not (.Skips "handler")}}
 		handler.New{{.EntityName}}Handler,
+		{{- end}}
 		` + anchor
 
 	if paths.IsDDD {
 		providerTemplateStr = `
 		// {{.EntityName}} Providers
+		{{- if not (.Skips "repository")}}
 		persistence.New{{.EntityName}}Repository,
+		{{- end}}
+		{{- if not (.Skips "service")}}
 		service.New{{.EntityName}}Service,
+		{{- end}}
+		{{- if not (.Skips "handler")}}
 		handler.New{{.EntityName}}Handler,
+		{{- end}}
 		` + anchor
 	}
 
@@ -85,22 +99,62 @@ func addProviderToDI(info *EntityInfo, paths PathConfig) error {
 	return ensureImportsForDI(info, paths)
 }
 
+// diProvider 是一个需要注册到 DI 容器中的构造函数
+type diProvider struct {
+	Comment string
+	Expr    string
+}
+
+// addExtraProvidersToDI 在锚点前依次注册尚未注册的构造函数, 并导入其所在的包
+func addExtraProvidersToDI(paths PathConfig, importPath string, providers ...diProvider) error {
+	content, err := os.ReadFile(paths.DIFile)
+	if err != nil {
+		return err
+	}
+	anchor := "// [GENERATOR ANCHOR] - Don't remove this comment!"
+	if !strings.Contains(string(content), anchor) {
+		return fmt.Errorf("未找到锚点注释 %q", anchor)
+	}
+
+	updated := string(content)
+	for _, p := range providers {
+		if strings.Contains(updated, p.Expr+",") {
+			continue
+		}
+		fmt.Printf("  -> Modifying %s (adding %s)...\n", paths.DIFile, p.Expr)
+		updated = strings.Replace(updated, anchor, fmt.Sprintf("// %s\n\t\t%s,\n\n\t\t%s", p.Comment, p.Expr, anchor), 1)
+	}
+	if updated != string(content) {
+		if err := os.WriteFile(paths.DIFile, []byte(updated), 0o644); err != nil {
+			return err
+		}
+	}
+	return modifySourceFile(paths.DIFile, func(fset *token.FileSet, node *ast.File) error {
+		astutil.AddImport(fset, node, importPath)
+		return nil
+	})
+}
+
 func ensureImportsForDI(info *EntityInfo, paths PathConfig) error {
 	return modifySourceFile(paths.DIFile, func(fset *token.FileSet, node *ast.File) error {
-		for _, pkgPath := range paths.DIImports {
-			astutil.AddImport(fset, node, info.ProjectModule+pkgPath)
+		for _, imp := range paths.DIImports {
+			if info.Skips(imp.Layer) {
+				continue
+			}
+			astutil.AddImport(fset, node, info.ProjectModule+imp.Path)
 		}
 		return nil
 	})
 }
 
 func addHandlerToRouter(info *EntityInfo, paths PathConfig) error {
-	filePath := paths.RouterFile
-	fmt.Printf("  -> Modifying %s (injecting Handler)...\n", filePath)
+	fmt.Printf("  -> Modifying %s (injecting Handler)...\n", paths.RouterFile)
+	return injectRouterDependency(paths.RouterFile, info.EntityName+"Handler", "*handler."+info.EntityName+"Handler", info.ProjectModule+paths.HandlerPackagePath)
+}
 
+// injectRouterDependency 为 Router 结构体、NewRouter 的参数及其返回值添加一个依赖字段
+func injectRouterDependency(filePath, handlerName, handlerType, importPath string) error {
 	return modifySourceFile(filePath, func(fset *token.FileSet, node *ast.File) error {
-		handlerName := info.EntityName + "Handler"
-		handlerType := "*handler." + info.EntityName + "Handler"
 		paramName := toLowerCamel(handlerName)
 
 		astutil.Apply(node, func(cursor *astutil.Cursor) bool {
@@ -169,7 +223,7 @@ func addHandlerToRouter(info *EntityInfo, paths PathConfig) error {
 			return true
 		}, nil)
 
-		astutil.AddImport(fset, node, info.ProjectModule+paths.HandlerPackagePath)
+		astutil.AddImport(fset, node, importPath)
 		return nil
 	})
 }
@@ -192,13 +246,11 @@ func addRoutesToRouter(info *EntityInfo, paths PathConfig) error {
 
 	anchor := "// [GENERATOR ANCHOR] - Don't remove this comment!"
 	routeTemplate := `
-	// {{.EntityName}} routes
-	{{.LowerEntityName}}Routes := apiV1.Group("/{{.TableName}}")
-	{{.LowerEntityName}}Routes.Post("/", r.{{.EntityName}}Handler.Create)
-	{{.LowerEntityName}}Routes.Get("/", r.{{.EntityName}}Handler.GetAll)
-	{{.LowerEntityName}}Routes.Get("/:id", r.{{.EntityName}}Handler.GetByID)
-	{{.LowerEntityName}}Routes.Put("/:id", r.{{.EntityName}}Handler.Update)
-	{{.LowerEntityName}}Routes.Delete("/:id", r.{{.EntityName}}Handler.Delete)
+	// {{.Info.EntityName}} routes
+	{{.Info.LowerEntityName}}Routes := apiV1.Group("{{.Info.RoutePath}}")
+	{{- range .Routes}}
+	{{$.Info.LowerEntityName}}Routes.{{.Method}}("{{.Path}}", r.{{$.Info.EntityName}}Handler.{{.Handler}})
+	{{- end}}
 
 	` + anchor
 
@@ -207,7 +259,16 @@ func addRoutesToRouter(info *EntityInfo, paths PathConfig) error {
 	if err != nil {
 		return err
 	}
-	if err := tmpl.Execute(&tpl, info); err != nil {
+	data := struct {
+		Info   *EntityInfo
+		Routes []crudRoute
+	}{Info: info}
+	for _, r := range crudRoutes {
+		if info.HasRoute(r.Name) {
+			data.Routes = append(data.Routes, r)
+		}
+	}
+	if err := tmpl.Execute(&tpl, data); err != nil {
 		return err
 	}
 
@@ -221,3 +282,214 @@ func addRoutesToRouter(info *EntityInfo, paths PathConfig) error {
 
 	return os.WriteFile(filePath, formatted, 0o644)
 }
+
+// configSection 描述一个需要补充到旧项目配置中的配置段
+type configSection struct {
+	Field    string // Config 结构体中的字段名
+	Type     string // 字段类型名
+	Key      string // mapstructure 标签以及 config.yaml 中的顶层键
+	TypeDecl string // 字段类型的声明源码
+	YAML     string // 追加到 config.yaml 的默认配置
+}
+
+// ensureConfigSection 在 Config 结构体缺少该配置段时添加字段和类型声明, 并在 config.yaml 中补充默认值
+func ensureConfigSection(section configSection) error {
+	const configFile = "internal/configuration/config.go"
+	node, err := parser.ParseFile(token.NewFileSet(), configFile, nil, 0)
+	if err != nil {
+		return fmt.Errorf("无法解析文件 %s: %w", configFile, err)
+	}
+	st := findStructType(node, "Config")
+	if st == nil {
+		return fmt.Errorf("在 %s 中未找到 Config 结构体", configFile)
+	}
+
+	if !structHasField(st, section.Field) {
+		fmt.Printf("  -> Modifying %s (adding %s)...\n", configFile, section.Field)
+		err := modifySourceFile(configFile, func(fset *token.FileSet, node *ast.File) error {
+			st := findStructType(node, "Config")
+			st.Fields.List = append(st.Fields.List, &ast.Field{
+				Names: []*ast.Ident{ast.NewIdent(section.Field)},
+				Type:  ast.NewIdent(section.Type),
+				Tag:   &ast.BasicLit{Kind: token.STRING, Value: fmt.Sprintf("`mapstructure:%q`", section.Key)},
+			})
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if findTypeSpec(node, section.Type) == nil {
+		f, err := os.OpenFile(configFile, os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return err
+		}
+		_, err = f.WriteString("\n" + section.TypeDecl)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	const yamlFile = "config.yaml"
+	yamlContent, err := os.ReadFile(yamlFile)
+	if err != nil {
+		return nil
+	}
+	if regexp.MustCompile(`(?m)^` + regexp.QuoteMeta(section.Key) + `:`).Match(yamlContent) {
+		return nil
+	}
+	fmt.Printf("  -> Modifying %s (adding %s)...\n", yamlFile, section.Key)
+	yamlContent = append(bytes.TrimRight(yamlContent, "\n"), []byte("\n\n"+section.YAML)...)
+	return os.WriteFile(yamlFile, yamlContent, 0o644)
+}
+
+// findTypeSpec 返回文件中名为 name 的类型声明, 不存在时返回 nil
+func findTypeSpec(node *ast.File, name string) *ast.TypeSpec {
+	for _, decl := range node.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			if ts, ok := spec.(*ast.TypeSpec); ok && ts.Name.Name == name {
+				return ts
+			}
+		}
+	}
+	return nil
+}
+
+// findStructType 返回文件中名为 name 的结构体类型, 不存在或不是结构体时返回 nil
+func findStructType(node *ast.File, name string) *ast.StructType {
+	ts := findTypeSpec(node, name)
+	if ts == nil {
+		return nil
+	}
+	st, _ := ts.Type.(*ast.StructType)
+	return st
+}
+
+// structHasField 判断结构体是否已有名为 name 的字段 (包括同名的嵌入字段)
+func structHasField(st *ast.StructType, name string) bool {
+	for _, field := range st.Fields.List {
+		for _, ident := range field.Names {
+			if ident.Name == name {
+				return true
+			}
+		}
+		if len(field.Names) == 0 {
+			typ := field.Type
+			if star, ok := typ.(*ast.StarExpr); ok {
+				typ = star.X
+			}
+			if ident, ok := typ.(*ast.Ident); ok && ident.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// addRoutesBeforeEntityRoutes 以 "// <Entity> <kind> routes" 为标记挂载实体的附加路由, 路径相对于实体的路由前缀。
+// Fiber 按注册顺序匹配路由, 附加路由必须注册在实体的 /:id 路由之前, 否则 DELETE <前缀>/bulk
+// 之类的请求会被 /:id 匹配, 因此优先插入到实体路由块的上方
+func addRoutesBeforeEntityRoutes(info *EntityInfo, paths PathConfig, kind, handlerName string, routes []crudRoute) error {
+	content, err := os.ReadFile(paths.RouterFile)
+	if err != nil {
+		return err
+	}
+	routeCheck := fmt.Sprintf("// %s %s routes", info.EntityName, kind)
+	if strings.Contains(string(content), routeCheck) {
+		fmt.Printf("  -> %s routes for %s already exist in %s, skipping.\n", kind, info.EntityName, paths.RouterFile)
+		return nil
+	}
+
+	// 中间件 (Use) 插入到实体的第一个路由块之前, 使它同样作用于已有的附加路由;
+	// 其他附加路由插入到实体路由块之前, 位于中间件之后
+	block := ` routes`
+	for _, r := range routes {
+		if r.Method == "Use" {
+			block = `( \w+)? routes`
+		}
+	}
+	pos := -1
+	entityRoutes := regexp.MustCompile(`(?m)^[ \t]*(// ` + regexp.QuoteMeta(info.EntityName) + block + `)$`)
+	if loc := entityRoutes.FindStringSubmatchIndex(string(content)); loc != nil {
+		pos = loc[2]
+	} else if anchor := "// [GENERATOR ANCHOR] - Don't remove this comment!"; strings.Contains(string(content), anchor) {
+		pos = strings.Index(string(content), anchor)
+	} else {
+		return fmt.Errorf("未找到锚点注释 %q", anchor)
+	}
+	fmt.Printf("  -> Adding %s routes to %s...\n", kind, paths.RouterFile)
+
+	routeTemplate := `{{.Check}}
+	{{- range .Routes}}
+	apiV1.{{.Method}}("{{$.Info.RoutePath}}{{.Path}}", r.{{$.Handler}}.{{.Handler}})
+	{{- end}}
+
+	`
+	var tpl bytes.Buffer
+	tmpl, err := template.New("extra-routes").Parse(routeTemplate)
+	if err != nil {
+		return err
+	}
+	if err := tmpl.Execute(&tpl, struct {
+		Info    *EntityInfo
+		Check   string
+		Handler string
+		Routes  []crudRoute
+	}{Info: info, Check: routeCheck, Handler: handlerName, Routes: routes}); err != nil {
+		return err
+	}
+
+	newContent := string(content[:pos]) + tpl.String() + string(content[pos:])
+	formatted, err := format.Source([]byte(newContent))
+	if err != nil {
+		fmt.Printf("    ⚠️ Code formatting failed: %v. Writing unformatted code.\n", err)
+		return os.WriteFile(paths.RouterFile, []byte(newContent), 0o644)
+	}
+	return os.WriteFile(paths.RouterFile, formatted, 0o644)
+}
+
+// injectIntoMain 在 main.go 的 gRPC 锚点之前插入启动代码, 在添加锚点之前创建的项目中插入到启动 HTTP 服务之前。
+// main.go 中已经包含 check 时不做任何修改; 找不到插入位置时返回的错误提示用户手动 manual
+func injectIntoMain(check, snippet, action, manual string, imports ...string) error {
+	mainFiles, _ := filepath.Glob("cmd/*/main.go")
+	if len(mainFiles) == 0 {
+		return fmt.Errorf("未找到 cmd/*/main.go, 请手动%s", manual)
+	}
+	mainFile := mainFiles[0]
+	content, err := os.ReadFile(mainFile)
+	if err != nil {
+		return err
+	}
+	if strings.Contains(string(content), check) {
+		return nil
+	}
+
+	var updated string
+	switch {
+	case strings.Contains(string(content), grpcAnchor):
+		updated = strings.Replace(string(content), grpcAnchor, snippet+grpcAnchor, 1)
+	case strings.Contains(string(content), "err = container.Invoke(func(r *router.Router)"):
+		// 在添加锚点之前创建的项目
+		updated = strings.Replace(string(content), "err = container.Invoke(func(r *router.Router)", snippet+"err = container.Invoke(func(r *router.Router)", 1)
+	default:
+		return fmt.Errorf("未在 %s 中找到 %q, 请手动%s", mainFile, grpcAnchor, manual)
+	}
+
+	fmt.Printf("  -> Modifying %s (%s)...\n", mainFile, action)
+	if err := os.WriteFile(mainFile, []byte(updated), 0o644); err != nil {
+		return err
+	}
+	return modifySourceFile(mainFile, func(fset *token.FileSet, node *ast.File) error {
+		for _, path := range imports {
+			astutil.AddImport(fset, node, path)
+		}
+		return nil
+	})
+}
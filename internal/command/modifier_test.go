@@ -0,0 +1,163 @@
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAddRoutesBeforeEntityRoutes(t *testing.T) {
+	src := `package router
+
+func (r *Router) SetupRoutes() {
+	apiV1 := r.App.Group("/api/v1")
+
+	// Song routes
+	songRoutes := apiV1.Group("/songs")
+	songRoutes.Delete("/:id", r.SongHandler.Delete)
+
+	// [GENERATOR ANCHOR] - Don't remove this comment!
+}
+`
+	path := filepath.Join(t.TempDir(), "router.go")
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	info := &EntityInfo{EntityName: "Song", TableName: "songs"}
+	for i := 0; i < 2; i++ {
+		if err := addRoutesBeforeEntityRoutes(info, PathConfig{RouterFile: path}, "bulk", "SongBulkHandler", bulkRoutes); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(content)
+	if n := strings.Count(got, "// Song bulk routes"); n != 1 {
+		t.Fatalf("bulk routes inserted %d times:\n%s", n, got)
+	}
+	bulk := strings.Index(got, `apiV1.Delete("/songs/bulk", r.SongBulkHandler.BulkDelete)`)
+	byID := strings.Index(got, `songRoutes.Delete("/:id"`)
+	if bulk < 0 || bulk > byID {
+		t.Errorf("bulk routes must be registered before /:id routes:\n%s", got)
+	}
+
+	// 后加入的中间件需要作用于已有的附加路由
+	if err := addRoutesBeforeEntityRoutes(info, PathConfig{RouterFile: path}, "tenant", "TenantMiddleware", tenantMiddlewareRoutes); err != nil {
+		t.Fatal(err)
+	}
+	content, err = os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = string(content)
+	use := strings.Index(got, `apiV1.Use("/songs", r.TenantMiddleware.Handle)`)
+	if use < 0 || use > strings.Index(got, "// Song bulk routes") {
+		t.Errorf("tenant middleware must be registered before the bulk routes:\n%s", got)
+	}
+}
+
+func TestInjectIntoMain(t *testing.T) {
+	src := `package main
+
+func main() {
+	container, err := di.BuildContainer(config)
+
+	// [GRPC ANCHOR] - Don't remove this comment!
+
+	err = container.Invoke(func(r *router.Router) {})
+}
+`
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "cmd", "app"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "cmd", "app", "main.go")
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+
+	snippet := "go relay.Run(context.Background())\n\n\t"
+	for i := 0; i < 2; i++ {
+		if err := injectIntoMain("relay.Run(", snippet, "starting outbox relay", "运行 relay", "context"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(content)
+	if n := strings.Count(got, "relay.Run("); n != 1 {
+		t.Fatalf("startup code inserted %d times:\n%s", n, got)
+	}
+	if strings.Index(got, "relay.Run(") > strings.Index(got, grpcAnchor) || !strings.Contains(got, `import "context"`) {
+		t.Errorf("startup code must precede the anchor and add its imports:\n%s", got)
+	}
+}
+
+func TestEnsureConfigSection(t *testing.T) {
+	section := configSection{
+		Field:    "Bulk",
+		Type:     "BulkConfig",
+		Key:      "bulk",
+		TypeDecl: "type BulkConfig struct {\n\tMaxItems int `mapstructure:\"max_items\"`\n}\n",
+		YAML:     "bulk:\n  max_items: 10\n",
+	}
+
+	// 注释中提到 BulkConfig 不代表已有该配置段, 空的 type () 声明也不能导致崩溃
+	writeFixture(t, t.TempDir(), map[string]string{
+		"internal/configuration/config.go": "package configuration\n\ntype ()\n\n// Config 的 Bulk 字段使用 BulkConfig\ntype Config struct {\n\tName string `mapstructure:\"name\"`\n}\n",
+		"config.yaml":                      "name: demo\n",
+	})
+	for i := 0; i < 2; i++ {
+		if err := ensureConfigSection(section); err != nil {
+			t.Fatal(err)
+		}
+	}
+	content, err := os.ReadFile("internal/configuration/config.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(content)
+	if n := strings.Count(got, "Bulk BulkConfig `mapstructure:\"bulk\"`"); n != 1 {
+		t.Errorf("Bulk field added %d times:\n%s", n, got)
+	}
+	if n := strings.Count(got, "type BulkConfig struct"); n != 1 {
+		t.Errorf("BulkConfig declared %d times:\n%s", n, got)
+	}
+	yaml, err := os.ReadFile("config.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(yaml), "bulk:"); n != 1 {
+		t.Errorf("bulk section added %d times:\n%s", n, yaml)
+	}
+
+	// 已有字段时只补充缺少的类型声明
+	writeFixture(t, t.TempDir(), map[string]string{
+		"internal/configuration/config.go": "package configuration\n\ntype Config struct {\n\tBulk BulkConfig\n}\n",
+	})
+	if err := ensureConfigSection(section); err != nil {
+		t.Fatal(err)
+	}
+	content, err = os.ReadFile("internal/configuration/config.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(content); strings.Contains(got, "mapstructure:\"bulk\"") || !strings.Contains(got, "type BulkConfig struct") {
+		t.Errorf("existing Bulk field must be kept and only the type declared:\n%s", got)
+	}
+
+	writeFixture(t, t.TempDir(), map[string]string{
+		"internal/configuration/config.go": "package configuration\n\ntype Settings struct{}\n",
+	})
+	if err := ensureConfigSection(section); err == nil || !strings.Contains(err.Error(), "Config") {
+		t.Errorf("missing Config struct must be reported, got %v", err)
+	}
+}
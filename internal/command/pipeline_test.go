@@ -0,0 +1,132 @@
+package command
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "用生成结果覆盖 testdata/pipeline/golden 中的文件")
+
+// setupPipelineProject 把 testdata/pipeline/project 复制到临时目录并切换过去, 使用内置的 mock 提供商返回
+// testdata/pipeline/llm-mock 中预设的代码片段。返回 golden 目录的绝对路径
+func setupPipelineProject(t *testing.T, scenario string) string {
+	t.Helper()
+	testdata, err := filepath.Abs(filepath.Join("testdata", "pipeline"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.CopyFS(dir, os.DirFS(filepath.Join(testdata, "project"))); err != nil {
+		t.Fatal(err)
+	}
+	config := "llm:\n  cache:\n    disabled: true\n  profiles:\n    default:\n      model: mock:canned\n  providers:\n    mock:\n      base_url: " + filepath.Join(testdata, "llm-mock") + "\n"
+	if err := os.WriteFile(filepath.Join(dir, ".goprojectstarter.yaml"), []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOPROJECTSTARTER_CASSETTE", "")
+	t.Chdir(dir)
+	return filepath.Join(testdata, "golden", scenario)
+}
+
+// checkGolden 比较覆盖层写入的文件和 golden 目录中的同名文件, -update 时改为覆盖 golden 文件
+func checkGolden(t *testing.T, overlay *sourceOverlay, goldenDir string) {
+	t.Helper()
+	cwd, _ := os.Getwd()
+	written := make(map[string]bool)
+	for _, path := range overlay.paths() {
+		rel, err := filepath.Rel(cwd, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		written[filepath.ToSlash(rel)] = true
+		got, _ := overlay.ReadFile(path)
+		golden := filepath.Join(goldenDir, rel+".golden")
+		if *updateGolden {
+			if err := os.MkdirAll(filepath.Dir(golden), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(golden, got, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		want, err := os.ReadFile(golden)
+		if err != nil {
+			t.Errorf("%s: %v (使用 -update 生成 golden 文件)", rel, err)
+			continue
+		}
+		if string(got) != string(want) {
+			t.Errorf("%s 与 %s 不一致:\n--- got ---\n%s\n--- want ---\n%s", rel, golden, got, want)
+		}
+	}
+	if *updateGolden {
+		return
+	}
+	err := filepath.WalkDir(goldenDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, _ := filepath.Rel(goldenDir, path)
+		if rel = strings.TrimSuffix(filepath.ToSlash(rel), ".golden"); !written[rel] {
+			t.Errorf("golden 文件 %s 对应的 %s 没有被修改", path, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGenApiPipelineGolden(t *testing.T) {
+	goldenDir := setupPipelineProject(t, "gen-api")
+
+	info, err := buildApiInfo("Song", "Play", "POST", "/:id/play")
+	if err != nil {
+		t.Fatal(err)
+	}
+	prompt, err := buildApiPrompt(info, "播放歌曲并把播放次数加一, 返回更新后的歌曲")
+	if err != nil {
+		t.Fatalf("buildApiPrompt() error = %v", err)
+	}
+	if !strings.Contains(prompt, "type SongResponse struct") {
+		t.Errorf("prompt does not include the retrieved dto.SongResponse declaration")
+	}
+	overlay, err := generateUntilCompiles(prompt, apiSnippetsSchema, func(response string, overlay *sourceOverlay) error {
+		snippets, err := parseApiSnippets(response)
+		if err != nil {
+			return err
+		}
+		return injectGeneratedCode(info, snippets, overlay)
+	})
+	if err != nil {
+		t.Fatalf("generateUntilCompiles() error = %v", err)
+	}
+	checkGolden(t, overlay, goldenDir)
+}
+
+func TestGenLogicPipelineGolden(t *testing.T) {
+	goldenDir := setupPipelineProject(t, "gen-logic")
+
+	info, err := buildLogicAdditionInfo("Song", "GetByID", "区分无效 ID、不存在和其他错误", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prompt, err := buildPromptFromInfo(info)
+	if err != nil {
+		t.Fatalf("buildPromptFromInfo() error = %v", err)
+	}
+	overlay, err := generateUntilCompiles(prompt, modifiedSnippetsSchema, func(response string, overlay *sourceOverlay) error {
+		snippets, err := parseModifiedCodeSnippets(response)
+		if err != nil {
+			return err
+		}
+		return applyGeneratedCode(info, snippets, overlay)
+	})
+	if err != nil {
+		t.Fatalf("generateUntilCompiles() error = %v", err)
+	}
+	checkGolden(t, overlay, goldenDir)
+}
@@ -0,0 +1,458 @@
+package command
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// generatedStorePath 记录了生成器写出的每个顶层声明的指纹, 需要随项目一起提交
+const generatedStorePath = ".goprojectstarter/generated.json"
+
+// overwriteGenerated 为 true 时 -F 退回到整文件覆盖的旧行为
+var overwriteGenerated bool
+
+// generatedStore 是 generatedStorePath 的内容: 文件路径 -> 声明 -> 指纹
+type generatedStore struct {
+	Files map[string]map[string]string `json:"files"`
+}
+
+// regenReport 汇总一次合并中每个声明的处理结果
+type regenReport struct {
+	Replaced []string // 未被修改过, 已替换为新版本
+	Added    []string // 新模板中新增的声明
+	Kept     []string // 已被手动修改, 原样保留
+	Merged   []string // 已被手动修改, 但合并了新增的字段
+	Deleted  []string // 曾经生成但已被手动删除, 不再恢复
+}
+
+func loadGeneratedStore() (*generatedStore, error) {
+	store := &generatedStore{Files: make(map[string]map[string]string)}
+	content, err := os.ReadFile(generatedStorePath)
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, store); err != nil {
+		return nil, fmt.Errorf("解析 %s 失败: %w", generatedStorePath, err)
+	}
+	if store.Files == nil {
+		store.Files = make(map[string]map[string]string)
+	}
+	return store, nil
+}
+
+func (s *generatedStore) save() error {
+	if err := os.MkdirAll(filepath.Dir(generatedStorePath), 0o755); err != nil {
+		return err
+	}
+	content, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(generatedStorePath, append(content, '\n'), 0o644)
+}
+
+// recordGenerated 记录文件中由生成器写出的声明指纹
+func recordGenerated(filePath string, hashes map[string]string) {
+	store, err := loadGeneratedStore()
+	if err != nil {
+		fmt.Printf("     ⚠️ 无法读取生成记录: %v\n", err)
+		return
+	}
+	store.Files[filepath.ToSlash(filePath)] = hashes
+	if err := store.save(); err != nil {
+		fmt.Printf("     ⚠️ 无法写入生成记录 %s: %v\n", generatedStorePath, err)
+	}
+}
+
+// recordedHashes 返回文件上次生成时记录的声明指纹, 文件未被记录时返回 nil
+func recordedHashes(filePath string) map[string]string {
+	store, err := loadGeneratedStore()
+	if err != nil {
+		fmt.Printf("     ⚠️ 无法读取生成记录: %v\n", err)
+		return nil
+	}
+	return store.Files[filepath.ToSlash(filePath)]
+}
+
+// sourceDecl 是一个顶层声明以及它 (连同文档注释) 在源码中的字节范围
+type sourceDecl struct {
+	Key        string
+	Decl       ast.Decl
+	Start, End int
+}
+
+// parseDecls 解析源码并按出现顺序返回除 import 以外的所有顶层声明
+func parseDecls(src []byte) (*token.FileSet, *ast.File, []sourceDecl, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var decls []sourceDecl
+	for _, d := range file.Decls {
+		if gd, ok := d.(*ast.GenDecl); ok && gd.Tok == token.IMPORT {
+			continue
+		}
+		start := d.Pos()
+		switch d := d.(type) {
+		case *ast.FuncDecl:
+			if d.Doc != nil {
+				start = d.Doc.Pos()
+			}
+		case *ast.GenDecl:
+			if d.Doc != nil {
+				start = d.Doc.Pos()
+			}
+		}
+		decls = append(decls, sourceDecl{
+			Key:   declKey(fset, d),
+			Decl:  d,
+			Start: fset.Position(start).Offset,
+			End:   fset.Position(d.End()).Offset,
+		})
+	}
+	return fset, file, decls, nil
+}
+
+// declKey 为顶层声明生成稳定的标识, 例如 "func (*SongHandler).Create" 或 "type SongResponse"
+func declKey(fset *token.FileSet, d ast.Decl) string {
+	switch d := d.(type) {
+	case *ast.FuncDecl:
+		if d.Recv != nil && len(d.Recv.List) > 0 {
+			return fmt.Sprintf("func (%s).%s", exprString(fset, d.Recv.List[0].Type), d.Name.Name)
+		}
+		return "func " + d.Name.Name
+	case *ast.GenDecl:
+		var names []string
+		for _, spec := range d.Specs {
+			switch s := spec.(type) {
+			case *ast.TypeSpec:
+				names = append(names, s.Name.Name)
+			case *ast.ValueSpec:
+				for _, n := range s.Names {
+					names = append(names, n.Name)
+				}
+			}
+		}
+		return d.Tok.String() + " " + strings.Join(names, ",")
+	}
+	return ""
+}
+
+// declHash 计算声明的指纹。指纹基于词法单元而不是原始文本,
+// 因此 gofmt/gofumpt/goimports 等格式化工具带来的空白变化不会被误判为手动修改。
+func declHash(src []byte) string {
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+	var s scanner.Scanner
+	s.Init(file, src, nil, scanner.ScanComments)
+
+	h := sha256.New()
+	for {
+		_, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok == token.SEMICOLON {
+			continue
+		}
+		if tok == token.COMMENT {
+			lit = strings.Join(strings.Fields(strings.TrimPrefix(lit, "//")), " ")
+		}
+		h.Write([]byte(tok.String()))
+		h.Write([]byte{0})
+		h.Write([]byte(lit))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// hashDecls 计算源码中每个顶层声明的指纹
+func hashDecls(src []byte) (map[string]string, error) {
+	_, _, decls, err := parseDecls(src)
+	if err != nil {
+		return nil, err
+	}
+	hashes := make(map[string]string, len(decls))
+	for _, d := range decls {
+		hashes[d.Key] = declHash(src[d.Start:d.End])
+	}
+	return hashes, nil
+}
+
+// sourceEdit 是对源码字节区间 [Start, End) 的一次替换
+type sourceEdit struct {
+	Start, End int
+	Text       string
+}
+
+// mergeGenerated 将新渲染的代码合并进已存在的文件:
+//   - 指纹与上次生成时一致的声明被替换为新版本;
+//   - 新模板中新增的声明被追加到文件末尾;
+//   - 被手动修改过的声明原样保留, 但结构体会补上新增的字段, 函数中的复合字面量会补上新增的键值;
+//   - 上次生成过但已被手动删除的声明不会恢复。
+//
+// recorded 为上次生成时的指纹, 文件未被记录时为 nil, 此时只有与新版本完全一致的声明视为未修改。
+// 返回合并后的源码以及需要写入生成记录的新指纹。
+func mergeGenerated(existing, rendered []byte, recorded map[string]string) ([]byte, map[string]string, *regenReport, error) {
+	_, oldFile, oldDecls, err := parseDecls(existing)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("无法解析已存在的文件: %w", err)
+	}
+	newFset, newFile, newDecls, err := parseDecls(rendered)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("无法解析新渲染的代码: %w", err)
+	}
+
+	oldByKey := make(map[string]sourceDecl, len(oldDecls))
+	for _, d := range oldDecls {
+		oldByKey[d.Key] = d
+	}
+
+	report := &regenReport{}
+	hashes := make(map[string]string)
+	var edits []sourceEdit
+	var appended []string
+	for _, nd := range newDecls {
+		newText := string(rendered[nd.Start:nd.End])
+		newHash := declHash(rendered[nd.Start:nd.End])
+
+		od, exists := oldByKey[nd.Key]
+		if !exists {
+			if _, wasGenerated := recorded[nd.Key]; wasGenerated {
+				report.Deleted = append(report.Deleted, nd.Key)
+				continue
+			}
+			appended = append(appended, newText)
+			hashes[nd.Key] = newHash
+			report.Added = append(report.Added, nd.Key)
+			continue
+		}
+
+		oldHash := declHash(existing[od.Start:od.End])
+		if oldHash == newHash {
+			hashes[nd.Key] = newHash
+			continue
+		}
+		if recorded[nd.Key] == oldHash {
+			edits = append(edits, sourceEdit{Start: od.Start, End: od.End, Text: newText})
+			hashes[nd.Key] = newHash
+			report.Replaced = append(report.Replaced, nd.Key)
+			continue
+		}
+
+		// 手动修改过的声明: 保留原指纹, 下次仍会被识别为已修改
+		if h, ok := recorded[nd.Key]; ok {
+			hashes[nd.Key] = h
+		}
+		mergeEdits := mergeAdditions(existing, od.Decl, oldFile, rendered, nd.Decl, newFset)
+		if len(mergeEdits) > 0 {
+			edits = append(edits, mergeEdits...)
+			report.Merged = append(report.Merged, nd.Key)
+		} else {
+			report.Kept = append(report.Kept, nd.Key)
+		}
+	}
+
+	merged := applyEdits(existing, edits)
+	for _, text := range appended {
+		merged = append(merged, []byte("\n\n"+text+"\n")...)
+	}
+
+	merged, err = mergeImports(merged, newFile)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return merged, hashes, report, nil
+}
+
+// applyEdits 按从后往前的顺序应用互不重叠的编辑, 返回新的源码
+func applyEdits(src []byte, edits []sourceEdit) []byte {
+	sort.Slice(edits, func(i, j int) bool { return edits[i].Start > edits[j].Start })
+	out := append([]byte(nil), src...)
+	for _, e := range edits {
+		out = append(out[:e.Start], append([]byte(e.Text), out[e.End:]...)...)
+	}
+	return out
+}
+
+// mergeAdditions 为被手动修改过的声明补上新版本中新增的结构体字段和复合字面量键值
+func mergeAdditions(oldSrc []byte, oldDecl ast.Decl, oldFile *ast.File, newSrc []byte, newDecl ast.Decl, newFset *token.FileSet) []sourceEdit {
+	var edits []sourceEdit
+	oldBase := oldFile.FileStart
+	newBase := token.Pos(newFset.File(newDecl.Pos()).Base())
+
+	// 结构体: 按字段名补上缺失的字段
+	oldStructs := collectStructs(oldDecl)
+	for name, ns := range collectStructs(newDecl) {
+		oldStruct, ok := oldStructs[name]
+		if !ok {
+			continue
+		}
+		existing := make(map[string]bool)
+		for _, f := range oldStruct.Fields.List {
+			for _, n := range f.Names {
+				existing[n.Name] = true
+			}
+		}
+		var lines []string
+		for _, f := range ns.Fields.List {
+			if len(f.Names) == 0 || existing[f.Names[0].Name] {
+				continue
+			}
+			start, end := f.Pos(), f.End()
+			if f.Comment != nil {
+				end = f.Comment.End()
+			}
+			lines = append(lines, string(newSrc[start-newBase:end-newBase]))
+		}
+		if len(lines) > 0 {
+			edits = append(edits, insertBeforeClosing(oldSrc, int(oldStruct.Fields.Closing-oldBase), lines, ""))
+		}
+	}
+
+	// 函数: 按类型匹配复合字面量, 补上缺失的键值 (例如 mapper 的 ToResponse)
+	oldLits := collectKeyedLits(oldDecl, oldSrc, oldBase)
+	for typ, nl := range collectKeyedLits(newDecl, newSrc, newBase) {
+		ol, ok := oldLits[typ]
+		if !ok {
+			continue
+		}
+		existing := make(map[string]bool)
+		for _, elt := range ol.Elts {
+			if kv, ok := elt.(*ast.KeyValueExpr); ok {
+				if ident, ok := kv.Key.(*ast.Ident); ok {
+					existing[ident.Name] = true
+				}
+			}
+		}
+		var lines []string
+		for _, elt := range nl.Elts {
+			kv, ok := elt.(*ast.KeyValueExpr)
+			if !ok {
+				continue
+			}
+			if ident, ok := kv.Key.(*ast.Ident); ok && !existing[ident.Name] {
+				lines = append(lines, string(newSrc[kv.Pos()-newBase:kv.End()-newBase]))
+			}
+		}
+		if len(lines) > 0 {
+			edits = append(edits, insertBeforeClosing(oldSrc, int(ol.Rbrace-oldBase), lines, ","))
+		}
+	}
+	return edits
+}
+
+// collectStructs 返回声明中按名称索引的结构体类型
+func collectStructs(d ast.Decl) map[string]*ast.StructType {
+	structs := make(map[string]*ast.StructType)
+	if gd, ok := d.(*ast.GenDecl); ok && gd.Tok == token.TYPE {
+		for _, spec := range gd.Specs {
+			if ts, ok := spec.(*ast.TypeSpec); ok {
+				if st, ok := ts.Type.(*ast.StructType); ok {
+					structs[ts.Name.Name] = st
+				}
+			}
+		}
+	}
+	return structs
+}
+
+// collectKeyedLits 返回函数体中按类型文本索引的复合字面量 (同一类型只取第一个)
+func collectKeyedLits(d ast.Decl, src []byte, base token.Pos) map[string]*ast.CompositeLit {
+	lits := make(map[string]*ast.CompositeLit)
+	fd, ok := d.(*ast.FuncDecl)
+	if !ok || fd.Body == nil {
+		return lits
+	}
+	ast.Inspect(fd.Body, func(n ast.Node) bool {
+		cl, ok := n.(*ast.CompositeLit)
+		if !ok || cl.Type == nil {
+			return true
+		}
+		typ := string(src[cl.Type.Pos()-base : cl.Type.End()-base])
+		if _, seen := lits[typ]; !seen {
+			lits[typ] = cl
+		}
+		return true
+	})
+	return lits
+}
+
+// insertBeforeClosing 生成在右花括号 closing 之前插入若干行的编辑, sep 为每行末尾的分隔符
+func insertBeforeClosing(src []byte, closing int, lines []string, sep string) sourceEdit {
+	content := strings.TrimRight(string(src[:closing]), " \t\n")
+	var b strings.Builder
+	if sep != "" && !strings.HasSuffix(content, sep) && !strings.HasSuffix(content, "{") {
+		// 最后一个元素与右花括号在同一行时没有结尾的分隔符
+		b.WriteString(sep)
+	}
+	b.WriteString("\n")
+	for _, line := range lines {
+		b.WriteString("\t" + line + sep + "\n")
+	}
+	return sourceEdit{Start: len(content), End: closing, Text: b.String()}
+}
+
+// mergeImports 把新代码中用到而旧文件中缺失的 import 补上, 未使用的 import 留给 goimports 清理
+func mergeImports(src []byte, newFile *ast.File) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("合并后的代码无法解析: %w", err)
+	}
+	for _, imp := range newFile.Imports {
+		path, _ := strconv.Unquote(imp.Path.Value)
+		name := ""
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		astutil.AddNamedImport(fset, file, name, path)
+	}
+	return formatNode(fset, file)
+}
+
+func formatNode(fset *token.FileSet, file *ast.File) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, file); err != nil {
+		return nil, fmt.Errorf("格式化 AST 失败: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// printRegenReport 输出一次安全重新生成的结果
+func printRegenReport(report *regenReport) {
+	for _, key := range report.Replaced {
+		fmt.Printf("     ↻ 已更新: %s\n", key)
+	}
+	for _, key := range report.Added {
+		fmt.Printf("     + 已新增: %s\n", key)
+	}
+	for _, key := range report.Merged {
+		fmt.Printf("     ⚠️ 已手动修改, 仅合并了新增字段: %s\n", key)
+	}
+	for _, key := range report.Kept {
+		fmt.Printf("     ⚠️ 已手动修改, 保持不变: %s\n", key)
+	}
+	for _, key := range report.Deleted {
+		fmt.Printf("     - 已被手动删除, 不再恢复: %s\n", key)
+	}
+}
@@ -0,0 +1,122 @@
+package command
+
+import (
+	"strings"
+	"testing"
+)
+
+const regenV1 = `package dto
+
+type SongResponse struct {
+	ID    uint   ` + "`json:\"id\"`" + `
+	Title string ` + "`json:\"title\"`" + `
+}
+
+func ToResponse(e *Song) *SongResponse {
+	return &SongResponse{
+		ID:    e.ID,
+		Title: e.Title,
+	}
+}
+
+func Helper() int {
+	return 1
+}
+`
+
+const regenV2 = `package dto
+
+type SongResponse struct {
+	ID    uint   ` + "`json:\"id\"`" + `
+	Title string ` + "`json:\"title\"`" + `
+	Plays int    ` + "`json:\"plays\"`" + `
+}
+
+func ToResponse(e *Song) *SongResponse {
+	return &SongResponse{
+		ID:    e.ID,
+		Title: e.Title,
+		Plays: e.Plays,
+	}
+}
+
+func Helper() int {
+	return 2
+}
+
+func Added() {}
+`
+
+func TestMergeGenerated(t *testing.T) {
+	recorded, err := hashDecls([]byte(regenV1))
+	if err != nil {
+		t.Fatalf("hashDecls() error = %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		existing     string
+		recorded     map[string]string
+		wantContains []string
+		wantReplaced int
+		wantMerged   int
+		wantKept     int
+		wantDeleted  int
+	}{
+		{
+			name:         "untouched declarations are replaced",
+			existing:     regenV1,
+			recorded:     recorded,
+			wantContains: []string{"Plays int", "Plays: e.Plays", "return 2", "func Added()"},
+			wantReplaced: 3,
+		},
+		{
+			name:         "formatting changes do not count as modifications",
+			existing:     strings.ReplaceAll(regenV1, "\treturn 1\n", "\n\treturn 1\n\n"),
+			recorded:     recorded,
+			wantContains: []string{"return 2"},
+			wantReplaced: 3,
+		},
+		{
+			name:         "modified declarations are kept but receive new fields",
+			existing:     strings.Replace(strings.Replace(regenV1, "Title: e.Title,", "Title: strings.ToUpper(e.Title),", 1), "return 1", "return 42", 1),
+			recorded:     recorded,
+			wantContains: []string{"strings.ToUpper(e.Title)", "Plays: e.Plays", "return 42"},
+			wantReplaced: 1,
+			wantMerged:   1,
+			wantKept:     1,
+		},
+		{
+			name:         "deleted declarations are not restored",
+			existing:     strings.Replace(regenV1, "func Helper() int {\n\treturn 1\n}\n", "", 1),
+			recorded:     recorded,
+			wantReplaced: 2,
+			wantDeleted:  1,
+		},
+		{
+			name:         "untracked file keeps everything that differs",
+			existing:     regenV1,
+			wantContains: []string{"Plays int", "Plays: e.Plays", "return 1"},
+			wantMerged:   2,
+			wantKept:     1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, _, report, err := mergeGenerated([]byte(tt.existing), []byte(regenV2), tt.recorded)
+			if err != nil {
+				t.Fatalf("mergeGenerated() error = %v", err)
+			}
+			for _, want := range tt.wantContains {
+				if !strings.Contains(string(merged), want) {
+					t.Errorf("merged output missing %q:\n%s", want, merged)
+				}
+			}
+			if len(report.Replaced) != tt.wantReplaced || len(report.Merged) != tt.wantMerged ||
+				len(report.Kept) != tt.wantKept || len(report.Deleted) != tt.wantDeleted {
+				t.Errorf("report = %+v", report)
+			}
+		})
+	}
+}
@@ -0,0 +1,598 @@
+package command
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/Skyenought/goprojectstarter/internal/common"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/tools/go/packages"
+)
+
+var renameDryRun bool
+
+var renameCmd = &cobra.Command{
+	Use:   "rename",
+	Short: "跨层重命名实体或方法",
+	Long: `基于 go/types 在实体、DTO、仓库、服务、处理器、DI 容器和路由之间同步重命名标识符,
+同时按 common.ToSnakeCase 的约定重命名文件, 并更新路由路径和 Swagger 注释。
+数据库表名保持不变: 如果实体没有 TableName() 方法, 会自动补上一个返回原表名的方法。`,
+}
+
+var renameEntityCmd = &cobra.Command{
+	Use:     "entity <Old> <New>",
+	Short:   "重命名实体, 例如: rename entity Song Track",
+	Args:    cobra.ExactArgs(2),
+	Run:     runRenameEntity,
+	Example: "  goprojectstarter rename entity Song Track --dry-run",
+}
+
+var renameMethodCmd = &cobra.Command{
+	Use:     "method <Entity.Method> <NewMethod>",
+	Short:   "重命名实体各层中的同名方法, 例如: rename method Song.Promote Feature",
+	Args:    cobra.ExactArgs(2),
+	Run:     runRenameMethod,
+	Example: "  goprojectstarter rename method Song.Promote Feature --dry-run",
+}
+
+func init() {
+	rootCmd.AddCommand(renameCmd)
+	renameCmd.AddCommand(renameEntityCmd)
+	renameCmd.AddCommand(renameMethodCmd)
+	renameCmd.PersistentFlags().BoolVar(&renameDryRun, "dry-run", false, "只打印将要进行的修改, 不写入任何文件")
+}
+
+// renamePlan 汇总一次重命名需要进行的全部修改
+type renamePlan struct {
+	module    string
+	edits     map[string]map[int]sourceEdit // 文件 -> 起始偏移 -> 编辑, 按偏移去重 (测试包会重复加载同一文件)
+	renames   map[string]string             // 旧文件路径 -> 新文件路径
+	renameKey func(string) string           // 生成记录中声明标识的重命名规则
+}
+
+func newRenamePlan(module string) *renamePlan {
+	return &renamePlan{module: module, edits: make(map[string]map[int]sourceEdit), renames: make(map[string]string)}
+}
+
+func (p *renamePlan) add(file string, e sourceEdit) {
+	if p.edits[file] == nil {
+		p.edits[file] = make(map[int]sourceEdit)
+	}
+	p.edits[file][e.Start] = e
+}
+
+func runRenameEntity(cmd *cobra.Command, args []string) {
+	oldName, newName := args[0], args[1]
+	if !token.IsExported(oldName) || !token.IsExported(newName) || !token.IsIdentifier(newName) {
+		fmt.Println("❌ 实体名必须是以大写字母开头的合法标识符")
+		return
+	}
+
+	module, err := getProjectModule()
+	if err != nil {
+		fmt.Printf("❌ 获取项目 module 失败: %v\n", err)
+		return
+	}
+	_, entityPath, err := findEntityContent(oldName)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	info, err := parseEntityFile(entityPath, module)
+	if err != nil {
+		fmt.Printf("❌ 解析实体文件 %s 失败: %v\n", entityPath, err)
+		return
+	}
+
+	pkgs, err := loadProjectPackages()
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+
+	fmt.Printf("🚀 正在规划重命名 %s -> %s...\n", oldName, newName)
+	plan := newRenamePlan(module)
+	plan.renameKey = func(s string) string { return renameWords(s, oldName, newName) }
+
+	// 仅当路由使用默认的表名路径时才跟随实体名修改, 自定义路径保持不变
+	oldPath, newPath := "", ""
+	if info.TableName == defaultTableName(oldName) {
+		oldPath, newPath = "/"+info.TableName, "/"+defaultTableName(newName)
+	}
+
+	forEachProjectFile(pkgs, func(pkg *packages.Package, filename string, file *ast.File) {
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.Ident:
+				obj := pkg.TypesInfo.ObjectOf(n)
+				if !plan.ownsObject(obj) {
+					return true
+				}
+				renamed := renameWords(n.Name, oldName, newName)
+				if renamed == n.Name {
+					return true
+				}
+				// 结构体字段对应数据库列和 JSON, 只有类型本身被重命名的字段 (例如 Router.SongHandler) 才跟着改名
+				if v, ok := obj.(*types.Var); ok && v.IsField() && !containsWord(types.TypeString(v.Type(), packageName), oldName) {
+					return true
+				}
+				plan.add(filename, nodeEdit(pkg.Fset, n, renamed))
+			case *ast.BasicLit:
+				// 字符串中的 SQL、列名、事件名等与数据库或外部约定绑定, 只有路由路径跟随实体名修改。
+				// Swagger 注解写在注释中, 由下面的注释替换处理
+				if oldPath == "" || n.Kind != token.STRING {
+					return true
+				}
+				value, err := strconv.Unquote(n.Value)
+				if err != nil {
+					return true
+				}
+				if renamed := renamePathPrefix(value, oldPath, newPath); renamed != value {
+					plan.add(filename, nodeEdit(pkg.Fset, n, strconv.Quote(renamed)))
+				}
+			}
+			return true
+		})
+		for _, group := range file.Comments {
+			for _, c := range group.List {
+				text := renameWords(c.Text, oldName, newName)
+				if oldPath != "" {
+					text = renamePathInText(text, oldPath, newPath)
+				}
+				if text != c.Text {
+					plan.add(filename, nodeEdit(pkg.Fset, c, text))
+				}
+			}
+		}
+		if renamed := renameFileName(filename, oldName, newName); renamed != filename {
+			plan.renames[filename] = renamed
+		}
+	})
+
+	// 保持数据库表名不变
+	if !info.HasTableNameMethod {
+		absEntity, _ := filepath.Abs(entityPath)
+		src, err := os.ReadFile(absEntity)
+		if err == nil {
+			method := fmt.Sprintf("\n// TableName 重命名后保持原有的数据库表名\nfunc (%s) TableName() string {\n\treturn %q\n}\n", newName, info.TableName)
+			plan.add(absEntity, sourceEdit{Start: len(src), End: len(src), Text: method})
+		}
+	}
+
+	plan.apply()
+}
+
+func runRenameMethod(cmd *cobra.Command, args []string) {
+	entityName, oldMethod, ok := strings.Cut(args[0], ".")
+	newMethod := args[1]
+	if !ok || !token.IsIdentifier(entityName) || !token.IsIdentifier(oldMethod) {
+		fmt.Println("❌ 第一个参数的格式应为 <Entity>.<Method>, 例如 Song.Promote")
+		return
+	}
+	if !token.IsExported(newMethod) || !token.IsIdentifier(newMethod) {
+		fmt.Println("❌ 新方法名必须是以大写字母开头的合法标识符")
+		return
+	}
+
+	module, err := getProjectModule()
+	if err != nil {
+		fmt.Printf("❌ 获取项目 module 失败: %v\n", err)
+		return
+	}
+	pkgs, err := loadProjectPackages()
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+
+	fmt.Printf("🚀 正在规划重命名 %s.%s -> %s...\n", entityName, oldMethod, newMethod)
+	plan := newRenamePlan(module)
+	plan.renameKey = func(s string) string {
+		if strings.HasSuffix(s, "."+oldMethod) && containsWord(s, entityName) {
+			return strings.TrimSuffix(s, oldMethod) + newMethod
+		}
+		return s
+	}
+
+	forEachProjectFile(pkgs, func(pkg *packages.Package, filename string, file *ast.File) {
+		touched := false
+		ast.Inspect(file, func(n ast.Node) bool {
+			id, ok := n.(*ast.Ident)
+			if !ok {
+				return true
+			}
+			obj := pkg.TypesInfo.ObjectOf(id)
+			if !plan.ownsObject(obj) {
+				return true
+			}
+			switch obj := obj.(type) {
+			case *types.Func:
+				sig, _ := obj.Type().(*types.Signature)
+				if obj.Name() != oldMethod || sig == nil || sig.Recv() == nil || !isEntityReceiver(sig.Recv().Type(), entityName) {
+					return true
+				}
+				plan.add(filename, nodeEdit(pkg.Fset, id, newMethod))
+				touched = true
+			case *types.Var:
+				// 仓库 mock 中的 XxxFunc 字段
+				if obj.IsField() && obj.Name() == oldMethod+"Func" {
+					plan.add(filename, nodeEdit(pkg.Fset, id, newMethod+"Func"))
+					touched = true
+				}
+			}
+			return true
+		})
+		if !touched {
+			return
+		}
+
+		// 同一文件中: mock 的调用计数字符串, 被重命名方法的文档注释, 以及路由注册中的路径
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.BasicLit:
+				value, err := strconv.Unquote(n.Value)
+				if n.Kind != token.STRING || err != nil {
+					return true
+				}
+				// "GetAll" 以及测试中的 "GetAll() error = %v"
+				if value == oldMethod || strings.HasPrefix(value, oldMethod+"(") {
+					renamed := newMethod + strings.TrimPrefix(value, oldMethod)
+					plan.add(filename, nodeEdit(pkg.Fset, n, strconv.Quote(renamed)))
+				}
+			case *ast.FuncDecl:
+				// 生成的测试函数: TestSongService_GetAll
+				if n.Recv == nil && strings.HasPrefix(n.Name.Name, "Test") && strings.HasSuffix(n.Name.Name, "_"+oldMethod) {
+					plan.add(filename, nodeEdit(pkg.Fset, n.Name, strings.TrimSuffix(n.Name.Name, oldMethod)+newMethod))
+				}
+				if n.Name.Name == oldMethod && n.Doc != nil {
+					for _, c := range n.Doc.List {
+						if text := renameMethodInComment(c.Text, oldMethod, newMethod); text != c.Text {
+							plan.add(filename, nodeEdit(pkg.Fset, c, text))
+						}
+					}
+				}
+			case *ast.CallExpr:
+				if !callReferences(n, oldMethod) {
+					return true
+				}
+				for _, arg := range n.Args {
+					if lit, ok := arg.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+						value, _ := strconv.Unquote(lit.Value)
+						if renamed := renamePathSegments(value, oldMethod, newMethod); renamed != value {
+							plan.add(filename, nodeEdit(pkg.Fset, lit, strconv.Quote(renamed)))
+						}
+					}
+				}
+			}
+			return true
+		})
+	})
+
+	plan.apply()
+}
+
+// loadProjectPackages 加载当前项目的全部包 (包括测试文件) 及其类型信息
+func loadProjectPackages() ([]*packages.Package, error) {
+	cfg := &packages.Config{
+		Mode:  packages.NeedName | packages.NeedFiles | packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo | packages.NeedImports | packages.NeedDeps,
+		Tests: true,
+	}
+	pkgs, err := packages.Load(cfg, "./...")
+	if err != nil {
+		return nil, fmt.Errorf("加载项目包失败: %w", err)
+	}
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		for _, e := range pkg.Errors {
+			fmt.Printf("   ⚠️ %s: %v (类型信息可能不完整, 请检查重命名结果)\n", pkg.PkgPath, e)
+		}
+	})
+	return pkgs, nil
+}
+
+// forEachProjectFile 遍历所有包中的源文件, 跳过 go 工具生成的测试主文件
+func forEachProjectFile(pkgs []*packages.Package, fn func(pkg *packages.Package, filename string, file *ast.File)) {
+	for _, pkg := range pkgs {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		for _, file := range pkg.Syntax {
+			filename := pkg.Fset.Position(file.Pos()).Filename
+			if !strings.HasSuffix(filename, ".go") {
+				continue
+			}
+			fn(pkg, filename, file)
+		}
+	}
+}
+
+// ownsObject 判断对象是否声明在当前项目中 (包名本身不参与重命名)
+func (p *renamePlan) ownsObject(obj types.Object) bool {
+	if obj == nil || obj.Pkg() == nil {
+		return false
+	}
+	if _, isPkg := obj.(*types.PkgName); isPkg {
+		return false
+	}
+	path := obj.Pkg().Path()
+	return path == p.module || strings.HasPrefix(path, p.module+"/")
+}
+
+// apply 打印计划, 并在非 dry-run 模式下写入修改、重命名文件和更新生成记录
+func (p *renamePlan) apply() {
+	cwd, _ := os.Getwd()
+	rel := func(path string) string {
+		if r, err := filepath.Rel(cwd, path); err == nil {
+			return r
+		}
+		return path
+	}
+
+	files := make(map[string]bool)
+	for f := range p.edits {
+		files[f] = true
+	}
+	for f := range p.renames {
+		files[f] = true
+	}
+	if len(files) == 0 {
+		fmt.Println("🤷 没有找到需要修改的内容。")
+		return
+	}
+	ordered := make([]string, 0, len(files))
+	for f := range files {
+		ordered = append(ordered, f)
+	}
+	sort.Strings(ordered)
+
+	store, err := loadGeneratedStore()
+	if err != nil {
+		fmt.Printf("   ⚠️ 无法读取生成记录: %v\n", err)
+	}
+
+	for _, file := range ordered {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			fmt.Printf("   ⚠️ 读取 %s 失败: %v\n", rel(file), err)
+			continue
+		}
+		var edits []sourceEdit
+		for _, e := range p.edits[file] {
+			edits = append(edits, e)
+		}
+		out := applyEdits(src, edits)
+		if formatted, err := format.Source(out); err == nil {
+			out = formatted
+		}
+
+		target := file
+		if renamed, ok := p.renames[file]; ok {
+			target = renamed
+			fmt.Printf("  -> %s => %s\n", rel(file), rel(target))
+		} else {
+			fmt.Printf("  -> %s\n", rel(file))
+		}
+		if len(edits) > 0 {
+			fmt.Printf("     %d 处修改\n", len(edits))
+		}
+		if renameDryRun {
+			printChangedLines(src, out)
+			continue
+		}
+
+		if err := os.WriteFile(target, out, 0o644); err != nil {
+			fmt.Printf("   ⚠️ 写入 %s 失败: %v\n", rel(target), err)
+			continue
+		}
+		if target != file {
+			if err := os.Remove(file); err != nil {
+				fmt.Printf("   ⚠️ 删除旧文件 %s 失败: %v\n", rel(file), err)
+			}
+		}
+		if store != nil {
+			p.updateStore(store, filepath.ToSlash(rel(file)), filepath.ToSlash(rel(target)), src, out)
+		}
+	}
+
+	if renameDryRun {
+		fmt.Println("\n📝 dry-run 模式, 未写入任何文件。")
+		return
+	}
+	if store != nil && len(store.Files) > 0 {
+		if err := store.save(); err != nil {
+			fmt.Printf("   ⚠️ 写入生成记录失败: %v\n", err)
+		}
+	}
+	_ = common.FormatImport()
+	_ = common.FormatFile()
+	fmt.Println("🎉 重命名完成! 请运行 go build ./... 并检查改动。")
+}
+
+// updateStore 把生成记录迁移到新的文件名和声明名上。重命名前未被手动修改过的声明, 重命名后依然视为未修改。
+func (p *renamePlan) updateStore(store *generatedStore, oldFile, newFile string, oldSrc, newSrc []byte) {
+	recorded, ok := store.Files[oldFile]
+	if !ok {
+		return
+	}
+	oldHashes, err := hashDecls(oldSrc)
+	if err != nil {
+		return
+	}
+	newHashes, err := hashDecls(newSrc)
+	if err != nil {
+		return
+	}
+	updated := make(map[string]string, len(recorded))
+	for key, hash := range recorded {
+		newKey := p.renameKey(key)
+		if oldHashes[key] == hash && newHashes[newKey] != "" {
+			updated[newKey] = newHashes[newKey]
+		} else {
+			updated[newKey] = hash
+		}
+	}
+	delete(store.Files, oldFile)
+	store.Files[newFile] = updated
+}
+
+// printChangedLines 以 -/+ 的形式打印修改前后不同的行
+func printChangedLines(before, after []byte) {
+	oldLines := strings.Split(string(before), "\n")
+	newLines := strings.Split(string(after), "\n")
+	if len(oldLines) != len(newLines) {
+		fmt.Printf("     (行数 %d -> %d)\n", len(oldLines), len(newLines))
+		return
+	}
+	for i := range oldLines {
+		if oldLines[i] != newLines[i] {
+			fmt.Printf("     - %s\n     + %s\n", strings.TrimSpace(oldLines[i]), strings.TrimSpace(newLines[i]))
+		}
+	}
+}
+
+func nodeEdit(fset *token.FileSet, n ast.Node, text string) sourceEdit {
+	return sourceEdit{Start: fset.Position(n.Pos()).Offset, End: fset.Position(n.End()).Offset, Text: text}
+}
+
+var wordPattern = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*`)
+
+// renameWords 替换文本中每个单词里按驼峰边界出现的 old (以及开头的小写形式),
+// 例如 Song -> Track 时 NewSongService -> NewTrackService, songRoutes -> trackRoutes, 但 Songbook 不变
+func renameWords(text, old, new string) string {
+	return wordPattern.ReplaceAllStringFunc(text, func(word string) string {
+		return renameCamelWord(word, old, new)
+	})
+}
+
+func renameCamelWord(word, old, new string) string {
+	lowerOld, lowerNew := toLowerCamel(old), toLowerCamel(new)
+	var b strings.Builder
+	for i := 0; i < len(word); {
+		if i == 0 && lowerOld != old && strings.HasPrefix(word, lowerOld) && camelBoundary(word, len(lowerOld)) {
+			b.WriteString(lowerNew)
+			i += len(lowerOld)
+			continue
+		}
+		if strings.HasPrefix(word[i:], old) && camelBoundary(word, i+len(old)) {
+			b.WriteString(new)
+			i += len(old)
+			continue
+		}
+		b.WriteByte(word[i])
+		i++
+	}
+	return b.String()
+}
+
+// camelBoundary 判断 word[i] 处是否是驼峰单词的边界 (结尾, 或后面跟着非小写字母)
+func camelBoundary(word string, i int) bool {
+	return i == len(word) || !unicode.IsLower(rune(word[i]))
+}
+
+// containsWord 判断 text 中是否按驼峰边界包含 word
+func containsWord(text, word string) bool {
+	for _, w := range wordPattern.FindAllString(text, -1) {
+		if renameCamelWord(w, word, "") != w {
+			return true
+		}
+	}
+	return false
+}
+
+// packageName 是只保留包名的 types.Qualifier, 避免模块路径中的单词影响匹配
+func packageName(p *types.Package) string {
+	return p.Name()
+}
+
+// renameFileName 按 ToSnakeCase 约定重命名以实体名开头的文件, 例如 song_repository_impl.go -> track_repository_impl.go
+func renameFileName(path, old, new string) string {
+	base := filepath.Base(path)
+	oldSnake, newSnake := common.ToSnakeCase(old), common.ToSnakeCase(new)
+	switch {
+	case base == oldSnake+".go":
+		return filepath.Join(filepath.Dir(path), newSnake+".go")
+	case strings.HasPrefix(base, oldSnake+"_"):
+		return filepath.Join(filepath.Dir(path), newSnake+strings.TrimPrefix(base, oldSnake))
+	}
+	return path
+}
+
+// renamePathPrefix 将以 oldPath 开头的路由路径替换为 newPath
+func renamePathPrefix(value, oldPath, newPath string) string {
+	if value == oldPath {
+		return newPath
+	}
+	if strings.HasPrefix(value, oldPath+"/") {
+		return newPath + strings.TrimPrefix(value, oldPath)
+	}
+	return value
+}
+
+// renamePathInText 替换注释中 (例如 Swagger 的 @Router) 出现的路由路径
+func renamePathInText(text, oldPath, newPath string) string {
+	re := regexp.MustCompile(regexp.QuoteMeta(oldPath) + `(/|\s|$)`)
+	return re.ReplaceAllString(text, newPath+"$1")
+}
+
+// isEntityReceiver 判断方法的接收者类型 (如 *SongHandler, songServiceImpl, SongRepository) 是否属于该实体
+func isEntityReceiver(t types.Type, entity string) bool {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	return containsWord(named.Obj().Name(), entity)
+}
+
+// renameMethodInComment 替换方法文档注释中的方法名以及 @Router 路径中对应的路径段
+func renameMethodInComment(text, old, new string) string {
+	text = regexp.MustCompile(`\b`+regexp.QuoteMeta(old)+`\b`).ReplaceAllString(text, new)
+	if strings.Contains(text, "@Router") {
+		fields := strings.Fields(text)
+		for _, f := range fields {
+			if strings.HasPrefix(f, "/") {
+				text = strings.Replace(text, f, renamePathSegments(f, old, new), 1)
+			}
+		}
+	}
+	return text
+}
+
+// renamePathSegments 替换路由路径中与方法名对应的路径段 (支持 promote, promote_all, promote-all 等写法)
+func renamePathSegments(path, old, new string) string {
+	forms := map[string]string{
+		strings.ToLower(old):                                  strings.ToLower(new),
+		common.ToSnakeCase(old):                               common.ToSnakeCase(new),
+		strings.ReplaceAll(common.ToSnakeCase(old), "_", "-"): strings.ReplaceAll(common.ToSnakeCase(new), "_", "-"),
+		toLowerCamel(old):                                     toLowerCamel(new),
+	}
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		if renamed, ok := forms[s]; ok {
+			segments[i] = renamed
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// callReferences 判断调用的参数中是否以选择器形式引用了名为 name 的方法, 例如 r.SongHandler.Promote
+func callReferences(call *ast.CallExpr, name string) bool {
+	for _, arg := range call.Args {
+		if sel, ok := arg.(*ast.SelectorExpr); ok && sel.Sel.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+package command
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// assertFileContains 检查文件包含 want 中的每一段文本, 且不包含 unwanted 中的任何一段
+func assertFileContains(t *testing.T, path string, want, unwanted []string) {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for _, s := range want {
+		if !strings.Contains(string(content), s) {
+			t.Errorf("%s 中缺少 %q:\n%s", path, s, content)
+		}
+	}
+	for _, s := range unwanted {
+		if strings.Contains(string(content), s) {
+			t.Errorf("%s 中不应包含 %q:\n%s", path, s, content)
+		}
+	}
+}
+
+func TestRenameEntity(t *testing.T) {
+	copyFixture(t, "rename")
+	runRenameEntity(nil, []string{"Song", "Track"})
+
+	for _, path := range []string{
+		"internal/domain/entity/song.go",
+		"internal/domain/repository/song_repository.go",
+		"internal/domain/repository/mock/song_repository_mock.go",
+		"internal/infrastructure/persistence/song_repository_impl.go",
+		"internal/application/service/song_service.go",
+		"internal/application/service/song_service_test.go",
+		"internal/interfaces/handler/song_handler.go",
+	} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s 没有被重命名", path)
+		}
+	}
+
+	// 表名保持不变, 字段对应数据库列, 不跟随实体名修改
+	assertFileContains(t, "internal/domain/entity/track.go",
+		[]string{"type Track struct", "SongCode string `gorm:\"column:song_code\" json:\"songCode\"`", "func (Track) TableName() string {\n\treturn \"songs\"\n}"},
+		nil)
+	// SQL 和列名字符串保持不变
+	assertFileContains(t, "internal/infrastructure/persistence/track_repository_impl.go",
+		[]string{"type trackRepositoryImpl struct", "func NewTrackRepository(db *web.DB) repository.TrackRepository", `Where("song_id = ?", id).Order("song_code desc")`, `Where("song_id = ? AND promoted = ?", id, false)`},
+		[]string{"track_id", "track_code"})
+	assertFileContains(t, "internal/domain/repository/mock/track_repository_mock.go",
+		[]string{"var _ repository.TrackRepository = (*TrackRepository)(nil)", `m.Calls["Promote"]++`},
+		nil)
+	assertFileContains(t, "internal/interfaces/handler/track_handler.go",
+		[]string{"type TrackHandler struct", "// @Router       /tracks/{id}/promote [post]", "// @Tags         Track", `"Track ID"`},
+		[]string{"/songs"})
+	assertFileContains(t, "internal/interfaces/router/router.go",
+		[]string{"TrackHandler *handler.TrackHandler", `Group("/api/v1").Group("/tracks")`, `songs.Get("/:id", r.TrackHandler.GetByID)`},
+		nil)
+	typeCheckClean(t)
+}
+
+func TestRenameEntityDefaultPlural(t *testing.T) {
+	copyFixture(t, "rename")
+	runRenameEntity(nil, []string{"Song", "Category"})
+
+	// 路由路径与 generate 的默认表名规则一致 (实体名的 snake_case 加 s), 重新生成 Category 时路径不变
+	assertFileContains(t, "internal/interfaces/router/router.go", []string{`Group("/api/v1").Group("/categorys")`}, []string{"/categories"})
+	assertFileContains(t, "internal/interfaces/handler/category_handler.go", []string{"/categorys/{id}/promote"}, []string{"/categories", "/songs"})
+	assertFileContains(t, "internal/domain/entity/category.go", []string{"func (Category) TableName() string {\n\treturn \"songs\"\n}"}, nil)
+	typeCheckClean(t)
+}
+
+func TestRenameEntityKeepsCustomRoutePath(t *testing.T) {
+	copyFixture(t, "rename")
+	entity := "internal/domain/entity/song.go"
+	src, err := os.ReadFile(entity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 表名不是默认的 songs 时, 路由路径也不是由实体名推导的, 保持不变
+	src = append(src, "\nfunc (Song) TableName() string { return \"music_songs\" }\n"...)
+	if err := os.WriteFile(entity, src, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	runRenameEntity(nil, []string{"Song", "Track"})
+	assertFileContains(t, "internal/interfaces/router/router.go", []string{`Group("/songs")`}, nil)
+	assertFileContains(t, "internal/interfaces/handler/track_handler.go", []string{"/songs/{id}/promote"}, nil)
+	assertFileContains(t, "internal/domain/entity/track.go", []string{`return "music_songs"`}, []string{"重命名后保持原有的数据库表名"})
+	typeCheckClean(t)
+}
+
+func TestRenameMethod(t *testing.T) {
+	copyFixture(t, "rename")
+	runRenameMethod(nil, []string{"Song.Promote", "Feature"})
+
+	assertFileContains(t, "internal/domain/repository/song_repository.go", []string{"Feature(id uint) error"}, []string{"Promote"})
+	assertFileContains(t, "internal/domain/repository/mock/song_repository_mock.go",
+		[]string{"FeatureFunc  func(id uint) error", `m.Calls["Feature"]++`, "return m.FeatureFunc(id)"},
+		[]string{"Promote"})
+	// 方法名之外的字符串和列名保持不变
+	assertFileContains(t, "internal/infrastructure/persistence/song_repository_impl.go",
+		[]string{"// Feature 把歌曲标记为推荐", "func (r *songRepositoryImpl) Feature(id uint) error", `Where("song_id = ? AND promoted = ?", id, false)`},
+		nil)
+	assertFileContains(t, "internal/domain/entity/song.go", []string{"Promoted bool"}, nil)
+	assertFileContains(t, "internal/application/service/song_service_test.go",
+		[]string{"func TestSongService_Feature(", "NewSongService(repo).Feature(1)", `t.Fatalf("Feature() error = %v", err)`, `repo.Calls["Feature"]`},
+		nil)
+	assertFileContains(t, "internal/interfaces/handler/song_handler.go",
+		[]string{"// Feature godoc", "// @Router       /songs/{id}/feature [post]", "func (h *SongHandler) Feature(c *web.Ctx) error"},
+		[]string{"Promote", "/promote"})
+	assertFileContains(t, "internal/interfaces/router/router.go",
+		[]string{`songs.Post("/:id/feature", r.SongHandler.Feature)`, `songs.Get("/:id", r.SongHandler.GetByID)`},
+		nil)
+	typeCheckClean(t)
+}
@@ -0,0 +1,385 @@
+package command
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+var (
+	// contextTopK 是自动检索并放入 prompt 的相关声明数量, 0 表示关闭自动检索
+	contextTopK int
+	// contextPicks 是无论排名如何都要放入 prompt 的声明, 例如 dto.CreateSongRequest 或 ErrNotFound
+	contextPicks []string
+)
+
+// retrieveDepth 是从种子声明出发沿类型引用遍历的最大深度
+const retrieveDepth = 2
+
+// RelatedDecl 是检索到的一个相关声明
+type RelatedDecl struct {
+	Name   string // 包名.标识符, 例如 dto.SongResponse
+	Kind   string // 实体、DTO、仓库接口、服务接口、错误变量等
+	Path   string // 相对项目根目录的文件路径
+	Code   string
+	Score  float64
+	Picked bool // 由 --context 手动指定
+}
+
+// methodRef 指定一个作为检索起点的方法
+type methodRef struct {
+	Recv string // 接收者类型名, 例如 SongHandler
+	Name string
+}
+
+// declSite 是项目中一个顶层声明的位置
+type declSite struct {
+	pkg  *packages.Package
+	file string
+	node ast.Node // *ast.FuncDecl、*ast.TypeSpec 或 *ast.ValueSpec
+	decl *ast.GenDecl
+}
+
+// contextRetriever 从目标实体和方法出发, 用 go/types 沿引用关系查找相关的项目声明并排序
+type contextRetriever struct {
+	cwd   string
+	sites map[types.Object]*declSite
+	// exclude 中的文件已经完整地放进 prompt, 其中的声明不再重复检索
+	exclude map[string]bool
+}
+
+// newContextRetriever 加载项目包并为所有顶层声明建立索引
+func newContextRetriever() (*contextRetriever, error) {
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo | packages.NeedImports | packages.NeedDeps,
+	}
+	pkgs, err := packages.Load(cfg, "./...")
+	if err != nil {
+		return nil, fmt.Errorf("加载项目包失败: %w", err)
+	}
+	cwd, _ := os.Getwd()
+	r := &contextRetriever{cwd: cwd, sites: make(map[types.Object]*declSite), exclude: make(map[string]bool)}
+	forEachProjectFile(pkgs, func(pkg *packages.Package, filename string, file *ast.File) {
+		for _, decl := range file.Decls {
+			switch d := decl.(type) {
+			case *ast.FuncDecl:
+				r.index(pkg, filename, d.Name, d, nil)
+			case *ast.GenDecl:
+				for _, spec := range d.Specs {
+					switch s := spec.(type) {
+					case *ast.TypeSpec:
+						r.index(pkg, filename, s.Name, s, d)
+					case *ast.ValueSpec:
+						for _, name := range s.Names {
+							r.index(pkg, filename, name, s, d)
+						}
+					}
+				}
+			}
+		}
+	})
+	return r, nil
+}
+
+func (r *contextRetriever) index(pkg *packages.Package, filename string, name *ast.Ident, node ast.Node, decl *ast.GenDecl) {
+	if obj := pkg.TypesInfo.Defs[name]; obj != nil {
+		r.sites[obj] = &declSite{pkg: pkg, file: filename, node: node, decl: decl}
+	}
+}
+
+// excludeFiles 标记已经完整放进 prompt 的文件
+func (r *contextRetriever) excludeFiles(paths ...string) {
+	for _, p := range paths {
+		if p == "" {
+			continue
+		}
+		if abs, err := filepath.Abs(p); err == nil {
+			r.exclude[abs] = true
+		}
+	}
+}
+
+// lookupType 按名称查找项目中的类型声明
+func (r *contextRetriever) lookupType(name string) types.Object {
+	for obj := range r.sites {
+		if _, ok := obj.(*types.TypeName); ok && obj.Name() == name {
+			return obj
+		}
+	}
+	return nil
+}
+
+// lookupMethod 查找 recvType 类型上名为 method 的方法声明
+func (r *contextRetriever) lookupMethod(recvType, method string) types.Object {
+	for obj := range r.sites {
+		fn, ok := obj.(*types.Func)
+		if !ok || fn.Name() != method {
+			continue
+		}
+		if recv := fn.Type().(*types.Signature).Recv(); recv != nil && namedTypeName(recv.Type()) == recvType {
+			return obj
+		}
+	}
+	return nil
+}
+
+// lookupPick 解析 --context 中的名称: 可以是 包名.标识符 或者只有标识符
+func (r *contextRetriever) lookupPick(name string) types.Object {
+	pkgName, ident, qualified := strings.Cut(name, ".")
+	if !qualified {
+		ident = pkgName
+	}
+	var found types.Object
+	for obj := range r.sites {
+		if obj.Name() != ident || (qualified && obj.Pkg().Name() != pkgName) {
+			continue
+		}
+		if fn, ok := obj.(*types.Func); ok && fn.Type().(*types.Signature).Recv() != nil {
+			continue
+		}
+		found = obj
+		break
+	}
+	return found
+}
+
+func namedTypeName(t types.Type) string {
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	if n, ok := t.(*types.Named); ok {
+		return n.Obj().Name()
+	}
+	return ""
+}
+
+// normalize 把接口方法映射到接口类型本身: 在 prompt 中接口的完整定义比单个方法更有用
+func (r *contextRetriever) normalize(obj types.Object) types.Object {
+	fn, ok := obj.(*types.Func)
+	if !ok {
+		return obj
+	}
+	recv := fn.Type().(*types.Signature).Recv()
+	if recv == nil {
+		return obj
+	}
+	if _, isInterface := recv.Type().Underlying().(*types.Interface); isInterface {
+		if named, ok := recv.Type().(*types.Named); ok {
+			return named.Obj()
+		}
+	}
+	return obj
+}
+
+// retrieve 从种子声明出发沿引用关系遍历, 按得分返回前 k 个相关声明; picks 总是排在最前面
+func (r *contextRetriever) retrieve(seeds []types.Object, picks []string, k int) []RelatedDecl {
+	seen := make(map[types.Object]bool)
+	isSeed := make(map[types.Object]bool)
+	for _, s := range seeds {
+		seen[s], isSeed[s] = true, true
+	}
+	scores := make(map[types.Object]float64)
+	frontier := seeds
+	weight := 1.0
+	for depth := 0; depth < retrieveDepth && len(frontier) > 0; depth++ {
+		var next []types.Object
+		for _, from := range frontier {
+			site := r.sites[from]
+			if site == nil {
+				continue
+			}
+			ast.Inspect(site.node, func(n ast.Node) bool {
+				ident, ok := n.(*ast.Ident)
+				if !ok {
+					return true
+				}
+				obj := site.pkg.TypesInfo.Uses[ident]
+				if obj == nil {
+					return true
+				}
+				obj = r.normalize(obj)
+				if r.sites[obj] == nil || isSeed[obj] {
+					return true
+				}
+				scores[obj] += weight
+				if !seen[obj] {
+					seen[obj] = true
+					next = append(next, obj)
+				}
+				return true
+			})
+		}
+		frontier = next
+		weight /= 2
+	}
+
+	var related []RelatedDecl
+	added := make(map[types.Object]bool)
+	for _, name := range picks {
+		obj := r.lookupPick(name)
+		if obj == nil {
+			fmt.Printf("   ⚠️ 未找到 --context 指定的声明: %s\n", name)
+			continue
+		}
+		if !added[obj] {
+			added[obj] = true
+			d := r.describe(obj, 0)
+			d.Picked = true
+			related = append(related, d)
+		}
+	}
+
+	var ranked []RelatedDecl
+	for obj, score := range scores {
+		if added[obj] || r.exclude[r.sites[obj].file] {
+			continue
+		}
+		d := r.describe(obj, score)
+		d.Score *= kindBoost(d.Kind)
+		ranked = append(ranked, d)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].Name < ranked[j].Name
+	})
+	if len(ranked) > k {
+		ranked = ranked[:k]
+	}
+	return append(related, ranked...)
+}
+
+// kindBoost 让 DTO、接口和错误变量排在普通辅助函数之前, 它们最常被生成的代码直接使用
+func kindBoost(kind string) float64 {
+	switch kind {
+	case "DTO", "仓库接口", "服务接口", "错误变量":
+		return 1.5
+	case "实体":
+		return 1.2
+	default:
+		return 1.0
+	}
+}
+
+// describe 提取声明的源码并判断类别; 函数和方法只保留签名
+func (r *contextRetriever) describe(obj types.Object, score float64) RelatedDecl {
+	site := r.sites[obj]
+	path := site.file
+	if rel, err := filepath.Rel(r.cwd, path); err == nil {
+		path = filepath.ToSlash(rel)
+	}
+	d := RelatedDecl{Name: obj.Pkg().Name() + "." + obj.Name(), Path: path, Score: score, Kind: r.kindOf(obj, path)}
+	if fn, ok := obj.(*types.Func); ok {
+		if recv := fn.Type().(*types.Signature).Recv(); recv != nil {
+			d.Name = obj.Pkg().Name() + "." + namedTypeName(recv.Type()) + "." + obj.Name()
+		}
+	}
+
+	src, err := os.ReadFile(site.file)
+	if err != nil {
+		return d
+	}
+	fset := site.pkg.Fset
+	offset := func(p token.Pos) int { return fset.Position(p).Offset }
+	switch node := site.node.(type) {
+	case *ast.FuncDecl:
+		start := node.Pos()
+		if node.Doc != nil {
+			start = node.Doc.Pos()
+		}
+		end := node.End()
+		if node.Body != nil {
+			end = node.Body.Lbrace
+		}
+		d.Code = strings.TrimSpace(string(src[offset(start):offset(end)]))
+	default:
+		// 单独声明的类型或变量连同 type/var 关键字和文档注释一起输出, 分组声明只输出其中的一项
+		if len(site.decl.Specs) == 1 {
+			start := site.decl.Pos()
+			if site.decl.Doc != nil {
+				start = site.decl.Doc.Pos()
+			}
+			d.Code = string(src[offset(start):offset(site.decl.End())])
+		} else {
+			d.Code = site.decl.Tok.String() + " " + string(src[offset(node.Pos()):offset(node.End())])
+		}
+	}
+	return d
+}
+
+func (r *contextRetriever) kindOf(obj types.Object, path string) string {
+	dir := filepath.ToSlash(filepath.Dir(path))
+	switch obj := obj.(type) {
+	case *types.TypeName:
+		_, isInterface := obj.Type().Underlying().(*types.Interface)
+		switch {
+		case strings.HasSuffix(dir, "/dto"):
+			return "DTO"
+		case isInterface && strings.HasSuffix(obj.Name(), "Repository"):
+			return "仓库接口"
+		case isInterface && strings.HasSuffix(obj.Name(), "Service"):
+			return "服务接口"
+		case strings.HasSuffix(dir, "/entity"):
+			return "实体"
+		default:
+			return "类型"
+		}
+	case *types.Var:
+		if types.Identical(obj.Type(), types.Universe.Lookup("error").Type()) {
+			return "错误变量"
+		}
+		return "变量"
+	case *types.Const:
+		return "常量"
+	case *types.Func:
+		if obj.Type().(*types.Signature).Recv() != nil {
+			return "方法"
+		}
+		return "函数"
+	}
+	return "声明"
+}
+
+// retrieveRelatedContext 检索与实体、seedTypes 和 seedMethods 相关的声明,
+// excludeFiles 是已经完整放进 prompt 的文件。检索失败时只打印警告, 不影响生成。
+func retrieveRelatedContext(entityName string, seedTypes []string, seedMethods []methodRef, excludeFiles []string) []RelatedDecl {
+	if contextTopK <= 0 && len(contextPicks) == 0 {
+		return nil
+	}
+	fmt.Println("   - 正在检索相关的类型和声明...")
+	r, err := newContextRetriever()
+	if err != nil {
+		fmt.Printf("   ⚠️ 自动检索上下文失败, 将只使用手动选择的上下文: %v\n", err)
+		return nil
+	}
+	r.excludeFiles(excludeFiles...)
+
+	var seeds []types.Object
+	for _, name := range append([]string{entityName}, seedTypes...) {
+		if obj := r.lookupType(name); obj != nil {
+			seeds = append(seeds, obj)
+		}
+	}
+	for _, m := range seedMethods {
+		if obj := r.lookupMethod(m.Recv, m.Name); obj != nil {
+			seeds = append(seeds, obj)
+		}
+	}
+	related := r.retrieve(seeds, contextPicks, contextTopK)
+	for _, d := range related {
+		mark := ""
+		if d.Picked {
+			mark = " (手动指定)"
+		}
+		fmt.Printf("     - %s [%s]%s\n", d.Name, d.Kind, mark)
+	}
+	return related
+}
@@ -0,0 +1,104 @@
+package command
+
+import (
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestContextRetriever(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":                             "module example.com/app\n\ngo 1.21\n",
+		"internal/domain/entity/song.go":     "package entity\n\ntype Song struct {\n\tID    int\n\tAlbum *Album\n}\n",
+		"internal/domain/entity/album.go":    "package entity\n\n// Album 是专辑\ntype Album struct {\n\tID int\n}\n",
+		"internal/domain/entity/artist.go":   "package entity\n\ntype Artist struct {\n\tID int\n}\n",
+		"internal/application/dto/song.go":   "package dto\n\ntype SongResponse struct {\n\tID int\n}\n\ntype UnusedRequest struct{}\n",
+		"internal/domain/repository/repo.go": "package repository\n\nimport (\n\t\"errors\"\n\n\t\"example.com/app/internal/domain/entity\"\n)\n\nvar (\n\tErrNotFound = errors.New(\"not found\")\n\tErrConflict = errors.New(\"conflict\")\n)\n\ntype SongRepository interface {\n\tFindByID(id int) (*entity.Song, error)\n}\n",
+		"internal/application/service/song.go": `package service
+
+import (
+	"example.com/app/internal/application/dto"
+	"example.com/app/internal/domain/repository"
+)
+
+type songServiceImpl struct {
+	repo repository.SongRepository
+}
+
+// helper 只用于测试函数签名的提取
+func helper(id int) int {
+	return id * 2
+}
+
+func (s *songServiceImpl) Get(id int) (*dto.SongResponse, error) {
+	song, err := s.repo.FindByID(helper(id))
+	if err != nil {
+		return nil, repository.ErrNotFound
+	}
+	return &dto.SongResponse{ID: song.ID}, nil
+}
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(dir)
+
+	r, err := newContextRetriever()
+	if err != nil {
+		t.Fatalf("newContextRetriever() error = %v", err)
+	}
+	r.excludeFiles("internal/domain/entity/song.go")
+	seeds := []types.Object{r.lookupType("Song"), r.lookupMethod("songServiceImpl", "Get")}
+	for i, s := range seeds {
+		if s == nil {
+			t.Fatalf("seed %d not found", i)
+		}
+	}
+	related := r.retrieve(seeds, []string{"entity.Artist", "dto.Missing"}, 4)
+
+	got := make(map[string]RelatedDecl)
+	var names []string
+	for _, d := range related {
+		got[d.Name] = d
+		names = append(names, d.Name)
+	}
+	if len(related) == 0 || related[0].Name != "entity.Artist" || !related[0].Picked {
+		t.Fatalf("retrieve() = %v, want manual pick entity.Artist first", names)
+	}
+	for name, kind := range map[string]string{
+		"dto.SongResponse":          "DTO",
+		"repository.SongRepository": "仓库接口",
+		"repository.ErrNotFound":    "错误变量",
+		"entity.Album":              "实体",
+	} {
+		d, ok := got[name]
+		if !ok {
+			t.Errorf("retrieve() = %v, missing %s", names, name)
+			continue
+		}
+		if d.Kind != kind {
+			t.Errorf("%s kind = %q, want %q", name, d.Kind, kind)
+		}
+	}
+	for _, name := range []string{"entity.Song", "dto.UnusedRequest", "repository.ErrConflict", "service.helper"} {
+		if _, ok := got[name]; ok {
+			t.Errorf("retrieve() = %v, should not include %s", names, name)
+		}
+	}
+	if code := got["entity.Album"].Code; !strings.HasPrefix(code, "// Album 是专辑\ntype Album struct") {
+		t.Errorf("entity.Album code = %q, want doc comment and type declaration", code)
+	}
+	if code := got["repository.ErrNotFound"].Code; code != `var ErrNotFound = errors.New("not found")` {
+		t.Errorf("repository.ErrNotFound code = %q", code)
+	}
+}
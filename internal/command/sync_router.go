@@ -85,15 +85,24 @@ func parseRoutes(path string) (map[string]RouteInfo, error) {
 	}
 
 	routes := make(map[string]RouteInfo)
-	var currentGroupPrefix string
+	// 记录每个路由分组变量对应的完整前缀, 如 apiV1 -> /api/v1, songRoutes -> /api/v1/songs
+	groupPrefixes := make(map[string]string)
+	prefixOf := func(x ast.Expr) string {
+		if ident, ok := x.(*ast.Ident); ok {
+			return groupPrefixes[ident.Name]
+		}
+		return ""
+	}
 
 	ast.Inspect(node, func(n ast.Node) bool {
-		if as, ok := n.(*ast.AssignStmt); ok {
+		if as, ok := n.(*ast.AssignStmt); ok && len(as.Lhs) == 1 && len(as.Rhs) == 1 {
 			if call, ok := as.Rhs[0].(*ast.CallExpr); ok {
 				if sel, ok := call.Fun.(*ast.SelectorExpr); ok && sel.Sel.Name == "Group" {
 					if len(call.Args) > 0 {
 						if pathLit, ok := call.Args[0].(*ast.BasicLit); ok {
-							currentGroupPrefix = "/api/v1" + strings.Trim(pathLit.Value, `"`)
+							if ident, ok := as.Lhs[0].(*ast.Ident); ok {
+								groupPrefixes[ident.Name] = prefixOf(sel.X) + strings.Trim(pathLit.Value, `"`)
+							}
 						}
 					}
 				}
@@ -115,7 +124,7 @@ func parseRoutes(path string) (map[string]RouteInfo, error) {
 				handlerSel, okHandler := call.Args[1].(*ast.SelectorExpr)
 
 				if okPath && okHandler {
-					routePath := currentGroupPrefix + strings.Trim(pathLit.Value, `"`)
+					routePath := prefixOf(sel.X) + strings.Trim(pathLit.Value, `"`)
 					routePath = strings.Replace(routePath, "//", "/", -1)
 
 					innerSel, okInner := handlerSel.X.(*ast.SelectorExpr)
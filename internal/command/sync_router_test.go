@@ -0,0 +1,47 @@
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseRoutesGroupPrefixes(t *testing.T) {
+	src := `package router
+
+func (r *Router) SetupRoutes() {
+	apiV1 := r.App.Group("/api/v1")
+
+	songRoutes := apiV1.Group("/songs")
+	songRoutes.Get("/:id", r.SongHandler.GetByID)
+
+	r.App.Post("/graphql", r.GraphQLHandler.Serve)
+
+	adminRoutes := apiV1.Group("/admin")
+	userRoutes := adminRoutes.Group("/users")
+	userRoutes.Delete("/:id", r.UserHandler.Delete)
+}
+`
+	path := filepath.Join(t.TempDir(), "router.go")
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	routes, err := parseRoutes(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"SongHandler.GetByID":  "GET /api/v1/songs/:id",
+		"GraphQLHandler.Serve": "POST /graphql",
+		"UserHandler.Delete":   "DELETE /api/v1/admin/users/:id",
+	}
+	if len(routes) != len(want) {
+		t.Fatalf("got %d routes, want %d: %v", len(routes), len(want), routes)
+	}
+	for key, w := range want {
+		if got := routes[key].HTTPMethod + " " + routes[key].Path; got != w {
+			t.Errorf("%s = %q, want %q", key, got, w)
+		}
+	}
+}
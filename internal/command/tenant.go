@@ -0,0 +1,90 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// tenantField 是开启多租户的字段名, 实体包含该字段时 generate 自动生成按租户隔离的代码
+	tenantField = "TenantID"
+
+	tenantMiddlewareDir = "internal/infrastructure/middleware/tenant"
+)
+
+// idFieldTypes 是租户字段以及 CreatedBy/UpdatedBy 字段支持的类型, 与 pkg/tenant.ID 和 pkg/audit.ID 的约束一致
+var idFieldTypes = map[string]bool{
+	"string": true,
+	"int":    true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true,
+}
+
+// tenantMiddlewareRoutes 把租户中间件挂载到实体的路由前缀上, 覆盖 CRUD 以及批量、导出等附加路由
+var tenantMiddlewareRoutes = []crudRoute{{Name: "tenant", Method: "Use", Handler: "Handle"}}
+
+// checkTenantSupported 检查实体能否按租户隔离: 租户从请求上下文传入仓库, 目前只有 DDD 项目的仓库接收 context。
+// 标准项目的仓库方法没有 context 参数, 生成的代码无法按租户限定查询, 因此返回错误由调用方跳过该实体,
+// 而不是生成一套暴露所有租户数据的接口
+func checkTenantSupported(info *EntityInfo, paths PathConfig) error {
+	if !paths.IsDDD {
+		return fmt.Errorf("多租户目前只支持 DDD 项目 (标准项目的仓库不接收 context), 为避免生成不按租户隔离的接口, 已跳过 %s; 请去掉 %s 字段或改用 DDD 项目", info.EntityName, tenantField)
+	}
+	if !idFieldTypes[info.Tenant.Type] {
+		return fmt.Errorf("%s.%s 的类型 %s 不受支持 (可用 string 或整数类型), 已跳过 %s", info.EntityName, tenantField, info.Tenant.Type, info.EntityName)
+	}
+	if info.Skips("repository") {
+		return fmt.Errorf("多租户依赖生成的 Repository, //gps:skip=repository 时无法按租户隔离, 已跳过 %s", info.EntityName)
+	}
+	return nil
+}
+
+// generateTenantMiddleware 生成租户中间件并挂载到实体的路由前缀上, 同时为旧项目补充 tenant 配置。
+// 中间件必须先于实体的其他路由注册, 因此在附加路由之前调用
+func generateTenantMiddleware(info *EntityInfo, paths PathConfig) error {
+	if _, err := os.Stat(filepath.Join(tenantMiddlewareDir, "tenant.go")); os.IsNotExist(err) || forceGenerate {
+		renderTask(FileGenerationTask{TemplatePath: "tmpl/generate/tenant/middleware.go.tmpl", OutputDir: tenantMiddlewareDir, FileName: "tenant", IsSingular: true}, info.EntityName, info)
+	}
+
+	importPath := info.ProjectModule + "/" + tenantMiddlewareDir
+	if err := addExtraProvidersToDI(paths, importPath, diProvider{Comment: "Tenant Middleware", Expr: "tenant.NewMiddleware"}); err != nil {
+		return fmt.Errorf("自动修改 %s 失败: %w", paths.DIFile, err)
+	}
+	if err := injectRouterDependency(paths.RouterFile, "TenantMiddleware", "*tenant.Middleware", importPath); err != nil {
+		return fmt.Errorf("自动修改 %s 失败: %w", paths.RouterFile, err)
+	}
+	if err := addRoutesBeforeEntityRoutes(info, paths, "tenant", "TenantMiddleware", tenantMiddlewareRoutes); err != nil {
+		return fmt.Errorf("自动添加租户中间件到 %s 失败: %w", paths.RouterFile, err)
+	}
+	return ensureTenantConfig()
+}
+
+// generateTenantInterceptor 生成从 gRPC metadata 读取租户的一元拦截器, 并通过 DI 分组串联到 gRPC Server 上
+func generateTenantInterceptor(info *EntityInfo, paths PathConfig) error {
+	if _, err := os.Stat(filepath.Join(tenantMiddlewareDir, "interceptor.go")); os.IsNotExist(err) || forceGenerate {
+		renderTask(FileGenerationTask{TemplatePath: "tmpl/generate/tenant/interceptor.go.tmpl", OutputDir: tenantMiddlewareDir, FileName: "interceptor", IsSingular: true}, info.EntityName, info)
+	}
+
+	importPath := info.ProjectModule + "/" + tenantMiddlewareDir
+	if err := addExtraProvidersToDI(paths, importPath, diProvider{Comment: "Tenant gRPC Interceptor", Expr: "tenant.NewUnaryInterceptor"}); err != nil {
+		return fmt.Errorf("自动修改 %s 失败: %w", paths.DIFile, err)
+	}
+	// 旧版本生成的 server.go 不收集拦截器分组, 拦截器不会生效
+	registry := filepath.Join(paths.rpcDir(), "server.go")
+	if content, err := os.ReadFile(registry); err == nil && !strings.Contains(string(content), "grpc_unary_interceptors") {
+		fmt.Printf("   ⚠️ %s 不会加载租户拦截器, 请使用 -F 重新生成, 或在 ServerParams 中加入 group:\"grpc_unary_interceptors\" 的拦截器并传给 grpc.ChainUnaryInterceptor\n", registry)
+	}
+	return ensureTenantConfig()
+}
+
+// ensureTenantConfig 为旧项目补充 tenant 配置
+func ensureTenantConfig() error {
+	return ensureConfigSection(configSection{
+		Field:    "Tenant",
+		Type:     "TenantConfig",
+		Key:      "tenant",
+		TypeDecl: "// TenantConfig holds where the tenant middleware reads the tenant from.\ntype TenantConfig struct {\n\tClaim  string `mapstructure:\"claim\"`\n\tHeader string `mapstructure:\"header\"`\n}\n",
+		YAML:     "tenant:\n  claim: tenant_id\n  header: X-Tenant-ID\n",
+	})
+}
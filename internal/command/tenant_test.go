@@ -0,0 +1,96 @@
+package command
+
+import (
+	"bytes"
+	"go/format"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckTenantSupported(t *testing.T) {
+	tests := []struct {
+		name    string
+		ddd     bool
+		typ     string
+		wantErr string
+	}{
+		{name: "ddd", ddd: true, typ: "uint"},
+		// 标准项目的仓库不接收 context, 包含 TenantID 的实体必须被拒绝, 而不是生成不隔离的接口
+		{name: "standard layout", ddd: false, typ: "uint", wantErr: "只支持 DDD 项目"},
+		{name: "unsupported type", ddd: true, typ: "float64", wantErr: "不受支持"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := &EntityInfo{EntityName: "Song", Tenant: &FieldInfo{Name: tenantField, Type: tt.typ}}
+			err := checkTenantSupported(info, PathConfig{IsDDD: tt.ddd})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("checkTenantSupported() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) || !strings.Contains(err.Error(), "已跳过 Song") {
+				t.Fatalf("checkTenantSupported() = %v, want an error containing %q that skips the entity", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGenerateTenantInterceptor(t *testing.T) {
+	writeFixture(t, t.TempDir(), map[string]string{
+		"internal/di/container.go": `package di
+
+func providers() []interface{} {
+	return []interface{}{
+		// [GENERATOR ANCHOR] - Don't remove this comment!
+	}
+}
+`,
+		"internal/configuration/config.go": "package configuration\n\ntype Config struct {\n\tName string `mapstructure:\"name\"`\n}\n",
+	})
+	info := &EntityInfo{EntityName: "Song", ProjectModule: "example.com/music", Tenant: &FieldInfo{Name: tenantField, Type: "uint"}}
+	paths := PathConfig{IsDDD: true, DIFile: "internal/di/container.go"}
+	for i := 0; i < 2; i++ {
+		if err := generateTenantInterceptor(info, paths); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	interceptor := filepath.Join(tenantMiddlewareDir, "interceptor.go")
+	assertGofmt(t, interceptor)
+	di, err := os.ReadFile(paths.DIFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(di), "tenant.NewUnaryInterceptor,"); n != 1 {
+		t.Errorf("interceptor provider registered %d times:\n%s", n, di)
+	}
+	if !strings.Contains(string(di), `"example.com/music/`+tenantMiddlewareDir+`"`) {
+		t.Errorf("DI container must import the tenant middleware package:\n%s", di)
+	}
+	config, err := os.ReadFile("internal/configuration/config.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(config), "Tenant TenantConfig") {
+		t.Errorf("tenant config section missing:\n%s", config)
+	}
+
+	// gRPC Server 需要收集拦截器分组, 生成的 server.go 本身也必须是 gofmt 格式
+	registry, err := renderEmbedded("tmpl/generate/grpc/registry.go.tmpl", info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	formatted, err := format.Source(registry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(registry, formatted) {
+		t.Errorf("registry template is not gofmt-formatted:\n%s", registry)
+	}
+	if !bytes.Contains(registry, []byte(`group:"grpc_unary_interceptors"`)) || !bytes.Contains(registry, []byte("grpc.ChainUnaryInterceptor(")) {
+		t.Errorf("gRPC server must chain the interceptor group:\n%s", registry)
+	}
+}
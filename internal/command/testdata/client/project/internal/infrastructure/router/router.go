@@ -0,0 +1,29 @@
+package router
+
+import (
+	"example.com/music/internal/interfaces/handler"
+	"example.com/music/pkg/web"
+)
+
+type Router struct {
+	App          web.Router
+	AlbumHandler *handler.AlbumHandler
+	SongHandler  *handler.SongHandler
+}
+
+func (r *Router) Setup() {
+	apiV1 := r.App.Group("/api/v1")
+
+	albumRoutes := apiV1.Group("/albums")
+	albumRoutes.Post("/", r.AlbumHandler.Create)
+	albumRoutes.Get("/", r.AlbumHandler.GetAll)
+	albumRoutes.Get("/:id", r.AlbumHandler.GetByID)
+	albumRoutes.Delete("/:id", r.AlbumHandler.Delete)
+	albumRoutes.Get("/:id/cover", r.AlbumHandler.Cover)
+	albumRoutes.Get("/:albumId/songs/:songId", r.AlbumHandler.GetSong)
+	albumRoutes.Put("/:albumId/tracks/:position", r.AlbumHandler.MoveTrack)
+
+	songRoutes := apiV1.Group("/songs")
+	songRoutes.Get("/", r.SongHandler.GetAll)
+	songRoutes.Get("/:id", r.SongHandler.GetByID)
+}
@@ -0,0 +1,26 @@
+package dto
+
+import "time"
+
+// AlbumStatus 是专辑的发行状态
+type AlbumStatus string
+
+// CreateAlbumRequest 是创建专辑的请求
+type CreateAlbumRequest struct {
+	Title  string      `json:"title"`
+	Status AlbumStatus `json:"status"`
+}
+
+// AlbumResponse 是专辑的响应
+type AlbumResponse struct {
+	ID         uint           `json:"id"`
+	Title      string         `json:"title"`
+	Status     AlbumStatus    `json:"status"`
+	ReleasedAt *time.Time     `json:"releasedAt,omitempty"`
+	Songs      []SongResponse `json:"songs"`
+}
+
+// MoveTrackRequest 把歌曲移动到专辑中的新位置
+type MoveTrackRequest struct {
+	SongID uint `json:"songId"`
+}
@@ -0,0 +1,9 @@
+package dto
+
+// SongResponse 是歌曲的响应
+type SongResponse struct {
+	ID       uint              `json:"id"`
+	Title    string            `json:"title"`
+	Duration *int              `json:"duration,omitempty"`
+	Tags     map[string]string `json:"tags"`
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"example.com/music/internal/interfaces/dto"
+	"example.com/music/pkg/response"
+	"example.com/music/pkg/web"
+)
+
+type AlbumHandler struct{}
+
+// Create godoc
+// @Summary      创建专辑
+// @Tags         Album
+// @Accept       json
+// @Produce      json
+// @Param        album  body      dto.CreateAlbumRequest  true  "专辑"
+// @Success      201    {object}  response.Response{data=dto.AlbumResponse}
+// @Router       /api/v1/albums [post]
+func (h *AlbumHandler) Create(c web.Ctx) error {
+	var req dto.CreateAlbumRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return response.BadRequest(c, err.Error())
+	}
+	return response.Created(c, &dto.AlbumResponse{Title: req.Title, Status: req.Status})
+}
+
+// GetAll godoc
+// @Summary      获取所有专辑
+// @Tags         Album
+// @Produce      json
+// @Success      200  {object}  response.Response{data=[]dto.AlbumResponse}
+// @Router       /api/v1/albums [get]
+func (h *AlbumHandler) GetAll(c web.Ctx) error {
+	return response.Success(c, []dto.AlbumResponse{})
+}
+
+// GetByID godoc
+// @Summary      获取专辑
+// @Tags         Album
+// @Produce      json
+// @Param        id   path      int  true  "Album ID"
+// @Success      200  {object}  response.Response{data=dto.AlbumResponse}
+// @Router       /api/v1/albums/{id} [get]
+func (h *AlbumHandler) GetByID(c web.Ctx) error {
+	return response.Success(c, &dto.AlbumResponse{})
+}
+
+// Delete godoc
+// @Summary      删除专辑
+// @Tags         Album
+// @Param        id   path      int  true  "Album ID"
+// @Success      204
+// @Router       /api/v1/albums/{id} [delete]
+func (h *AlbumHandler) Delete(c web.Ctx) error {
+	return response.NoContent(c)
+}
+
+// Cover godoc
+// @Summary      下载专辑封面
+// @Tags         Album
+// @Produce      image/jpeg
+// @Param        id   path      int  true  "Album ID"
+// @Router       /api/v1/albums/{id}/cover [get]
+func (h *AlbumHandler) Cover(c web.Ctx) error {
+	return c.SendFile("cover.jpg")
+}
+
+// GetSong godoc
+// @Summary      获取专辑中的歌曲
+// @Tags         Album
+// @Produce      json
+// @Param        albumId  path      int     true  "Album ID"
+// @Param        songId   path      string  true  "Song ID"
+// @Success      200      {object}  response.Response{data=dto.SongResponse}
+// @Router       /api/v1/albums/{albumId}/songs/{songId} [get]
+func (h *AlbumHandler) GetSong(c web.Ctx) error {
+	return response.Success(c, &dto.SongResponse{})
+}
+
+// MoveTrack godoc
+// @Summary      调整歌曲在专辑中的位置
+// @Tags         Album
+// @Accept       json
+// @Produce      json
+// @Param        albumId   path      int  true  "Album ID"
+// @Param        position  path      int  true  "新的位置"
+// @Success      200       {object}  response.Response{data=dto.AlbumResponse}
+// @Router       /api/v1/albums/{albumId}/tracks/{position} [put]
+func (h *AlbumHandler) MoveTrack(c web.Ctx) error {
+	var req dto.MoveTrackRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return response.BadRequest(c, err.Error())
+	}
+	return response.Success(c, &dto.AlbumResponse{})
+}
@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"example.com/music/internal/interfaces/dto"
+	"example.com/music/pkg/response"
+	"example.com/music/pkg/web"
+)
+
+type SongHandler struct{}
+
+// GetAll godoc
+// @Summary      获取所有歌曲
+// @Tags         Song
+// @Produce      json
+// @Success      200  {array}  dto.SongResponse
+// @Router       /api/v1/songs [get]
+func (h *SongHandler) GetAll(c web.Ctx) error {
+	return response.SuccessFlat(c, []dto.SongResponse{})
+}
+
+// GetByID godoc
+// @Summary      获取歌曲
+// @Tags         Song
+// @Produce      json
+// @Param        id   path      int  true  "Song ID"
+// @Success      200  {object}  dto.SongResponse
+// @Router       /api/v1/songs/{id} [get]
+func (h *SongHandler) GetByID(c web.Ctx) error {
+	return response.SuccessFlat(c, &dto.SongResponse{})
+}
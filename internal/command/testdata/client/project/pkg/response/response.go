@@ -0,0 +1,19 @@
+// Package response 是示例项目中代替 pkg/response 的桩实现
+package response
+
+import "example.com/music/pkg/web"
+
+// Response 是统一返回结构
+type Response struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+	Data any    `json:"data"`
+}
+
+func Success(c web.Ctx, data any) error     { return nil }
+func Created(c web.Ctx, data any) error     { return nil }
+func SuccessFlat(c web.Ctx, data any) error { return nil }
+func NoContent(c web.Ctx) error             { return nil }
+func BadRequest(c web.Ctx, msg string) error {
+	return nil
+}
@@ -0,0 +1,26 @@
+// Package web 是示例项目中代替 Fiber 的最小接口
+package web
+
+// Binder 解析请求体
+type Binder interface {
+	JSON(out any) error
+}
+
+// Ctx 是请求上下文
+type Ctx interface {
+	Params(key string) string
+	Bind() Binder
+	SendFile(path string) error
+}
+
+// Handler 处理一个请求
+type Handler func(c Ctx) error
+
+// Router 注册路由
+type Router interface {
+	Group(prefix string) Router
+	Get(path string, h Handler)
+	Post(path string, h Handler)
+	Put(path string, h Handler)
+	Delete(path string, h Handler)
+}
@@ -0,0 +1,4 @@
+package service
+
+// SongService 是歌曲的应用服务
+type SongService struct{}
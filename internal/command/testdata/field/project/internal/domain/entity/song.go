@@ -0,0 +1,12 @@
+package entity
+
+import "time"
+
+// Song 是一首歌曲
+type Song struct {
+	ID    uint   `gorm:"primaryKey"`
+	Title string `gorm:"column:title"`
+	// Artist 是演唱者
+	Artist    string `gorm:"column:artist"`
+	CreatedAt time.Time
+}
@@ -0,0 +1,12 @@
+package persistence
+
+// songFilterWhitelist 是列表接口允许过滤的查询参数到列名的映射
+var songFilterWhitelist = map[string]string{
+	"title":  "title",
+	"artist": "artist",
+}
+
+// SongRepository 是歌曲仓库的实现
+type SongRepository struct{}
+
+var _ = songFilterWhitelist
@@ -0,0 +1,24 @@
+package dto
+
+import "time"
+
+// SongResponse 是歌曲的响应 DTO
+type SongResponse struct {
+	ID        uint      `json:"id"`
+	Title     string    `json:"title"`
+	Artist    string    `json:"artist"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// CreateSongRequest 是创建歌曲的请求 DTO
+type CreateSongRequest struct {
+	// TODO: 根据业务添加校验规则
+	Title  string `json:"title" validate:"required"`
+	Artist string `json:"artist"`
+}
+
+// UpdateSongRequest 是更新歌曲的请求 DTO
+type UpdateSongRequest struct {
+	Title  *string `json:"title,omitempty"`
+	Artist *string `json:"artist,omitempty"`
+}
@@ -0,0 +1,37 @@
+package dto
+
+import "example.com/music/internal/domain/entity"
+
+// SongMapper 负责 DTO 和实体之间的转换
+type SongMapper struct{}
+
+// ToEntity 将创建请求的 DTO 转换为实体。
+func (m *SongMapper) ToEntity(req *CreateSongRequest) *entity.Song {
+	return &entity.Song{
+		Title:  req.Title,
+		Artist: req.Artist,
+	}
+}
+
+// UpdateEntityFromDTO 使用更新请求的 DTO 来更新一个已存在的实体。
+func (m *SongMapper) UpdateEntityFromDTO(e *entity.Song, req *UpdateSongRequest) {
+	if req.Title != nil {
+		e.Title = *req.Title
+	}
+	if req.Artist != nil {
+		e.Artist = *req.Artist
+	}
+}
+
+// ToResponse 将单个实体转换为响应 DTO。
+func (m *SongMapper) ToResponse(e *entity.Song) *SongResponse {
+	if e == nil {
+		return nil
+	}
+	return &SongResponse{
+		ID:        e.ID,
+		Title:     e.Title,
+		Artist:    e.Artist,
+		CreatedAt: e.CreatedAt,
+	}
+}
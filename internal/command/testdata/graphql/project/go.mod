@@ -0,0 +1,11 @@
+module example.com/music
+
+go 1.25.0
+
+require (
+	github.com/Skyenought/goprojectstarter/pkg v0.0.0
+	github.com/gofiber/fiber/v3 v3.0.0-rc.1
+	github.com/graphql-go/graphql v0.8.1
+	go.uber.org/dig v1.19.0
+	gorm.io/gorm v1.31.0
+)
@@ -0,0 +1,17 @@
+package service
+
+import (
+	"context"
+
+	"example.com/music/internal/interfaces/dto"
+)
+
+// AlbumService 定义 Album 的业务操作
+type AlbumService interface {
+	Create(ctx context.Context, req *dto.CreateAlbumRequest) (*dto.AlbumResponse, error)
+	GetAll(ctx context.Context) ([]dto.AlbumResponse, error)
+	GetByID(ctx context.Context, id uint) (*dto.AlbumResponse, error)
+	GetByIDs(ctx context.Context, ids []uint) ([]dto.AlbumResponse, error)
+	Update(ctx context.Context, id uint, req *dto.UpdateAlbumRequest) (*dto.AlbumResponse, error)
+	Delete(ctx context.Context, id uint) error
+}
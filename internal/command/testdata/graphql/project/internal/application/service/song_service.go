@@ -0,0 +1,17 @@
+package service
+
+import (
+	"context"
+
+	"example.com/music/internal/interfaces/dto"
+)
+
+// SongService 定义 Song 的业务操作
+type SongService interface {
+	Create(ctx context.Context, req *dto.CreateSongRequest) (*dto.SongResponse, error)
+	GetAll(ctx context.Context) ([]dto.SongResponse, error)
+	GetByID(ctx context.Context, id uint) (*dto.SongResponse, error)
+	GetByIDs(ctx context.Context, ids []uint) ([]dto.SongResponse, error)
+	Update(ctx context.Context, id uint, req *dto.UpdateSongRequest) (*dto.SongResponse, error)
+	Delete(ctx context.Context, id uint) error
+}
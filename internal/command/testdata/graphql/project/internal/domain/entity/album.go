@@ -0,0 +1,8 @@
+package entity
+
+// Album 是一张专辑
+type Album struct {
+	ID    uint   `gorm:"primaryKey"`
+	Title string `gorm:"column:title"`
+	Songs []Song
+}
@@ -0,0 +1,9 @@
+package entity
+
+// Song 是一首歌曲
+type Song struct {
+	ID      uint   `gorm:"primaryKey"`
+	Title   string `gorm:"column:title"`
+	AlbumID uint   `gorm:"column:album_id"`
+	Album   *Album
+}
@@ -0,0 +1,18 @@
+package dto
+
+// CreateAlbumRequest 是创建专辑的请求 DTO
+type CreateAlbumRequest struct {
+	Title string `json:"title" binding:"required"`
+}
+
+// UpdateAlbumRequest 是更新专辑的请求 DTO
+type UpdateAlbumRequest struct {
+	Title *string `json:"title,omitempty"`
+}
+
+// AlbumResponse 是专辑的响应 DTO
+type AlbumResponse struct {
+	ID    uint           `json:"id"`
+	Title string         `json:"title"`
+	Songs []SongResponse `json:"songs"`
+}
@@ -0,0 +1,21 @@
+package dto
+
+// CreateSongRequest 是创建歌曲的请求 DTO
+type CreateSongRequest struct {
+	Title   string `json:"title" binding:"required"`
+	AlbumID uint   `json:"albumId" binding:"required"`
+}
+
+// UpdateSongRequest 是更新歌曲的请求 DTO
+type UpdateSongRequest struct {
+	Title   *string `json:"title,omitempty"`
+	AlbumID *uint   `json:"albumId,omitempty"`
+}
+
+// SongResponse 是歌曲的响应 DTO
+type SongResponse struct {
+	ID      uint           `json:"id"`
+	Title   string         `json:"title"`
+	AlbumID uint           `json:"albumId"`
+	Album   *AlbumResponse `json:"album"`
+}
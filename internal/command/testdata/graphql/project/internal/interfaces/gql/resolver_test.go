@@ -0,0 +1,91 @@
+package gql
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/Skyenought/goprojectstarter/pkg/graphqlx"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/parser"
+
+	"example.com/music/internal/application/service"
+	"example.com/music/internal/interfaces/dto"
+)
+
+// songService 返回固定的歌曲列表, 其中两首属于同一张专辑
+type songService struct {
+	service.SongService
+}
+
+func (songService) GetAll(context.Context) ([]dto.SongResponse, error) {
+	return []dto.SongResponse{
+		{ID: 1, Title: "One", AlbumID: 10},
+		{ID: 2, Title: "Two", AlbumID: 20},
+		{ID: 3, Title: "Three", AlbumID: 10},
+	}, nil
+}
+
+// albumService 记录每次 GetByIDs 调用的主键
+type albumService struct {
+	service.AlbumService
+	batches [][]uint
+}
+
+func (s *albumService) GetByIDs(_ context.Context, ids []uint) ([]dto.AlbumResponse, error) {
+	s.batches = append(s.batches, ids)
+	items := make([]dto.AlbumResponse, len(ids))
+	for i, id := range ids {
+		items[i] = dto.AlbumResponse{ID: id, Title: fmt.Sprintf("Album %d", id)}
+	}
+	return items, nil
+}
+
+// TestSDLParses 解析各实体生成的 SDL。schema.graphql 中没有字段的 type Query 和 type Mutation
+// 符合 GraphQL 规范 (June 2018 起), 但 graphql-go 的解析器还不支持, 因此不在这里检查
+func TestSDLParses(t *testing.T) {
+	files := []string{"../../../api/graphql/album.graphql", "../../../api/graphql/song.graphql"}
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := parser.Parse(parser.ParseParams{Source: string(content)}); err != nil {
+			t.Errorf("%s 无法解析: %v", file, err)
+		}
+	}
+}
+
+func TestBelongsToIsBatched(t *testing.T) {
+	albums := &albumService{}
+	handler, err := NewHandler(HandlerParams{Contributors: []Contributor{
+		NewSongResolver(songService{}).Contributor,
+		NewAlbumResolver(albums).Contributor,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema:        handler.Schema,
+		RequestString: `{ songs { title album { id title } } }`,
+		Context:       graphqlx.WithLoaders(context.Background()),
+	})
+	if result.HasErrors() {
+		t.Fatalf("查询失败: %v", result.Errors)
+	}
+	if want := [][]uint{{10, 20}}; !reflect.DeepEqual(albums.batches, want) {
+		t.Errorf("GetByIDs 调用 = %v, want 一次批量加载 %v", albums.batches, want)
+	}
+	songs := result.Data.(map[string]interface{})["songs"].([]interface{})
+	var titles []string
+	for _, song := range songs {
+		album := song.(map[string]interface{})["album"].(map[string]interface{})
+		titles = append(titles, album["title"].(string))
+	}
+	if want := []string{"Album 10", "Album 20", "Album 10"}; !reflect.DeepEqual(titles, want) {
+		t.Errorf("专辑 = %v, want %v", titles, want)
+	}
+}
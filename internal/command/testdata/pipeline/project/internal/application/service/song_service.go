@@ -0,0 +1,29 @@
+package service
+
+import (
+	"context"
+
+	"example.com/music/internal/domain/repository"
+	"example.com/music/internal/interfaces/dto"
+)
+
+type SongService interface {
+	GetByID(ctx context.Context, id uint) (*dto.SongResponse, error)
+}
+
+type songServiceImpl struct {
+	repo   repository.SongRepository
+	mapper dto.SongMapper
+}
+
+func NewSongService(repo repository.SongRepository) SongService {
+	return &songServiceImpl{repo: repo}
+}
+
+func (s *songServiceImpl) GetByID(ctx context.Context, id uint) (*dto.SongResponse, error) {
+	song, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.mapper.ToResponse(song), nil
+}
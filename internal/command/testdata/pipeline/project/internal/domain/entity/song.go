@@ -0,0 +1,7 @@
+package entity
+
+type Song struct {
+	ID    uint
+	Title string
+	Plays int
+}
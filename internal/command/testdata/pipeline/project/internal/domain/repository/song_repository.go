@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"example.com/music/internal/domain/entity"
+)
+
+var ErrSongNotFound = errors.New("song not found")
+
+type SongRepository interface {
+	FindByID(ctx context.Context, id uint) (*entity.Song, error)
+}
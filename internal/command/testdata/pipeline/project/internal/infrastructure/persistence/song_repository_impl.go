@@ -0,0 +1,24 @@
+package persistence
+
+import (
+	"context"
+
+	"example.com/music/internal/domain/entity"
+	"example.com/music/internal/domain/repository"
+)
+
+type songRepositoryImpl struct {
+	songs map[uint]*entity.Song
+}
+
+func NewSongRepository() repository.SongRepository {
+	return &songRepositoryImpl{songs: make(map[uint]*entity.Song)}
+}
+
+func (r *songRepositoryImpl) FindByID(ctx context.Context, id uint) (*entity.Song, error) {
+	song, ok := r.songs[id]
+	if !ok {
+		return nil, repository.ErrSongNotFound
+	}
+	return song, nil
+}
@@ -0,0 +1,17 @@
+package router
+
+import (
+	"example.com/music/internal/interfaces/handler"
+	"example.com/music/pkg/web"
+)
+
+type Router struct {
+	App         web.Router
+	SongHandler *handler.SongHandler
+}
+
+func (r *Router) Setup() {
+	apiV1 := r.App.Group("/api/v1")
+	songRoutes := apiV1.Group("/songs")
+	songRoutes.Get("/:id", r.SongHandler.GetByID)
+}
@@ -0,0 +1,7 @@
+package dto
+
+type SongResponse struct {
+	ID    uint   `json:"id"`
+	Title string `json:"title"`
+	Plays int    `json:"plays"`
+}
@@ -0,0 +1,9 @@
+package dto
+
+import "example.com/music/internal/domain/entity"
+
+type SongMapper struct{}
+
+func (SongMapper) ToResponse(song *entity.Song) *SongResponse {
+	return &SongResponse{ID: song.ID, Title: song.Title, Plays: song.Plays}
+}
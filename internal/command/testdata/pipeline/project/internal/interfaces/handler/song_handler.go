@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"context"
+	"strconv"
+
+	"example.com/music/internal/application/service"
+	"example.com/music/pkg/web"
+)
+
+type SongHandler struct {
+	service service.SongService
+}
+
+func NewSongHandler(service service.SongService) *SongHandler {
+	return &SongHandler{service: service}
+}
+
+// GetByID 根据 ID 获取歌曲
+// @Summary 获取歌曲
+// @Router /api/v1/songs/{id} [get]
+func (h *SongHandler) GetByID(c web.Ctx) error {
+	id, _ := strconv.ParseUint(c.Params("id"), 10, 64)
+	song, err := h.service.GetByID(context.Background(), uint(id))
+	if err != nil {
+		return c.SendStatus(404)
+	}
+	return c.JSON(song)
+}
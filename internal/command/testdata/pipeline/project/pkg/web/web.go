@@ -0,0 +1,16 @@
+// Package web 是测试项目中代替 Fiber 的最小 HTTP 接口
+package web
+
+type Ctx interface {
+	Params(key string) string
+	JSON(v any) error
+	SendStatus(code int) error
+}
+
+type Handler func(Ctx) error
+
+type Router interface {
+	Get(path string, h Handler)
+	Post(path string, h Handler)
+	Group(prefix string) Router
+}
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"example.com/music/internal/domain/entity"
+	"example.com/music/internal/domain/repository"
+)
+
+// SongService 定义歌曲的业务操作
+type SongService interface {
+	GetByID(id uint) (*entity.Song, error)
+	Promote(id uint) error
+}
+
+type songServiceImpl struct {
+	repo repository.SongRepository
+}
+
+// NewSongService 创建歌曲服务
+func NewSongService(repo repository.SongRepository) SongService {
+	return &songServiceImpl{repo: repo}
+}
+
+func (s *songServiceImpl) GetByID(id uint) (*entity.Song, error) {
+	return s.repo.FindByID(id)
+}
+
+// Promote 推荐一首歌曲
+func (s *songServiceImpl) Promote(id uint) error {
+	return s.repo.Promote(id)
+}
@@ -0,0 +1,17 @@
+package service
+
+import (
+	"testing"
+
+	"example.com/music/internal/domain/repository/mock"
+)
+
+func TestSongService_Promote(t *testing.T) {
+	repo := &mock.SongRepository{Calls: map[string]int{}, PromoteFunc: func(uint) error { return nil }}
+	if err := NewSongService(repo).Promote(1); err != nil {
+		t.Fatalf("Promote() error = %v", err)
+	}
+	if repo.Calls["Promote"] != 1 {
+		t.Errorf("Promote() calls = %d, want 1", repo.Calls["Promote"])
+	}
+}
@@ -0,0 +1,9 @@
+package entity
+
+// Song 是一首歌曲
+type Song struct {
+	ID       uint   `gorm:"primaryKey"`
+	Title    string `gorm:"column:title"`
+	SongCode string `gorm:"column:song_code" json:"songCode"`
+	Promoted bool   `gorm:"column:promoted"`
+}
@@ -0,0 +1,25 @@
+package mock
+
+import (
+	"example.com/music/internal/domain/entity"
+	"example.com/music/internal/domain/repository"
+)
+
+var _ repository.SongRepository = (*SongRepository)(nil)
+
+// SongRepository 是 repository.SongRepository 的 mock
+type SongRepository struct {
+	FindByIDFunc func(id uint) (*entity.Song, error)
+	PromoteFunc  func(id uint) error
+	Calls        map[string]int
+}
+
+func (m *SongRepository) FindByID(id uint) (*entity.Song, error) {
+	m.Calls["FindByID"]++
+	return m.FindByIDFunc(id)
+}
+
+func (m *SongRepository) Promote(id uint) error {
+	m.Calls["Promote"]++
+	return m.PromoteFunc(id)
+}
@@ -0,0 +1,9 @@
+package repository
+
+import "example.com/music/internal/domain/entity"
+
+// SongRepository 定义歌曲的持久化操作
+type SongRepository interface {
+	FindByID(id uint) (*entity.Song, error)
+	Promote(id uint) error
+}
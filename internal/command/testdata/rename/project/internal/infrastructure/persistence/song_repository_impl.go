@@ -0,0 +1,27 @@
+package persistence
+
+import (
+	"example.com/music/internal/domain/entity"
+	"example.com/music/internal/domain/repository"
+	"example.com/music/internal/pkg/web"
+)
+
+type songRepositoryImpl struct {
+	db *web.DB
+}
+
+// NewSongRepository 创建歌曲仓库
+func NewSongRepository(db *web.DB) repository.SongRepository {
+	return &songRepositoryImpl{db: db}
+}
+
+func (r *songRepositoryImpl) FindByID(id uint) (*entity.Song, error) {
+	r.db.Where("song_id = ?", id).Order("song_code desc")
+	return &entity.Song{ID: id}, nil
+}
+
+// Promote 把歌曲标记为推荐
+func (r *songRepositoryImpl) Promote(id uint) error {
+	r.db.Where("song_id = ? AND promoted = ?", id, false)
+	return nil
+}
@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"strconv"
+
+	"example.com/music/internal/application/service"
+	"example.com/music/internal/pkg/web"
+)
+
+// SongHandler 处理歌曲相关的请求
+type SongHandler struct {
+	service service.SongService
+}
+
+// NewSongHandler 创建歌曲处理器
+func NewSongHandler(s service.SongService) *SongHandler {
+	return &SongHandler{service: s}
+}
+
+// GetByID godoc
+// @Summary      获取 Song
+// @Tags         Song
+// @Param        id   path      int  true  "Song ID"
+// @Router       /songs/{id} [get]
+func (h *SongHandler) GetByID(c *web.Ctx) error {
+	id, _ := strconv.Atoi(c.Params["id"])
+	_, err := h.service.GetByID(uint(id))
+	return err
+}
+
+// Promote godoc
+// @Summary      推荐 Song
+// @Tags         Song
+// @Param        id   path      int  true  "Song ID"
+// @Router       /songs/{id}/promote [post]
+func (h *SongHandler) Promote(c *web.Ctx) error {
+	id, _ := strconv.Atoi(c.Params["id"])
+	return h.service.Promote(uint(id))
+}
@@ -0,0 +1,18 @@
+package router
+
+import (
+	"example.com/music/internal/interfaces/handler"
+	"example.com/music/internal/pkg/web"
+)
+
+// Router 持有所有处理器
+type Router struct {
+	SongHandler *handler.SongHandler
+}
+
+// Register 注册全部路由
+func (r *Router) Register(app *web.Router) {
+	songs := app.Group("/api/v1").Group("/songs")
+	songs.Get("/:id", r.SongHandler.GetByID)
+	songs.Post("/:id/promote", r.SongHandler.Promote)
+}
@@ -0,0 +1,48 @@
+// Package web 是示例项目中代替 HTTP 框架的最小路由实现
+package web
+
+// Ctx 是请求上下文
+type Ctx struct {
+	Params map[string]string
+}
+
+// Handler 处理一个请求
+type Handler func(c *Ctx) error
+
+// Router 记录注册的路由
+type Router struct {
+	prefix string
+	Routes map[string]Handler
+}
+
+// Group 返回带有路径前缀的子路由
+func (r *Router) Group(prefix string) *Router {
+	return &Router{prefix: r.prefix + prefix, Routes: r.Routes}
+}
+
+// Get 注册 GET 路由
+func (r *Router) Get(path string, h Handler) {
+	r.Routes["GET "+r.prefix+path] = h
+}
+
+// Post 注册 POST 路由
+func (r *Router) Post(path string, h Handler) {
+	r.Routes["POST "+r.prefix+path] = h
+}
+
+// DB 是记录查询条件的查询构建器
+type DB struct {
+	Conditions []string
+}
+
+// Where 添加查询条件
+func (db *DB) Where(query string, args ...any) *DB {
+	db.Conditions = append(db.Conditions, query)
+	return db
+}
+
+// Order 设置排序
+func (db *DB) Order(value string) *DB {
+	db.Conditions = append(db.Conditions, "ORDER BY "+value)
+	return db
+}
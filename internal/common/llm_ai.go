@@ -2,81 +2,474 @@ package common
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/Skyenought/goprojectstarter/internal/llm"
-	"github.com/Skyenought/goprojectstarter/internal/llm/deepseek"
-	"github.com/Skyenought/goprojectstarter/internal/llm/gemini"
-	"github.com/Skyenought/goprojectstarter/internal/llm/volc"
+	// 各提供商包在 init 中注册到 llm 注册表
+	_ "github.com/Skyenought/goprojectstarter/internal/llm/deepseek"
+	_ "github.com/Skyenought/goprojectstarter/internal/llm/gemini"
+	_ "github.com/Skyenought/goprojectstarter/internal/llm/mock"
+	_ "github.com/Skyenought/goprojectstarter/internal/llm/ollama"
+	_ "github.com/Skyenought/goprojectstarter/internal/llm/openai"
+	_ "github.com/Skyenought/goprojectstarter/internal/llm/volc"
 	"gopkg.in/yaml.v3"
 )
 
+// defaultProfile 是未通过 --llm 指定时使用的 profile
+const defaultProfile = "default"
+
+// defaultLLMTimeout 是 profile 和提供商都没有配置 timeout 时单次请求的超时
+const defaultLLMTimeout = 2 * time.Minute
+
+// defaultCassetteDir 是没有配置 llm.cassette.dir 时保存录音的目录
+const defaultCassetteDir = ".llm-cassettes"
+
+// cassetteModeEnv 覆盖配置中的 llm.cassette.mode, 例如在 CI 中设置为 strict
+const cassetteModeEnv = "GOPROJECTSTARTER_CASSETTE"
+
+// defaultOutputReserve 是没有配置 max_tokens 时, 计算 prompt 预算为模型输出预留的 token 数
+const defaultOutputReserve = 8192
+
 type LLMConfig struct {
-	Default   string `yaml:"default"`
-	Providers map[string]struct {
-		Models []string `yaml:"models"`
-	} `yaml:"providers"`
+	// Default 是旧版的 provider:model 写法, 在 profiles 中没有 default 时作为 default profile
+	Default   string                    `yaml:"default"`
+	Profiles  map[string]ProfileConfig  `yaml:"profiles"`
+	Providers map[string]ProviderConfig `yaml:"providers"`
+	Retry     RetryConfig               `yaml:"retry"`
+	Cassette  CassetteConfig            `yaml:"cassette"`
+	Cache     CacheConfig               `yaml:"cache"`
 }
 
-// GenWithDefaultLLM 是一个高级辅助函数，它负责：
-// 1. 读取 `.goprojectstarter.yaml` 配置文件。
-// 2. 根据配置中的 `default` 字段确定要使用的 LLM 提供商和模型。
-// 3. 从环境变量中获取对应的 API Key。
-// 4. 初始化选择的 LLM 客户端。
-// 5. 发送 prompt 并返回结果。
+// CacheConfig 是 LLM 回复的本地缓存设置, 未设置的项使用 llm.DefaultCacheDir、llm.DefaultCacheTTL 和 llm.DefaultCacheMaxBytes
+type CacheConfig struct {
+	Disabled bool          `yaml:"disabled"`
+	Dir      string        `yaml:"dir"`
+	TTL      time.Duration `yaml:"ttl"`
+	// MaxSizeMB 是缓存目录的大小上限, 超出时删除最久未使用的条目
+	MaxSizeMB int `yaml:"max_size_mb"`
+}
+
+// NoLLMCache 为 true 时跳过 LLM 回复缓存, 既不读取也不写入, 由命令的 --no-cache 设置
+var NoLLMCache bool
+
+// CassetteConfig 启用 LLM 请求的录制和回放, Mode 为空且没有设置环境变量 GOPROJECTSTARTER_CASSETTE 时不启用
+type CassetteConfig struct {
+	// Dir 是保存录音的目录, 默认为 .llm-cassettes
+	Dir string `yaml:"dir"`
+	// Mode 为 record、replay 或 strict, 见 llm.CassetteMode
+	Mode string `yaml:"mode"`
+}
+
+// RetryConfig 是限流、5xx 和超时时在同一个模型上的重试设置, 未设置的项使用 llm.DefaultRetryPolicy
+type RetryConfig struct {
+	MaxRetries     *int          `yaml:"max_retries"`
+	InitialBackoff time.Duration `yaml:"initial_backoff"`
+	MaxBackoff     time.Duration `yaml:"max_backoff"`
+}
+
+// ProviderConfig 是单个 LLM 提供商的配置, 对使用该提供商的所有 profile 生效
+type ProviderConfig struct {
+	Models []string `yaml:"models"`
+	// BaseURL 覆盖默认的 API 地址; openai-compatible 必须配置, ollama 填写服务地址 (如 http://gpu-box:11434)
+	BaseURL string `yaml:"base_url"`
+	// APIKeyEnv 是读取 API Key 的环境变量名, 未配置时使用提供商的默认值 (openai-compatible 和 ollama 默认不发送 API Key)
+	APIKeyEnv string `yaml:"api_key_env"`
+	// Headers 是附加到每个请求的 HTTP 头, 值中的 ${VAR} 会被替换为对应的环境变量
+	Headers     map[string]string `yaml:"headers"`
+	Temperature *float32          `yaml:"temperature"`
+	MaxTokens   int               `yaml:"max_tokens"`
+	Timeout     time.Duration     `yaml:"timeout"`
+	// RequestsPerMinute 大于 0 时启用令牌桶限流, 同一进程中并发的生成共享该提供商的桶; Burst 默认为 1
+	RequestsPerMinute int `yaml:"requests_per_minute"`
+	Burst             int `yaml:"burst"`
+	// ContextWindow 覆盖内置的模型上下文长度 (tokens), 适用于自定义了 num_ctx 的本地模型
+	ContextWindow int `yaml:"context_window"`
+}
+
+// ProfileConfig 是一组命名的 LLM 设置, 命令通过 --llm <profile> 选择。未设置的项沿用提供商的配置
+type ProfileConfig struct {
+	// Model 的格式为 provider:model, 只按第一个冒号拆分: ollama 的模型名带有标签, 例如 ollama:qwen2.5-coder:7b
+	Model       string        `yaml:"model"`
+	BaseURL     string        `yaml:"base_url"`
+	APIKeyEnv   string        `yaml:"api_key_env"`
+	Temperature *float32      `yaml:"temperature"`
+	MaxTokens   int           `yaml:"max_tokens"`
+	Timeout     time.Duration `yaml:"timeout"`
+	// Fallback 是按顺序使用的后备模型 (provider:model), 在 Model 重试用尽或上下文超长时依次尝试。
+	// 后备模型使用各自提供商的 base_url 和 api_key_env, temperature、max_tokens、timeout 与 Model 相同
+	Fallback []string `yaml:"fallback"`
+	// PromptBudget 是 prompt 可以使用的 token 数, 未设置时为回退链中最小的上下文长度减去输出预留的 max_tokens
+	PromptBudget int `yaml:"prompt_budget"`
+}
+
+// GenWithDefaultLLM 使用 default profile 发送 prompt 并返回结果
 func GenWithDefaultLLM(prompt string) (string, error) {
-	// 加载 LLM 配置
+	return GenWithLLM(defaultProfile, prompt)
+}
+
+// ErrLLMCanceled 表示用户在等待 LLM 回复时按下了 Ctrl-C
+var ErrLLMCanceled = errors.New("已按 Ctrl-C 取消 LLM 请求")
+
+// GenWithLLM 是一个高级辅助函数，它负责：
+// 1. 读取 `.goprojectstarter.yaml` 配置文件。
+// 2. 根据 profile (为空时为 default) 确定要使用的模型回退链、超时和重试策略。
+// 3. 通过 llm 注册表为链中的每个模型创建客户端, API Key 按提供商声明的来源读取。
+// 4. 以流式方式发送 prompt 并在终端显示进度, 返回拼接的完整回复。每次请求的超时由回退链单独计时,
+// 收到内容之前失败的流式请求由回退链重试或切换到后备模型, 收到内容之后的错误直接返回。
+func GenWithLLM(profile, prompt string) (string, error) {
+	return genWithLLM(profile, func(ctx context.Context, client llm.Assistant, progress *streamProgress) (string, error) {
+		if progress == nil {
+			return client.Send(ctx, prompt)
+		}
+		return client.SendStream(ctx, prompt).Collect(progress.add)
+	})
+}
+
+// GenStructuredWithLLM 与 GenWithLLM 相同, 但要求模型返回符合 schema 的 JSON 对象, 返回经过校验的 JSON 文本。
+// 修复之后仍不合法时返回 *llm.StructuredError, 其中包含最后一次的原始回复。
+// 流式和阻塞请求都使用提供商原生的 JSON 模式, 流式回复在接收完成后校验
+func GenStructuredWithLLM(profile, prompt string, schema *llm.Schema) (string, error) {
+	return genWithLLM(profile, func(ctx context.Context, client llm.Assistant, progress *streamProgress) (string, error) {
+		if progress == nil {
+			return client.SendStructured(ctx, prompt, schema)
+		}
+		return llm.SendStructuredStream(ctx, client, prompt, schema, progress.add)
+	})
+}
+
+// genWithLLM 为 profile 创建客户端并执行 send, 未设置 NoLLMStream 时传入流式进度。
+// 请求期间按下 Ctrl-C 会取消 context 并返回 ErrLLMCanceled
+func genWithLLM(profile string, send func(ctx context.Context, client llm.Assistant, progress *streamProgress) (string, error)) (string, error) {
 	config, err := loadLLMConfig()
 	if err != nil {
 		return "", fmt.Errorf("无法加载 LLM 配置: %w", err)
 	}
+	client, err := config.newProfileClient(profile)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	var progress *streamProgress
+	if !NoLLMStream {
+		_, cfg, _ := config.Resolve(profile)
+		progress = startStreamProgress(cfg.Model)
+	}
+	reply, err := send(ctx, client, progress)
+	if ctx.Err() != nil {
+		err = fmt.Errorf("%w: %w", ErrLLMCanceled, context.Canceled)
+	}
+	if progress != nil {
+		progress.finish(err)
+	}
+	if err != nil {
+		return "", err
+	}
+	return reply, nil
+}
+
+// newProfileClient 为 profile 创建回退链, 启用了 cassette 时在回退链外包装一层录制和回放。
+// strict 模式只回放录音, 不创建回退链, 因此不需要 API Key
+func (c *LLMConfig) newProfileClient(profile string) (llm.Assistant, error) {
+	cassette, err := c.Cassette.resolve()
+	if err != nil {
+		return nil, err
+	}
+	if cassette == nil {
+		return c.newCachedChain(profile)
+	}
+	fmt.Printf("   - LLM cassette: %s 模式, 目录 %s\n", cassette.Mode, cassette.Dir)
+	if cassette.Mode != llm.CassetteStrict {
+		if cassette.Inner, err = c.newChain(profile, nil); err != nil {
+			return nil, err
+		}
+	}
+	return cassette, nil
+}
+
+// newCachedChain 创建回退链, 未禁用缓存时为链中的每个模型包装一层回复缓存,
+// 这样缓存键使用实际回答的模型, 后备模型的回复不会被当作主模型的结果复用。
+// 启用 cassette 时不使用缓存: record 模式要求每次都真正请求模型
+func (c *LLMConfig) newCachedChain(profile string) (llm.Assistant, error) {
+	if NoLLMCache || c.Cache.Disabled {
+		return c.newChain(profile, nil)
+	}
+	cache, err := c.Cache.open()
+	if err != nil {
+		fmt.Printf("   ⚠️ 无法打开 LLM 缓存, 本次不使用缓存: %v\n", err)
+		return c.newChain(profile, nil)
+	}
+	return c.newChain(profile, func(m ResolvedModel, client llm.Assistant) llm.Assistant {
+		return &llm.CachedAssistant{
+			Cache:       cache,
+			Inner:       client,
+			Provider:    m.Provider,
+			Model:       m.Config.Model,
+			Temperature: m.Config.Temperature,
+			OnHit: func(age time.Duration) {
+				logLLM("   - 命中 LLM 缓存 %s:%s (%s 前写入, 使用 --no-cache 重新请求)\n", m.Provider, m.Config.Model, age.Round(time.Second))
+			},
+			OnStoreError: func(err error) {
+				logLLM("   ⚠️ %v\n", err)
+			},
+		}
+	})
+}
+
+// open 返回配置的缓存目录
+func (c CacheConfig) open() (*llm.ResponseCache, error) {
+	dir := c.Dir
+	if dir == "" {
+		var err error
+		if dir, err = llm.DefaultCacheDir(); err != nil {
+			return nil, err
+		}
+	}
+	maxBytes := int64(llm.DefaultCacheMaxBytes)
+	if c.MaxSizeMB > 0 {
+		maxBytes = int64(c.MaxSizeMB) << 20
+	}
+	return &llm.ResponseCache{Dir: dir, TTL: firstNonZero(c.TTL, llm.DefaultCacheTTL), MaxBytes: maxBytes}, nil
+}
+
+// OpenLLMCache 返回当前项目配置的 LLM 回复缓存, 没有 .goprojectstarter.yaml 时使用默认设置
+func OpenLLMCache() (*llm.ResponseCache, error) {
+	config, err := loadLLMConfig()
+	if os.IsNotExist(err) {
+		config, err = &LLMConfig{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("无法加载 LLM 配置: %w", err)
+	}
+	return config.Cache.open()
+}
+
+// resolve 返回配置的 cassette (Inner 尚未设置), 未启用时返回 nil
+func (c CassetteConfig) resolve() (*llm.Cassette, error) {
+	mode := firstNonZero(os.Getenv(cassetteModeEnv), c.Mode)
+	if mode == "" {
+		return nil, nil
+	}
+	m, err := llm.ParseCassetteMode(mode)
+	if err != nil {
+		return nil, err
+	}
+	return &llm.Cassette{Dir: firstNonZero(c.Dir, defaultCassetteDir), Mode: m}, nil
+}
+
+// newChain 为 profile 创建回退链, wrap 不为 nil 时用它包装每个模型的客户端。
+// 后备模型创建失败 (例如没有设置 API Key) 时只打印警告并跳过, 主模型创建失败时返回错误
+func (c *LLMConfig) newChain(profile string, wrap func(ResolvedModel, llm.Assistant) llm.Assistant) (*llm.Chain, error) {
+	models, err := c.ResolveChain(profile)
+	if err != nil {
+		return nil, err
+	}
+	chain := &llm.Chain{Policy: c.Retry.policy(), OnAttempt: logLLMAttempt}
+	for i, m := range models {
+		client, err := llm.New(m.Provider, m.Config)
+		if err != nil {
+			if i == 0 {
+				return nil, fmt.Errorf("为 %s 创建 LLM 客户端失败: %w", m.Provider, err)
+			}
+			fmt.Printf("   ⚠️ 跳过后备模型 %s:%s: %v\n", m.Provider, m.Config.Model, err)
+			continue
+		}
+		if wrap != nil {
+			client = wrap(m, client)
+		}
+		pc := c.Providers[m.Provider]
+		chain.Links = append(chain.Links, llm.Link{
+			Provider: m.Provider,
+			Model:    m.Config.Model,
+			Client:   client,
+			Timeout:  m.Config.Timeout,
+			Limiter:  llm.LimiterFor(m.Provider, pc.RequestsPerMinute, pc.Burst),
+		})
+	}
+	fmt.Printf("   - 使用 LLM: %s (profile: %s)\n", chainDescription(chain), profileOrDefault(profile))
+	return chain, nil
+}
 
-	// 解析默认的提供商和模型
-	parts := strings.Split(config.Default, ":")
-	if len(parts) != 2 {
-		return "", fmt.Errorf("配置文件中 'default' LLM 格式无效 (应为 'provider:model'): %s", config.Default)
+func chainDescription(chain *llm.Chain) string {
+	names := make([]string, len(chain.Links))
+	for i, link := range chain.Links {
+		names[i] = link.Provider + ":" + link.Model
 	}
-	provider, model := parts[0], parts[1]
+	return strings.Join(names, " -> ")
+}
 
-	var client llm.Assistant // 使用顶层 Assistant 接口
-	var apiKey string
+// logLLMAttempt 打印每一次请求的提供商、模型、耗时和结果, 显示进度时打印在进度上方
+func logLLMAttempt(a llm.Attempt) {
+	outcome := "成功"
+	if a.Err != nil {
+		outcome = fmt.Sprintf("失败 [%s]: %v", a.Class, a.Err)
+	}
+	retry := ""
+	if a.Retry > 0 {
+		retry = fmt.Sprintf(" 第 %d 次重试", a.Retry)
+	}
+	logLLM("     · %s:%s%s, 耗时 %s, %s\n", a.Provider, a.Model, retry, a.Latency.Round(time.Millisecond), outcome)
+}
 
-	fmt.Printf("   - 使用默认 LLM: %s (%s)\n", provider, model)
+func (r RetryConfig) policy() llm.RetryPolicy {
+	p := llm.DefaultRetryPolicy
+	if r.MaxRetries != nil {
+		p.MaxRetries = *r.MaxRetries
+	}
+	if r.InitialBackoff > 0 {
+		p.InitialBackoff = r.InitialBackoff
+	}
+	if r.MaxBackoff > 0 {
+		p.MaxBackoff = r.MaxBackoff
+	}
+	return p
+}
+
+// PromptBudget 是 profile 下 prompt 可以使用的 token 数
+type PromptBudget struct {
+	// Model 是用于估算 token 数的模型, 即回退链中上下文最小的那个
+	Model  string
+	Tokens int
+}
+
+// ResolvePromptBudget 加载配置并计算 profile 的 prompt 预算
+func ResolvePromptBudget(profile string) (PromptBudget, error) {
+	config, err := loadLLMConfig()
+	if err != nil {
+		return PromptBudget{}, fmt.Errorf("无法加载 LLM 配置: %w", err)
+	}
+	return config.PromptBudget(profile)
+}
 
-	// 根据提供商选择并初始化客户端
-	switch provider {
-	case "gemini":
-		// Gemini 客户端通过环境变量自动读取 API key
-		client, err = gemini.NewClient(gemini.WithModel(model))
-	case "deepseek":
-		apiKey = os.Getenv("DEEPSEEK_API_KEY")
-		if apiKey == "" {
-			return "", fmt.Errorf("环境变量 DEEPSEEK_API_KEY 未设置")
+// PromptBudget 返回 profile 的 prompt 预算。配置了 prompt_budget 时直接使用, 否则取回退链中
+// 每个模型的上下文长度减去输出预留 (max_tokens) 后的最小值, 保证切换到后备模型时 prompt 仍然放得下
+func (c *LLMConfig) PromptBudget(profile string) (PromptBudget, error) {
+	models, err := c.ResolveChain(profile)
+	if err != nil {
+		return PromptBudget{}, err
+	}
+	budget := PromptBudget{Model: models[0].Config.Model}
+	for _, m := range models {
+		window := firstNonZero(c.Providers[m.Provider].ContextWindow, llm.ContextWindow(m.Config.Model))
+		// 输出预留不超过上下文的一半, 避免小模型上配置了很大的 max_tokens 时预算变为负数
+		tokens := max(window-firstNonZero(m.Config.MaxTokens, defaultOutputReserve), window/2)
+		if budget.Tokens == 0 || tokens < budget.Tokens {
+			budget.Model, budget.Tokens = m.Config.Model, tokens
 		}
-		client, err = deepseek.NewClient(apiKey, deepseek.WithModel(model))
-	case "volc":
-		apiKey = os.Getenv("ARK_API_KEY")
-		if apiKey == "" {
-			return "", fmt.Errorf("环境变量 ARK_API_KEY 未设置")
+	}
+	if explicit := c.Profiles[profileOrDefault(profile)].PromptBudget; explicit > 0 {
+		budget.Tokens = explicit
+	}
+	return budget, nil
+}
+
+// ResolvedModel 是回退链中的一个模型及其客户端配置
+type ResolvedModel struct {
+	Provider string
+	Config   llm.Config
+}
+
+// ResolveChain 返回 profile 的主模型及其后备模型, 按使用顺序排列
+func (c *LLMConfig) ResolveChain(profile string) ([]ResolvedModel, error) {
+	provider, cfg, err := c.Resolve(profile)
+	if err != nil {
+		return nil, err
+	}
+	chain := []ResolvedModel{{Provider: provider, Config: cfg}}
+	p := c.Profiles[profileOrDefault(profile)]
+	for _, spec := range p.Fallback {
+		fallback, err := c.resolveModel(profile, ProfileConfig{
+			Model:       spec,
+			Temperature: p.Temperature,
+			MaxTokens:   p.MaxTokens,
+			Timeout:     p.Timeout,
+		})
+		if err != nil {
+			return nil, err
 		}
-		client, err = volc.NewClient(volc.WithModel(model))
-	default:
-		return "", fmt.Errorf("不支持的 LLM 提供商: %s", provider)
+		chain = append(chain, ResolvedModel{Provider: fallback.Provider, Config: fallback.Config})
+	}
+	return chain, nil
+}
+
+// Resolve 返回 profile 使用的提供商以及合并了提供商配置后的客户端配置
+func (c *LLMConfig) Resolve(profile string) (string, llm.Config, error) {
+	profile = profileOrDefault(profile)
+	p, ok := c.Profiles[profile]
+	if !ok && profile == defaultProfile && c.Default != "" {
+		p, ok = ProfileConfig{Model: c.Default}, true
+	}
+	if !ok {
+		return "", llm.Config{}, fmt.Errorf("LLM profile '%s' 不存在 (可用: %s)", profile, strings.Join(c.profileNames(), ", "))
 	}
 
+	m, err := c.resolveModel(profile, p)
 	if err != nil {
-		return "", fmt.Errorf("为 %s 创建 LLM 客户端失败: %w", provider, err)
+		return "", llm.Config{}, err
 	}
+	return m.Provider, m.Config, nil
+}
 
-	// 为 API 调用设置一个超时上下文
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
-	defer cancel()
+// resolveModel 把 profile 中的 provider:model 和提供商配置合并为客户端配置
+func (c *LLMConfig) resolveModel(profile string, p ProfileConfig) (ResolvedModel, error) {
+	provider, model, ok := strings.Cut(p.Model, ":")
+	if !ok || provider == "" || model == "" {
+		return ResolvedModel{}, fmt.Errorf("LLM profile '%s' 的 model 格式无效 (应为 'provider:model'): %s", profile, p.Model)
+	}
+	pc := c.Providers[provider]
+	headers := make(map[string]string, len(pc.Headers))
+	for k, v := range pc.Headers {
+		headers[k] = os.ExpandEnv(v)
+	}
+	cfg := llm.Config{
+		Model:       model,
+		APIKeyEnv:   firstNonZero(p.APIKeyEnv, pc.APIKeyEnv),
+		BaseURL:     firstNonZero(p.BaseURL, pc.BaseURL),
+		Headers:     headers,
+		Temperature: pc.Temperature,
+		MaxTokens:   firstNonZero(p.MaxTokens, pc.MaxTokens),
+		Timeout:     firstNonZero(p.Timeout, pc.Timeout, defaultLLMTimeout),
+	}
+	if p.Temperature != nil {
+		cfg.Temperature = p.Temperature
+	}
+	return ResolvedModel{Provider: provider, Config: cfg}, nil
+}
 
-	// 使用通用的 Send 方法发送请求
-	return client.Send(ctx, prompt)
+func (c *LLMConfig) profileNames() []string {
+	names := make([]string, 0, len(c.Profiles)+1)
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	if _, ok := c.Profiles[defaultProfile]; !ok && c.Default != "" {
+		names = append(names, defaultProfile)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func profileOrDefault(profile string) string {
+	if profile == "" {
+		return defaultProfile
+	}
+	return profile
+}
+
+func firstNonZero[T comparable](values ...T) T {
+	var zero T
+	for _, v := range values {
+		if v != zero {
+			return v
+		}
+	}
+	return zero
 }
 
 // loadLLMConfig 读取并解析 .goprojectstarter.yaml 文件
@@ -0,0 +1,146 @@
+package common
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Skyenought/goprojectstarter/internal/llm"
+	"gopkg.in/yaml.v3"
+)
+
+func TestLLMConfigResolve(t *testing.T) {
+	var config struct {
+		LLM LLMConfig `yaml:"llm"`
+	}
+	err := yaml.Unmarshal([]byte(`
+llm:
+  profiles:
+    default:
+      model: ollama:qwen2.5-coder:7b
+    review:
+      model: openai:gpt-4o
+      temperature: 0.2
+      timeout: 5m
+  providers:
+    ollama:
+      base_url: http://gpu-box:11434
+      timeout: 30s
+    openai:
+      max_tokens: 4096
+      temperature: 0.7
+`), &config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	provider, cfg, err := config.LLM.Resolve("")
+	if err != nil || provider != "ollama" || cfg.Model != "qwen2.5-coder:7b" || cfg.BaseURL != "http://gpu-box:11434" || cfg.Timeout != 30*time.Second {
+		t.Errorf("default profile = %s %+v, %v", provider, cfg, err)
+	}
+	provider, cfg, err = config.LLM.Resolve("review")
+	if err != nil || provider != "openai" || *cfg.Temperature != 0.2 || cfg.MaxTokens != 4096 || cfg.Timeout != 5*time.Minute {
+		t.Errorf("review profile = %s %+v, %v", provider, cfg, err)
+	}
+	if _, _, err := config.LLM.Resolve("fast"); err == nil {
+		t.Error("Resolve accepted an undefined profile")
+	}
+
+	legacy := LLMConfig{Default: "deepseek:deepseek-chat"}
+	if provider, cfg, err := legacy.Resolve("default"); err != nil || provider != "deepseek" || cfg.Timeout != defaultLLMTimeout {
+		t.Errorf("legacy default = %s %+v, %v", provider, cfg, err)
+	}
+}
+
+func TestLLMConfigResolveChain(t *testing.T) {
+	var config struct {
+		LLM LLMConfig `yaml:"llm"`
+	}
+	err := yaml.Unmarshal([]byte(`
+llm:
+  retry:
+    max_retries: 0
+    max_backoff: 5s
+  profiles:
+    default:
+      model: deepseek:deepseek-chat
+      timeout: 30s
+      fallback:
+        - openai:gpt-4o-mini
+        - ollama:qwen2.5-coder:7b
+  providers:
+    deepseek:
+      requests_per_minute: 30
+    ollama:
+      base_url: http://gpu-box:11434
+`), &config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chain, err := config.LLM.ResolveChain("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, m := range chain {
+		got = append(got, m.Provider+":"+m.Config.Model)
+		if m.Config.Timeout != 30*time.Second {
+			t.Errorf("%s timeout = %s, want the profile's 30s", m.Provider, m.Config.Timeout)
+		}
+	}
+	if strings.Join(got, ",") != "deepseek:deepseek-chat,openai:gpt-4o-mini,ollama:qwen2.5-coder:7b" {
+		t.Errorf("ResolveChain() = %v", got)
+	}
+	if chain[2].Config.BaseURL != "http://gpu-box:11434" {
+		t.Errorf("fallback base_url = %q, want the ollama provider setting", chain[2].Config.BaseURL)
+	}
+
+	policy := config.LLM.Retry.policy()
+	if policy.MaxRetries != 0 || policy.MaxBackoff != 5*time.Second || policy.InitialBackoff != time.Second {
+		t.Errorf("retry policy = %+v", policy)
+	}
+}
+
+func TestLLMConfigPromptBudget(t *testing.T) {
+	config := LLMConfig{
+		Profiles: map[string]ProfileConfig{
+			"default":  {Model: "gemini:gemini-1.5-pro-latest", MaxTokens: 4000, Fallback: []string{"ollama:qwen2.5-coder:7b"}},
+			"explicit": {Model: "deepseek:deepseek-chat", PromptBudget: 12000},
+		},
+		Providers: map[string]ProviderConfig{"ollama": {ContextWindow: 16000}},
+	}
+
+	// 回退链中上下文最小的 ollama 决定预算
+	budget, err := config.PromptBudget("default")
+	if err != nil || budget.Model != "qwen2.5-coder:7b" || budget.Tokens != 12000 {
+		t.Errorf("default budget = %+v, %v; want qwen2.5-coder:7b with 16000-4000 tokens", budget, err)
+	}
+	budget, err = config.PromptBudget("explicit")
+	if err != nil || budget.Model != "deepseek-chat" || budget.Tokens != 12000 {
+		t.Errorf("explicit budget = %+v, %v", budget, err)
+	}
+}
+
+func TestCassetteConfigResolve(t *testing.T) {
+	t.Setenv(cassetteModeEnv, "")
+	if c, err := (CassetteConfig{}).resolve(); err != nil || c != nil {
+		t.Fatalf("resolve() without mode = %v, %v, want disabled", c, err)
+	}
+
+	c, err := CassetteConfig{Mode: "replay"}.resolve()
+	if err != nil || c.Mode != llm.CassetteReplay || c.Dir != defaultCassetteDir {
+		t.Fatalf("resolve() = %+v, %v, want replay in %s", c, err, defaultCassetteDir)
+	}
+
+	t.Setenv(cassetteModeEnv, "strict")
+	c, err = CassetteConfig{Dir: "fixtures", Mode: "record"}.resolve()
+	if err != nil || c.Mode != llm.CassetteStrict || c.Dir != "fixtures" {
+		t.Fatalf("resolve() with %s=strict = %+v, %v", cassetteModeEnv, c, err)
+	}
+
+	t.Setenv(cassetteModeEnv, "rewind")
+	if _, err := (CassetteConfig{}).resolve(); err == nil {
+		t.Fatal("resolve() with an invalid mode error = nil, want an error")
+	}
+}
@@ -0,0 +1,239 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Skyenought/goprojectstarter/internal/llm"
+	"golang.org/x/term"
+)
+
+// NoLLMStream 为 true 时使用阻塞请求, 不显示流式进度, 由命令的 --no-stream 设置
+var NoLLMStream bool
+
+// StreamPreviewLines 是进度行下方预览的最近几行回复, 为 0 时只显示进度行, 由命令的 --preview-lines 设置
+var StreamPreviewLines = 6
+
+// streamRefresh 是终端中进度的刷新间隔
+const streamRefresh = 100 * time.Millisecond
+
+// streamSpinner 是进度行开头的动画帧
+var streamSpinner = []rune("⠋⠙⠹⠸⠼⠴⠦⠧⠇⠏")
+
+// activeProgress 是正在显示的进度, 请求期间的日志需要经过它打印, 否则会被下一次重绘覆盖
+var (
+	activeMu       sync.Mutex
+	activeProgress *streamProgress
+)
+
+// logLLM 打印请求期间的一行日志。正在显示进度时先收起进度, 打印后在日志下方重新绘制
+func logLLM(format string, args ...any) {
+	activeMu.Lock()
+	defer activeMu.Unlock()
+	if activeProgress == nil {
+		fmt.Printf(format, args...)
+		return
+	}
+	activeProgress.printf(format, args...)
+}
+
+// streamProgress 在终端中实时显示流式生成的进度: 估算的 token 数、耗时, 以及最近收到的几行回复。
+// 结束时收起预览, 只留下一行摘要。输出不是终端时不做动态刷新, 只在结束时打印摘要
+type streamProgress struct {
+	out     io.Writer
+	live    bool
+	width   int
+	model   string
+	preview int
+	start   time.Time
+	done    chan struct{}
+	stopped chan struct{}
+
+	mu    sync.Mutex
+	text  strings.Builder
+	frame int
+	// drawn 是上一次绘制占用的行数, 下一次绘制前先回到这些行的开头
+	drawn int
+}
+
+func newStreamProgress(out io.Writer, live bool, width int, model string) *streamProgress {
+	return &streamProgress{
+		out:     out,
+		live:    live,
+		width:   width,
+		model:   model,
+		preview: StreamPreviewLines,
+		start:   time.Now(),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+}
+
+// startStreamProgress 在标准输出上开始显示进度, 标准输出是终端时每 streamRefresh 刷新一次
+func startStreamProgress(model string) *streamProgress {
+	fd := int(os.Stdout.Fd())
+	width := 80
+	if w, _, err := term.GetSize(fd); err == nil && w > 0 {
+		width = w
+	}
+	p := newStreamProgress(os.Stdout, term.IsTerminal(fd), width, model)
+	activeMu.Lock()
+	activeProgress = p
+	activeMu.Unlock()
+	if p.live {
+		go p.run()
+	} else {
+		close(p.stopped)
+	}
+	return p
+}
+
+func (p *streamProgress) run() {
+	defer close(p.stopped)
+	ticker := time.NewTicker(streamRefresh)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+			p.mu.Lock()
+			p.frame++
+			p.draw(p.lines())
+			p.mu.Unlock()
+		}
+	}
+}
+
+// add 记录收到的片段, 用作 onChunk 回调
+func (p *streamProgress) add(chunk string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.text.WriteString(chunk)
+}
+
+// finish 停止刷新, 收起预览并打印一行摘要。err 是整个请求的结果
+func (p *streamProgress) finish(err error) {
+	activeMu.Lock()
+	if activeProgress == p {
+		activeProgress = nil
+	}
+	activeMu.Unlock()
+
+	select {
+	case <-p.done:
+	default:
+		close(p.done)
+	}
+	<-p.stopped
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	tokens, elapsed := p.stats()
+	var summary string
+	switch {
+	case errors.Is(err, context.Canceled):
+		summary = fmt.Sprintf("   ⏹️ 已取消 LLM 请求 (已收到 ~%d tokens, 用时 %s)", tokens, elapsed)
+	case p.text.Len() == 0:
+		summary = fmt.Sprintf("   ✗ 没有收到 LLM 回复 (用时 %s)", elapsed)
+	default:
+		summary = fmt.Sprintf("   ✓ 已收到 LLM 回复: ~%d tokens, 用时 %s", tokens, elapsed)
+	}
+	p.draw([]string{summary})
+}
+
+func (p *streamProgress) stats() (int, time.Duration) {
+	return llm.EstimateTokens(p.model, p.text.String()), time.Since(p.start).Round(streamRefresh)
+}
+
+// draw 用 lines 覆盖上一次绘制的内容。非终端输出只写入最后的摘要
+func (p *streamProgress) draw(lines []string) {
+	var b strings.Builder
+	if p.live {
+		if p.drawn > 0 {
+			// 回到上一次绘制的第一行行首, 然后清除到屏幕末尾
+			fmt.Fprintf(&b, "\x1b[%dF", p.drawn)
+		}
+		b.WriteString("\x1b[J")
+	}
+	for _, line := range lines {
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	p.drawn = len(lines)
+	io.WriteString(p.out, b.String())
+}
+
+// printf 在进度上方打印一行日志: 收起上一次绘制的内容, 写入日志后重新绘制进度。非终端输出直接写入日志
+func (p *streamProgress) printf(format string, args ...any) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.live {
+		fmt.Fprintf(p.out, format, args...)
+		return
+	}
+	var b strings.Builder
+	if p.drawn > 0 {
+		fmt.Fprintf(&b, "\x1b[%dF", p.drawn)
+	}
+	b.WriteString("\x1b[J")
+	fmt.Fprintf(&b, format, args...)
+	io.WriteString(p.out, b.String())
+	p.drawn = 0
+	p.draw(p.lines())
+}
+
+// lines 返回进度行和预览
+func (p *streamProgress) lines() []string {
+	spinner := string(streamSpinner[p.frame%len(streamSpinner)])
+	tokens, elapsed := p.stats()
+	if p.text.Len() == 0 {
+		return []string{fmt.Sprintf("   %s 等待 LLM 响应... %s (Ctrl-C 取消)", spinner, elapsed)}
+	}
+	lines := []string{fmt.Sprintf("   %s 正在接收 LLM 回复: ~%d tokens, %s (Ctrl-C 取消)", spinner, tokens, elapsed)}
+	for _, line := range lastLines(p.text.String(), p.preview) {
+		line = strings.ReplaceAll(line, "\t", "    ")
+		lines = append(lines, "\x1b[2m     │ "+truncateWidth(line, p.width-8)+"\x1b[0m")
+	}
+	return lines
+}
+
+// lastLines 返回 text 的最后 n 行, 包括尚未结束的最后一行
+func lastLines(text string, n int) []string {
+	if n <= 0 {
+		return nil
+	}
+	text = strings.TrimRight(text, "\n")
+	end := len(text)
+	for i := len(text) - 1; i >= 0; i-- {
+		if text[i] != '\n' {
+			continue
+		}
+		if n--; n == 0 {
+			return strings.Split(text[i+1:end], "\n")
+		}
+	}
+	return strings.Split(text, "\n")
+}
+
+// truncateWidth 把一行截断到终端的 width 列以内, 避免折行打乱重绘的行数。中日韩等全角字符按两列计算
+func truncateWidth(s string, width int) string {
+	used := 0
+	for i, r := range s {
+		w := 1
+		if r >= 0x2E80 {
+			w = 2
+		}
+		if used+w > width {
+			return s[:i]
+		}
+		used += w
+	}
+	return s
+}
@@ -0,0 +1,88 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestStreamProgress(t *testing.T) {
+	var out bytes.Buffer
+	p := newStreamProgress(&out, true, 30, "gpt-4o")
+	p.preview = 2
+	close(p.stopped) // 测试中手动绘制, 不启动刷新的 goroutine
+
+	if lines := p.lines(); len(lines) != 1 || !strings.Contains(lines[0], "等待 LLM 响应") {
+		t.Fatalf("lines() before any chunk = %q", lines)
+	}
+	p.add("package main\n\nfunc main() {\n")
+	p.add("\tprintln(\"一二三四五六七八九十\")\n}")
+	lines := p.lines()
+	if len(lines) != 3 || !strings.Contains(lines[0], "正在接收 LLM 回复") {
+		t.Fatalf("lines() = %q, want a status line and two preview lines", lines)
+	}
+	if !strings.Contains(lines[1], "│     println(\"一二三四") || strings.Contains(lines[1], "五") || !strings.Contains(lines[2], "│ }") {
+		t.Errorf("preview = %q, want the last two lines truncated to the terminal width", lines[1:])
+	}
+
+	p.draw(lines)
+	out.Reset()
+	p.finish(nil)
+	// 收起三行预览, 只留下一行摘要
+	if got := out.String(); !strings.HasPrefix(got, "\x1b[3F\x1b[J") || !strings.Contains(got, "✓ 已收到 LLM 回复") || strings.Count(got, "\n") != 1 {
+		t.Errorf("finish() output = %q", got)
+	}
+}
+
+func TestStreamProgressLog(t *testing.T) {
+	var out bytes.Buffer
+	p := newStreamProgress(&out, true, 80, "")
+	p.preview = 0
+	close(p.stopped)
+	activeProgress = p
+	t.Cleanup(func() { activeProgress = nil })
+
+	p.draw(p.lines())
+	out.Reset()
+	logLLM("     · openai:gpt-4o, 耗时 %s, 成功\n", "1s")
+	// 日志写在进度原来的位置, 进度在日志下方重新绘制, 下一次重绘不会覆盖日志
+	got := out.String()
+	if !strings.HasPrefix(got, "\x1b[1F\x1b[J     · openai:gpt-4o, 耗时 1s, 成功\n\x1b[J") || !strings.Contains(got, "等待 LLM 响应") || p.drawn != 1 {
+		t.Errorf("logLLM() output = %q, drawn = %d", got, p.drawn)
+	}
+
+	p.finish(nil)
+	if activeProgress != nil {
+		t.Error("finish() must stop routing logs through the progress")
+	}
+}
+
+func TestStreamProgressCanceled(t *testing.T) {
+	var out bytes.Buffer
+	p := newStreamProgress(&out, false, 80, "")
+	close(p.stopped)
+	p.add("{\"handler\":")
+	p.finish(fmt.Errorf("%w: %w", ErrLLMCanceled, context.Canceled))
+	if got := out.String(); strings.Contains(got, "\x1b") || !strings.Contains(got, "已取消 LLM 请求") {
+		t.Errorf("finish() output = %q, want a plain cancel summary", got)
+	}
+}
+
+func TestLastLines(t *testing.T) {
+	tests := []struct {
+		text string
+		n    int
+		want string
+	}{
+		{"a\nb\nc\n", 2, "b|c"},
+		{"a\nb\nc", 5, "a|b|c"},
+		{"a\nb", 0, ""},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(lastLines(tt.text, tt.n), "|"); got != tt.want {
+			t.Errorf("lastLines(%q, %d) = %q, want %q", tt.text, tt.n, got, tt.want)
+		}
+	}
+}
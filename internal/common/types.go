@@ -19,6 +19,7 @@ type ProjectPathConfig struct {
 	RepoImplDir      string
 	ServiceDir       string
 	HandlerDir       string
+	DTODir           string
 	RouterFile       string
 }
 
@@ -15,6 +15,7 @@ func GetProjectPaths() (ProjectPathConfig, error) {
 			RepoImplDir:      "internal/infrastructure/persistence",
 			ServiceDir:       "internal/application/service",
 			HandlerDir:       "internal/interfaces/handler",
+			DTODir:           "internal/interfaces/dto",
 			RouterFile:       "internal/infrastructure/router/router.go",
 		}, nil
 	}
@@ -24,6 +25,7 @@ func GetProjectPaths() (ProjectPathConfig, error) {
 			RepoImplDir:      "internal/adapter/repository",
 			ServiceDir:       "internal/usecase/service",
 			HandlerDir:       "internal/adapter/handler",
+			DTODir:           "internal/adapter/dto",
 			RouterFile:       "internal/adapter/router/router.go",
 		}, nil
 	}
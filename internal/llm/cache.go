@@ -0,0 +1,368 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultCacheTTL 是缓存条目的默认有效期
+	DefaultCacheTTL = 7 * 24 * time.Hour
+	// DefaultCacheMaxBytes 是缓存目录的默认大小上限
+	DefaultCacheMaxBytes = 100 << 20
+)
+
+// DefaultCacheDir 返回用户缓存目录下的默认位置, 例如 ~/.cache/goprojectstarter/llm
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "goprojectstarter", "llm"), nil
+}
+
+// cacheEntry 是缓存目录中的一个文件
+type cacheEntry struct {
+	Provider    string    `json:"provider"`
+	Model       string    `json:"model"`
+	Temperature string    `json:"temperature"`
+	Kind        string    `json:"kind"`
+	CreatedAt   time.Time `json:"created_at"`
+	Response    string    `json:"response"`
+}
+
+// ResponseCache 是以请求内容的哈希寻址的磁盘缓存, 文件按哈希的前两位分目录存放。
+// 过期的条目在读取时删除; 写入后总大小超过 MaxBytes 时, 按最近使用时间从旧到新删除。
+type ResponseCache struct {
+	Dir string
+	// TTL 为 0 时条目不会过期
+	TTL time.Duration
+	// MaxBytes 为 0 时不限制大小
+	MaxBytes int64
+}
+
+func (c *ResponseCache) path(key string) string {
+	return filepath.Join(c.Dir, key[:2], key+".json")
+}
+
+// get 返回未过期的条目; 命中时更新文件的修改时间, 作为淘汰时的最近使用时间
+func (c *ResponseCache) get(key string) (*cacheEntry, bool) {
+	path := c.path(key)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || c.expired(entry.CreatedAt) {
+		os.Remove(path)
+		return nil, false
+	}
+	now := time.Now()
+	os.Chtimes(path, now, now)
+	return &entry, true
+}
+
+func (c *ResponseCache) put(key string, entry cacheEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	path := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("创建 LLM 缓存目录失败: %w", err)
+	}
+	// 先写临时文件再重命名, 避免并发的生成读到写了一半的条目
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("写入 LLM 缓存失败: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("写入 LLM 缓存失败: %w", err)
+	}
+	return c.prune()
+}
+
+func (c *ResponseCache) expired(created time.Time) bool {
+	return c.TTL > 0 && time.Since(created) > c.TTL
+}
+
+type cacheFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+func (c *ResponseCache) files() ([]cacheFile, error) {
+	var files []cacheFile
+	err := filepath.WalkDir(c.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return fs.SkipAll
+			}
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".json") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		files = append(files, cacheFile{path: path, size: info.Size(), modTime: info.ModTime()})
+		return nil
+	})
+	return files, err
+}
+
+// prune 在总大小超过 MaxBytes 时删除最久未使用的条目
+func (c *ResponseCache) prune() error {
+	if c.MaxBytes <= 0 {
+		return nil
+	}
+	files, err := c.files()
+	if err != nil {
+		return err
+	}
+	var total int64
+	for _, f := range files {
+		total += f.size
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+	for _, f := range files {
+		if total <= c.MaxBytes {
+			break
+		}
+		if err := os.Remove(f.path); err == nil {
+			total -= f.size
+		}
+	}
+	return nil
+}
+
+// Clear 删除所有缓存条目, 返回删除的数量
+func (c *ResponseCache) Clear() (int, error) {
+	files, err := c.files()
+	if err != nil {
+		return 0, err
+	}
+	for _, f := range files {
+		if err := os.Remove(f.path); err != nil {
+			return 0, err
+		}
+	}
+	return len(files), nil
+}
+
+// CacheStats 是缓存目录的统计信息
+type CacheStats struct {
+	Entries int
+	Expired int
+	Bytes   int64
+	Oldest  time.Time
+	Newest  time.Time
+	// ByModel 是每个 provider:model 的条目数
+	ByModel map[string]int
+}
+
+// Stats 读取所有条目并统计
+func (c *ResponseCache) Stats() (CacheStats, error) {
+	stats := CacheStats{ByModel: make(map[string]int)}
+	files, err := c.files()
+	if err != nil {
+		return stats, err
+	}
+	for _, f := range files {
+		data, err := os.ReadFile(f.path)
+		if err != nil {
+			continue
+		}
+		var entry cacheEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			continue
+		}
+		stats.Entries++
+		stats.Bytes += f.size
+		stats.ByModel[entry.Provider+":"+entry.Model]++
+		if c.expired(entry.CreatedAt) {
+			stats.Expired++
+		}
+		if stats.Oldest.IsZero() || entry.CreatedAt.Before(stats.Oldest) {
+			stats.Oldest = entry.CreatedAt
+		}
+		if entry.CreatedAt.After(stats.Newest) {
+			stats.Newest = entry.CreatedAt
+		}
+	}
+	return stats, nil
+}
+
+// CachedAssistant 在 Inner 之前查询 ResponseCache, 只缓存成功的回复。
+// 缓存键由 Provider、Model、Temperature 和请求内容 (prompt、附件的路径和内容、schema) 组成,
+// Provider 和 Model 必须是实际回答请求的模型, 回退链需要为每个模型单独包装
+type CachedAssistant struct {
+	Cache       *ResponseCache
+	Inner       Assistant
+	Provider    string
+	Model       string
+	Temperature *float32
+	// OnHit 在命中缓存时调用, 参数是条目写入至今的时间
+	OnHit func(age time.Duration)
+	// OnStoreError 在写入缓存失败时调用, 为 nil 时直接打印警告
+	OnStoreError func(err error)
+}
+
+var _ Assistant = (*CachedAssistant)(nil)
+
+// temperature 返回缓存键中使用的温度, 未设置时为 default (使用客户端自己的默认值)
+func (a *CachedAssistant) temperature() string {
+	if a.Temperature == nil {
+		return "default"
+	}
+	return strconv.FormatFloat(float64(*a.Temperature), 'f', -1, 32)
+}
+
+func (a *CachedAssistant) key(kind, prompt string, extra ...string) string {
+	return hashKey(append([]string{a.Provider, a.Model, a.temperature(), kind, prompt}, extra...)...)
+}
+
+// fileKeys 返回附件的路径和内容哈希, 附件内容变化后不会命中旧的回复。读取失败时返回错误, 调用方不使用缓存
+func fileKeys(files []string) ([]string, error) {
+	keys := make([]string, len(files))
+	for i, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(data)
+		keys[i] = f + "@" + hex.EncodeToString(sum[:])
+	}
+	return keys, nil
+}
+
+func (a *CachedAssistant) entry(kind, response string) cacheEntry {
+	return cacheEntry{Provider: a.Provider, Model: a.Model, Temperature: a.temperature(), Kind: kind, CreatedAt: time.Now(), Response: response}
+}
+
+func (a *CachedAssistant) lookup(key string) (string, bool) {
+	entry, ok := a.Cache.get(key)
+	if !ok {
+		return "", false
+	}
+	if a.OnHit != nil {
+		a.OnHit(time.Since(entry.CreatedAt))
+	}
+	return entry.Response, true
+}
+
+// store 写入缓存。写入失败不影响本次请求的结果
+func (a *CachedAssistant) store(key string, entry cacheEntry) {
+	err := a.Cache.put(key, entry)
+	switch {
+	case err == nil:
+	case a.OnStoreError != nil:
+		a.OnStoreError(err)
+	default:
+		fmt.Printf("   ⚠️ %v\n", err)
+	}
+}
+
+// Send 实现 Assistant 接口的 Send 方法。
+func (a *CachedAssistant) Send(ctx context.Context, prompt string, files ...string) (string, error) {
+	extra, err := fileKeys(files)
+	if err != nil {
+		return a.Inner.Send(ctx, prompt, files...)
+	}
+	key := a.key("send", prompt, extra...)
+	if reply, ok := a.lookup(key); ok {
+		return reply, nil
+	}
+	reply, err := a.Inner.Send(ctx, prompt, files...)
+	if err == nil {
+		a.store(key, a.entry("send", reply))
+	}
+	return reply, err
+}
+
+// SendStructured 实现 Assistant 接口的 SendStructured 方法。
+func (a *CachedAssistant) SendStructured(ctx context.Context, prompt string, schema *Schema) (string, error) {
+	schemaJSON, err := json.Marshal(schema)
+	if err != nil {
+		return "", err
+	}
+	key := a.key("structured", prompt, string(schemaJSON))
+	if reply, ok := a.lookup(key); ok {
+		return reply, nil
+	}
+	reply, err := a.Inner.SendStructured(ctx, prompt, schema)
+	if err == nil {
+		a.store(key, a.entry("structured", reply))
+	}
+	return reply, err
+}
+
+// SendStream 实现 Assistant 接口的 SendStream 方法。命中时整段回复作为一个片段返回, 未命中时在流结束后写入缓存
+func (a *CachedAssistant) SendStream(ctx context.Context, prompt string, files ...string) *StreamReply {
+	extra, err := fileKeys(files)
+	if err != nil {
+		return a.Inner.SendStream(ctx, prompt, files...)
+	}
+	return a.stream(a.key("send", prompt, extra...), "send", func() *StreamReply {
+		return a.Inner.SendStream(ctx, prompt, files...)
+	})
+}
+
+// SendStructuredStream 实现 Assistant 接口的 SendStructuredStream 方法。缓存的是未经校验的原始回复,
+// 因此与 SendStructured 的条目分开存放
+func (a *CachedAssistant) SendStructuredStream(ctx context.Context, prompt string, schema *Schema) *StreamReply {
+	schemaJSON, err := json.Marshal(schema)
+	if err != nil {
+		return a.Inner.SendStructuredStream(ctx, prompt, schema)
+	}
+	return a.stream(a.key("structured_stream", prompt, string(schemaJSON)), "structured_stream", func() *StreamReply {
+		return a.Inner.SendStructuredStream(ctx, prompt, schema)
+	})
+}
+
+func (a *CachedAssistant) stream(key, kind string, open func() *StreamReply) *StreamReply {
+	if reply, ok := a.lookup(key); ok {
+		stream := &StreamReply{Content: make(chan string, 1)}
+		stream.Content <- reply
+		close(stream.Content)
+		return stream
+	}
+	inner := open()
+	stream := &StreamReply{Content: make(chan string)}
+	go func() {
+		defer close(stream.Content)
+		var full strings.Builder
+		for chunk := range inner.Content {
+			full.WriteString(chunk)
+			stream.Content <- chunk
+		}
+		if stream.Err = inner.Err; stream.Err == nil {
+			a.store(key, a.entry(kind, full.String()))
+		}
+	}()
+	return stream
+}
+
+// RefreshContext 实现 Assistant 接口的 RefreshContext 方法。
+func (a *CachedAssistant) RefreshContext() {
+	a.Inner.RefreshContext()
+}
+
+// ListModelNames 实现 Assistant 接口的 ListModelNames 方法。
+func (a *CachedAssistant) ListModelNames(ctx context.Context) ([]string, error) {
+	return a.Inner.ListModelNames(ctx)
+}
@@ -0,0 +1,169 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCachedAssistant(t *testing.T) {
+	ctx := context.Background()
+	cache := &ResponseCache{Dir: t.TempDir(), TTL: time.Hour}
+	inner := &fakeAssistant{errs: []error{errors.New("boom")}, reply: "answer"}
+	hits := 0
+	cached := &CachedAssistant{Cache: cache, Inner: inner, Provider: "openai", Model: "gpt", OnHit: func(time.Duration) { hits++ }}
+
+	// 失败的回复不写入缓存
+	if _, err := cached.Send(ctx, "prompt"); err == nil {
+		t.Fatal("Send() error = nil, want the inner error")
+	}
+	if got, err := cached.Send(ctx, "prompt"); err != nil || got != "answer" || inner.calls != 2 {
+		t.Fatalf("Send() after an error = %q, %v, calls = %d, want a second request", got, err, inner.calls)
+	}
+	if got, err := cached.Send(ctx, "prompt"); err != nil || got != "answer" || inner.calls != 2 || hits != 1 {
+		t.Fatalf("Send() = %q, %v, calls = %d, hits = %d, want a cache hit", got, err, inner.calls, hits)
+	}
+
+	// 模型或温度不同时不命中
+	temperature := float32(0.2)
+	for _, other := range []*CachedAssistant{
+		{Cache: cache, Inner: inner, Provider: "openai", Model: "gpt-mini"},
+		{Cache: cache, Inner: inner, Provider: "openai", Model: "gpt", Temperature: &temperature},
+	} {
+		calls := inner.calls
+		if _, err := other.Send(ctx, "prompt"); err != nil || inner.calls != calls+1 {
+			t.Fatalf("Send() with model %q, temperature %s: calls = %d, want a new request", other.Model, other.temperature(), inner.calls)
+		}
+	}
+
+	stats, err := cache.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Entries != 3 || stats.ByModel["openai:gpt"] != 2 || stats.ByModel["openai:gpt-mini"] != 1 {
+		t.Fatalf("Stats() = %+v, want 3 entries over two models", stats)
+	}
+	if n, err := cache.Clear(); err != nil || n != 3 {
+		t.Fatalf("Clear() = %d, %v, want 3", n, err)
+	}
+	if stats, _ := cache.Stats(); stats.Entries != 0 {
+		t.Fatalf("Stats() after Clear() = %+v, want no entries", stats)
+	}
+}
+
+func TestResponseCacheExpiry(t *testing.T) {
+	cache := &ResponseCache{Dir: t.TempDir(), TTL: time.Hour}
+	key := hashKey("expired")
+	if err := cache.put(key, cacheEntry{CreatedAt: time.Now().Add(-2 * time.Hour), Response: "old"}); err != nil {
+		t.Fatal(err)
+	}
+	if stats, _ := cache.Stats(); stats.Expired != 1 {
+		t.Fatalf("Stats().Expired = %d, want 1", stats.Expired)
+	}
+	if _, ok := cache.get(key); ok {
+		t.Fatal("get() returned an expired entry")
+	}
+	if _, err := os.Stat(cache.path(key)); !os.IsNotExist(err) {
+		t.Fatalf("expired entry was not removed: %v", err)
+	}
+}
+
+func TestResponseCachePrune(t *testing.T) {
+	cache := &ResponseCache{Dir: t.TempDir()}
+	keys := []string{hashKey("a"), hashKey("b"), hashKey("c")}
+	base := time.Now().Add(-time.Hour)
+	for i, key := range keys {
+		if err := cache.put(key, cacheEntry{CreatedAt: time.Now(), Response: "reply"}); err != nil {
+			t.Fatal(err)
+		}
+		modTime := base.Add(time.Duration(i) * time.Minute)
+		os.Chtimes(cache.path(key), modTime, modTime)
+	}
+	// 读取第一个条目使它成为最近使用的
+	if _, ok := cache.get(keys[0]); !ok {
+		t.Fatal("get() missed a fresh entry")
+	}
+
+	info, err := os.Stat(cache.path(keys[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache.MaxBytes = 2 * info.Size()
+	if err := cache.prune(); err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []bool{true, false, true} {
+		_, err := os.Stat(cache.path(keys[i]))
+		if got := err == nil; got != want {
+			t.Errorf("entry %d exists = %v, want %v", i, got, want)
+		}
+	}
+	if matches, _ := filepath.Glob(filepath.Join(cache.Dir, "*", "*.tmp")); len(matches) != 0 {
+		t.Errorf("temporary files left behind: %v", matches)
+	}
+}
+
+func TestCachedAssistantFiles(t *testing.T) {
+	ctx := context.Background()
+	cache := &ResponseCache{Dir: t.TempDir()}
+	inner := &fakeAssistant{reply: "answer"}
+	cached := &CachedAssistant{Cache: cache, Inner: inner, Provider: "openai", Model: "gpt"}
+	file := filepath.Join(t.TempDir(), "schema.sql")
+	if err := os.WriteFile(file, []byte("CREATE TABLE a (id int);"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := cached.Send(ctx, "prompt", file); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if inner.calls != 1 {
+		t.Fatalf("calls = %d, want the second request to hit the cache", inner.calls)
+	}
+	// 同一路径的附件内容变化后必须重新请求
+	if err := os.WriteFile(file, []byte("CREATE TABLE b (id int);"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cached.Send(ctx, "prompt", file); err != nil || inner.calls != 2 {
+		t.Fatalf("Send() after the file changed: calls = %d, %v, want a new request", inner.calls, err)
+	}
+	// 无法读取的附件不使用缓存
+	missing := filepath.Join(t.TempDir(), "missing.sql")
+	for i := 0; i < 2; i++ {
+		cached.Send(ctx, "prompt", missing)
+	}
+	if inner.calls != 4 {
+		t.Fatalf("calls = %d, want unreadable attachments to bypass the cache", inner.calls)
+	}
+}
+
+func TestCachedChainKeysOnAnsweringModel(t *testing.T) {
+	ctx := context.Background()
+	cache := &ResponseCache{Dir: t.TempDir()}
+	rateLimited := errors.New("error, status code: 429, status: 429 Too Many Requests")
+	primary := &fakeAssistant{errs: []error{rateLimited}, reply: "primary"}
+	fallback := &fakeAssistant{reply: "fallback"}
+	chain := &Chain{Links: []Link{
+		{Provider: "openai", Model: "gpt", Client: &CachedAssistant{Cache: cache, Inner: primary, Provider: "openai", Model: "gpt"}},
+		{Provider: "ollama", Model: "llama", Client: &CachedAssistant{Cache: cache, Inner: fallback, Provider: "ollama", Model: "llama"}},
+	}}
+
+	if got, err := chain.Send(ctx, "prompt"); err != nil || got != "fallback" {
+		t.Fatalf("Send() = %q, %v, want the fallback reply", got, err)
+	}
+	stats, err := cache.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.ByModel["ollama:llama"] != 1 || stats.ByModel["openai:gpt"] != 0 {
+		t.Fatalf("Stats().ByModel = %v, want the reply stored under the fallback model", stats.ByModel)
+	}
+	// 主模型恢复后不能命中后备模型写入的条目
+	if got, err := chain.Send(ctx, "prompt"); err != nil || got != "primary" {
+		t.Fatalf("Send() after the primary recovered = %q, %v, want the primary reply", got, err)
+	}
+}
@@ -0,0 +1,228 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CassetteMode 决定 Cassette 如何使用磁带目录中的录音
+type CassetteMode string
+
+const (
+	// CassetteRecord 总是请求模型, 并用回复覆盖已有的录音
+	CassetteRecord CassetteMode = "record"
+	// CassetteReplay 优先回放录音, 没有录音时请求模型并录下回复
+	CassetteReplay CassetteMode = "replay"
+	// CassetteStrict 只回放录音, 遇到没有录音的 prompt 时返回 ErrCassetteMiss, 用于离线的确定性测试
+	CassetteStrict CassetteMode = "strict"
+)
+
+// ParseCassetteMode 解析配置或环境变量中的模式名称
+func ParseCassetteMode(s string) (CassetteMode, error) {
+	switch m := CassetteMode(strings.ToLower(strings.TrimSpace(s))); m {
+	case CassetteRecord, CassetteReplay, CassetteStrict:
+		return m, nil
+	}
+	return "", fmt.Errorf("无效的 cassette 模式: %q (可用: record, replay, strict)", s)
+}
+
+// ErrCassetteMiss 表示 strict 模式下磁带中没有该 prompt 的录音
+var ErrCassetteMiss = errors.New("cassette 中没有该 prompt 的录音")
+
+// cassetteEntry 是磁带目录中的一个录音文件。prompt 只用于人工查看和排查, 匹配只依赖文件名中的哈希
+type cassetteEntry struct {
+	Kind     string `json:"kind"`
+	Schema   string `json:"schema,omitempty"`
+	Prompt   string `json:"prompt"`
+	Response string `json:"response"`
+}
+
+// Cassette 包装一个 Assistant, 把 prompt 的哈希到回复的映射录制到 Dir 中并在之后回放,
+// 使生成命令可以在没有网络和 API Key 的情况下得到确定的结果。
+type Cassette struct {
+	Dir  string
+	Mode CassetteMode
+	// Inner 是真正发送请求的客户端, strict 模式下可以为 nil
+	Inner Assistant
+}
+
+var _ Assistant = (*Cassette)(nil)
+
+// Send 实现 Assistant 接口的 Send 方法。
+func (c *Cassette) Send(ctx context.Context, prompt string, files ...string) (string, error) {
+	entry := cassetteEntry{Kind: "send", Prompt: prompt}
+	return c.play(entry, files, func() (string, error) {
+		return c.Inner.Send(ctx, prompt, files...)
+	})
+}
+
+// SendStructured 实现 Assistant 接口的 SendStructured 方法。schema 参与哈希, 修改结构体字段后旧的录音不再匹配。
+func (c *Cassette) SendStructured(ctx context.Context, prompt string, schema *Schema) (string, error) {
+	entry := cassetteEntry{Kind: "structured", Schema: schema.Name, Prompt: prompt}
+	schemaJSON, err := json.Marshal(schema)
+	if err != nil {
+		return "", err
+	}
+	return c.play(entry, []string{string(schemaJSON)}, func() (string, error) {
+		return c.Inner.SendStructured(ctx, prompt, schema)
+	})
+}
+
+// SendStream 实现 Assistant 接口的 SendStream 方法。与 Send 共用录音: 回放时整段回复作为一个片段返回,
+// 录制时在流结束后保存拼接的完整回复。
+func (c *Cassette) SendStream(ctx context.Context, prompt string, files ...string) *StreamReply {
+	entry := cassetteEntry{Kind: "send", Prompt: prompt}
+	return c.stream(entry, files, func() *StreamReply {
+		return c.Inner.SendStream(ctx, prompt, files...)
+	})
+}
+
+// SendStructuredStream 实现 Assistant 接口的 SendStructuredStream 方法。录音与 SendStructured 分开,
+// 因为流式回复是还没有经过校验和修复的原始回复。
+func (c *Cassette) SendStructuredStream(ctx context.Context, prompt string, schema *Schema) *StreamReply {
+	entry := cassetteEntry{Kind: "structured_stream", Schema: schema.Name, Prompt: prompt}
+	schemaJSON, err := json.Marshal(schema)
+	if err != nil {
+		reply := &StreamReply{Content: make(chan string), Err: err}
+		close(reply.Content)
+		return reply
+	}
+	return c.stream(entry, []string{string(schemaJSON)}, func() *StreamReply {
+		return c.Inner.SendStructuredStream(ctx, prompt, schema)
+	})
+}
+
+// stream 按模式回放或录制一次流式请求
+func (c *Cassette) stream(entry cassetteEntry, extra []string, open func() *StreamReply) *StreamReply {
+	path := c.path(entry, extra)
+	reply := &StreamReply{Content: make(chan string, 1)}
+	if c.Mode != CassetteRecord {
+		if recorded, err := c.load(path); err == nil {
+			reply.Content <- recorded.Response
+			close(reply.Content)
+			return reply
+		} else if c.Mode == CassetteStrict || !os.IsNotExist(err) {
+			reply.Err = c.missError(err, path)
+			close(reply.Content)
+			return reply
+		}
+	}
+
+	if c.Inner == nil {
+		reply.Err = errors.New("cassette 没有可用的 LLM 客户端, 无法录制")
+		close(reply.Content)
+		return reply
+	}
+	inner := open()
+	reply.Content = make(chan string)
+	go func() {
+		defer close(reply.Content)
+		var full strings.Builder
+		for chunk := range inner.Content {
+			full.WriteString(chunk)
+			reply.Content <- chunk
+		}
+		if reply.Err = inner.Err; reply.Err == nil {
+			entry.Response = full.String()
+			reply.Err = c.save(path, entry)
+		}
+	}()
+	return reply
+}
+
+// RefreshContext 实现 Assistant 接口的 RefreshContext 方法。
+func (c *Cassette) RefreshContext() {
+	if c.Inner != nil {
+		c.Inner.RefreshContext()
+	}
+}
+
+// ListModelNames 实现 Assistant 接口的 ListModelNames 方法。
+func (c *Cassette) ListModelNames(ctx context.Context) ([]string, error) {
+	if c.Inner == nil {
+		return nil, errors.New("cassette 没有可用的 LLM 客户端")
+	}
+	return c.Inner.ListModelNames(ctx)
+}
+
+// play 按模式回放或录制一次请求
+func (c *Cassette) play(entry cassetteEntry, extra []string, call func() (string, error)) (string, error) {
+	path := c.path(entry, extra)
+	if c.Mode != CassetteRecord {
+		recorded, err := c.load(path)
+		if err == nil {
+			return recorded.Response, nil
+		}
+		if c.Mode == CassetteStrict || !os.IsNotExist(err) {
+			return "", c.missError(err, path)
+		}
+	}
+	if c.Inner == nil {
+		return "", errors.New("cassette 没有可用的 LLM 客户端, 无法录制")
+	}
+
+	response, err := call()
+	if err != nil {
+		return "", err
+	}
+	entry.Response = response
+	if err := c.save(path, entry); err != nil {
+		return "", err
+	}
+	return response, nil
+}
+
+func (c *Cassette) missError(err error, path string) error {
+	if !os.IsNotExist(err) {
+		return fmt.Errorf("读取 cassette 录音失败: %w", err)
+	}
+	return fmt.Errorf("%w (%s, 模式 %s): 请使用 record 模式重新录制", ErrCassetteMiss, path, c.Mode)
+}
+
+// path 返回请求对应的录音文件, 文件名取哈希的前 16 位。extra 是 prompt 之外参与哈希的内容 (附件路径或 schema)
+func (c *Cassette) path(entry cassetteEntry, extra []string) string {
+	return filepath.Join(c.Dir, hashKey(append([]string{entry.Kind, entry.Prompt}, extra...)...)[:16]+".json")
+}
+
+func (c *Cassette) load(path string) (*cassetteEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entry cassetteEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &entry, nil
+}
+
+func (c *Cassette) save(path string, entry cassetteEntry) error {
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(c.Dir, 0o755); err != nil {
+		return fmt.Errorf("创建 cassette 目录失败: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("保存 cassette 录音失败: %w", err)
+	}
+	return nil
+}
+
+// hashKey 返回各部分内容的 sha256 十六进制摘要, 各部分之间用 NUL 分隔
+func hashKey(parts ...string) string {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
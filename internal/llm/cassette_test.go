@@ -0,0 +1,74 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestCassette(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	schema := SchemaFor("answer", struct {
+		Text string `json:"text"`
+	}{})
+
+	inner := &fakeAssistant{reply: `{"text":"recorded"}`}
+	recorder := &Cassette{Dir: dir, Mode: CassetteRecord, Inner: inner}
+	if _, err := recorder.SendStructured(ctx, "prompt", schema); err != nil {
+		t.Fatalf("record SendStructured() error = %v", err)
+	}
+	if _, err := recorder.Send(ctx, "prompt"); err != nil {
+		t.Fatalf("record Send() error = %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+		t.Fatalf("cassette dir has %d entries, want 2 (structured and plain requests are recorded separately)", len(entries))
+	}
+
+	// strict 模式不需要客户端, 回放已录制的请求, 未录制的请求返回 ErrCassetteMiss
+	strict := &Cassette{Dir: dir, Mode: CassetteStrict}
+	if got, err := strict.SendStructured(ctx, "prompt", schema); err != nil || got != `{"text":"recorded"}` {
+		t.Fatalf("strict SendStructured() = %q, %v", got, err)
+	}
+	stream := strict.SendStream(ctx, "prompt")
+	var streamed string
+	for chunk := range stream.Content {
+		streamed += chunk
+	}
+	if stream.Err != nil || streamed != `{"text":"recorded"}` {
+		t.Fatalf("strict SendStream() = %q, %v", streamed, stream.Err)
+	}
+	if _, err := strict.Send(ctx, "another prompt"); !errors.Is(err, ErrCassetteMiss) {
+		t.Fatalf("strict Send() with unknown prompt error = %v, want ErrCassetteMiss", err)
+	}
+	other := SchemaFor("answer", struct {
+		Text  string `json:"text"`
+		Extra string `json:"extra"`
+	}{})
+	if _, err := strict.SendStructured(ctx, "prompt", other); !errors.Is(err, ErrCassetteMiss) {
+		t.Fatalf("strict SendStructured() with changed schema error = %v, want ErrCassetteMiss", err)
+	}
+
+	// replay 模式回放已有的录音, 只有未录制的请求才会发给客户端
+	inner.calls = 0
+	replay := &Cassette{Dir: dir, Mode: CassetteReplay, Inner: inner}
+	if _, err := replay.Send(ctx, "prompt"); err != nil || inner.calls != 0 {
+		t.Fatalf("replay Send() error = %v, calls = %d, want a replayed response", err, inner.calls)
+	}
+	if _, err := replay.Send(ctx, "another prompt"); err != nil || inner.calls != 1 {
+		t.Fatalf("replay Send() with unknown prompt error = %v, calls = %d, want one request", err, inner.calls)
+	}
+	if _, err := strict.Send(ctx, "another prompt"); err != nil {
+		t.Fatalf("strict Send() after replay recorded the prompt error = %v", err)
+	}
+}
+
+func TestParseCassetteMode(t *testing.T) {
+	if m, err := ParseCassetteMode(" Strict "); err != nil || m != CassetteStrict {
+		t.Fatalf("ParseCassetteMode() = %q, %v", m, err)
+	}
+	if _, err := ParseCassetteMode("rewind"); err == nil {
+		t.Fatal("ParseCassetteMode(\"rewind\") error = nil, want an error")
+	}
+}
@@ -0,0 +1,172 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Link 是回退链中的一个模型
+type Link struct {
+	Provider string
+	Model    string
+	Client   Assistant
+	// Timeout 是单次请求的超时, 每次重试重新计时; 为 0 时只受调用方 context 的限制
+	Timeout time.Duration
+	// Limiter 是提供商共享的限流器, 为 nil 时不限流
+	Limiter *rate.Limiter
+}
+
+// Attempt 记录对某个模型的一次请求
+type Attempt struct {
+	Provider string
+	Model    string
+	Retry    int // 在同一个模型上的第几次重试, 第一次请求为 0
+	Latency  time.Duration
+	Err      error
+	Class    ErrorClass // Err 不为 nil 时有效
+}
+
+// Chain 按顺序使用一组模型: 可重试的错误在同一个模型上退避重试,
+// 重试用尽或上下文超长时切换到下一个模型, 永久性错误直接返回。
+type Chain struct {
+	Links  []Link
+	Policy RetryPolicy
+	// OnAttempt 在每次请求结束后调用, 用于记录提供商、模型、耗时和结果
+	OnAttempt func(Attempt)
+}
+
+var _ Assistant = (*Chain)(nil)
+
+// Send 实现 Assistant 接口的 Send 方法。
+func (c *Chain) Send(ctx context.Context, prompt string, files ...string) (string, error) {
+	return c.do(ctx, func(ctx context.Context, a Assistant) (string, error) {
+		return a.Send(ctx, prompt, files...)
+	})
+}
+
+// SendStructured 实现 Assistant 接口的 SendStructured 方法。
+func (c *Chain) SendStructured(ctx context.Context, prompt string, schema *Schema) (string, error) {
+	return c.do(ctx, func(ctx context.Context, a Assistant) (string, error) {
+		return a.SendStructured(ctx, prompt, schema)
+	})
+}
+
+// SendStream 实现 Assistant 接口的 SendStream 方法, 重试和回退的规则见 stream。
+func (c *Chain) SendStream(ctx context.Context, prompt string, files ...string) *StreamReply {
+	return c.stream(ctx, func(ctx context.Context, a Assistant) *StreamReply {
+		return a.SendStream(ctx, prompt, files...)
+	})
+}
+
+// SendStructuredStream 实现 Assistant 接口的 SendStructuredStream 方法, 重试和回退的规则见 stream。
+func (c *Chain) SendStructuredStream(ctx context.Context, prompt string, schema *Schema) *StreamReply {
+	return c.stream(ctx, func(ctx context.Context, a Assistant) *StreamReply {
+		return a.SendStructuredStream(ctx, prompt, schema)
+	})
+}
+
+// RefreshContext 实现 Assistant 接口的 RefreshContext 方法。
+func (c *Chain) RefreshContext() {
+	for _, link := range c.Links {
+		link.Client.RefreshContext()
+	}
+}
+
+// ListModelNames 实现 Assistant 接口的 ListModelNames 方法, 返回第一个模型所在平台的模型列表。
+func (c *Chain) ListModelNames(ctx context.Context) ([]string, error) {
+	if len(c.Links) == 0 {
+		return nil, errors.New("LLM 回退链中没有可用的模型")
+	}
+	return c.Links[0].Client.ListModelNames(ctx)
+}
+
+func (c *Chain) do(ctx context.Context, call func(context.Context, Assistant) (string, error)) (string, error) {
+	if len(c.Links) == 0 {
+		return "", errors.New("LLM 回退链中没有可用的模型")
+	}
+	var errs []error
+	for _, link := range c.Links {
+		reply, err := c.tryLink(ctx, link, call)
+		if err == nil {
+			return reply, nil
+		}
+		errs = append(errs, fmt.Errorf("%s:%s: %w", link.Provider, link.Model, err))
+		if ctx.Err() != nil || Classify(err) == ErrorPermanent || isCommitted(err) {
+			break
+		}
+	}
+	return "", errors.Join(errs...)
+}
+
+// tryLink 在一个模型上请求, 可重试的错误按 Policy 退避重试
+func (c *Chain) tryLink(ctx context.Context, link Link, call func(context.Context, Assistant) (string, error)) (string, error) {
+	for retry := 0; ; retry++ {
+		if link.Limiter != nil {
+			if err := link.Limiter.Wait(ctx); err != nil {
+				return "", err
+			}
+		}
+
+		attemptCtx, cancel := ctx, context.CancelFunc(func() {})
+		if link.Timeout > 0 {
+			attemptCtx, cancel = context.WithTimeout(ctx, link.Timeout)
+		}
+		start := time.Now()
+		reply, err := call(attemptCtx, link.Client)
+		cancel()
+
+		attempt := Attempt{Provider: link.Provider, Model: link.Model, Retry: retry, Latency: time.Since(start), Err: err}
+		if err != nil {
+			attempt.Class = Classify(err)
+		}
+		if c.OnAttempt != nil {
+			c.OnAttempt(attempt)
+		}
+		if err == nil || !attempt.Class.Retryable() || retry >= c.Policy.MaxRetries || isCommitted(err) {
+			return reply, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(c.Policy.Backoff(retry)):
+		}
+	}
+}
+
+// committedError 表示流式回复已经向调用方输出了内容, 之后的错误不能再通过重试或回退掩盖
+type committedError struct{ err error }
+
+func (e *committedError) Error() string { return e.err.Error() }
+func (e *committedError) Unwrap() error { return e.err }
+
+func isCommitted(err error) bool {
+	var committed *committedError
+	return errors.As(err, &committed)
+}
+
+// stream 按与 Send 相同的规则请求流式回复: 每次尝试经过限流并单独计时 (Timeout 覆盖整个流), 结束后调用 OnAttempt;
+// 输出第一个非空片段之前的错误按 Policy 重试或回退到下一个模型, 之后的错误通过 StreamReply.Err 返回
+func (c *Chain) stream(ctx context.Context, open func(context.Context, Assistant) *StreamReply) *StreamReply {
+	reply := &StreamReply{Content: make(chan string)}
+	go func() {
+		defer close(reply.Content)
+		_, reply.Err = c.do(ctx, func(ctx context.Context, a Assistant) (string, error) {
+			inner := open(ctx, a)
+			started := false
+			for chunk := range inner.Content {
+				started = started || chunk != ""
+				reply.Content <- chunk
+			}
+			if inner.Err != nil && started {
+				return "", &committedError{err: inner.Err}
+			}
+			return "", inner.Err
+		})
+	}()
+	return reply
+}
@@ -0,0 +1,270 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeAssistant 依次返回预设的错误, 用完后返回 reply
+type fakeAssistant struct {
+	errs  []error
+	reply string
+	calls int
+}
+
+func (f *fakeAssistant) Send(context.Context, string, ...string) (string, error) {
+	f.calls++
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+		return "", err
+	}
+	return f.reply, nil
+}
+
+func (f *fakeAssistant) SendStructured(ctx context.Context, prompt string, _ *Schema) (string, error) {
+	return f.Send(ctx, prompt)
+}
+
+// SendStream 把 Send 的结果作为唯一的片段返回
+func (f *fakeAssistant) SendStream(ctx context.Context, prompt string, _ ...string) *StreamReply {
+	reply := &StreamReply{Content: make(chan string, 1)}
+	text, err := f.Send(ctx, prompt)
+	if reply.Err = err; err == nil {
+		reply.Content <- text
+	}
+	close(reply.Content)
+	return reply
+}
+
+func (f *fakeAssistant) SendStructuredStream(ctx context.Context, prompt string, _ *Schema) *StreamReply {
+	return f.SendStream(ctx, prompt)
+}
+
+func (f *fakeAssistant) RefreshContext()                                  {}
+func (f *fakeAssistant) ListModelNames(context.Context) ([]string, error) { return nil, nil }
+
+type httpCodeError int
+
+func (e httpCodeError) Error() string { return "googleapi error" }
+func (e httpCodeError) HTTPCode() int { return int(e) }
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		err  error
+		want ErrorClass
+	}{
+		{errors.New("error, status code: 429, status: 429 Too Many Requests, message: rate limit reached"), ErrorRateLimited},
+		{errors.New("error, status code: 503, status: 503 Service Unavailable, message: overloaded"), ErrorServer},
+		{errors.New("error, status code: 400, status: 400 Bad Request, message: This model's maximum context length is 65536 tokens"), ErrorContextLength},
+		{errors.New("error, status code: 401, status: 401 Unauthorized, message: invalid api key"), ErrorPermanent},
+		{fmt.Errorf("send: %w", context.DeadlineExceeded), ErrorTimeout},
+		{&StatusError{StatusCode: 502, Err: errors.New("bad gateway")}, ErrorServer},
+		{httpCodeError(429), ErrorRateLimited},
+		{&StructuredError{Err: errors.New("缺少必填字段")}, ErrorPermanent},
+	}
+	for _, tt := range tests {
+		if got := Classify(tt.err); got != tt.want {
+			t.Errorf("Classify(%v) = %s, want %s", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	for retry, want := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second} {
+		for range 20 {
+			if got := p.Backoff(retry); got < want/2 || got > want {
+				t.Fatalf("Backoff(%d) = %s, want within [%s, %s]", retry, got, want/2, want)
+			}
+		}
+	}
+}
+
+func TestChain(t *testing.T) {
+	rateLimited := errors.New("error, status code: 429, status: 429 Too Many Requests")
+	tooLong := errors.New("error, status code: 400, message: maximum context length exceeded")
+	unauthorized := errors.New("error, status code: 401, message: invalid api key")
+	policy := RetryPolicy{MaxRetries: 2, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+	tests := []struct {
+		name      string
+		primary   *fakeAssistant
+		fallback  *fakeAssistant
+		want      string
+		wantErr   string
+		wantCalls [2]int
+	}{
+		{
+			name:      "retries rate limits on the same model",
+			primary:   &fakeAssistant{errs: []error{rateLimited, rateLimited}, reply: "primary"},
+			fallback:  &fakeAssistant{reply: "fallback"},
+			want:      "primary",
+			wantCalls: [2]int{3, 0},
+		},
+		{
+			name:      "falls through after retries are exhausted",
+			primary:   &fakeAssistant{errs: []error{rateLimited, rateLimited, rateLimited}},
+			fallback:  &fakeAssistant{reply: "fallback"},
+			want:      "fallback",
+			wantCalls: [2]int{3, 1},
+		},
+		{
+			name:      "context length skips retries",
+			primary:   &fakeAssistant{errs: []error{tooLong}},
+			fallback:  &fakeAssistant{reply: "fallback"},
+			want:      "fallback",
+			wantCalls: [2]int{1, 1},
+		},
+		{
+			name:      "permanent errors stop the chain",
+			primary:   &fakeAssistant{errs: []error{unauthorized}},
+			fallback:  &fakeAssistant{reply: "fallback"},
+			wantErr:   "primary:m1: error, status code: 401",
+			wantCalls: [2]int{1, 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts []Attempt
+			chain := &Chain{
+				Links: []Link{
+					{Provider: "primary", Model: "m1", Client: tt.primary, Timeout: time.Second},
+					{Provider: "fallback", Model: "m2", Client: tt.fallback},
+				},
+				Policy:    policy,
+				OnAttempt: func(a Attempt) { attempts = append(attempts, a) },
+			}
+			got, err := chain.Send(context.Background(), "prompt")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Send() error = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil || got != tt.want {
+				t.Fatalf("Send() = %q, %v; want %q", got, err, tt.want)
+			}
+			if calls := [2]int{tt.primary.calls, tt.fallback.calls}; calls != tt.wantCalls {
+				t.Errorf("calls = %v, want %v", calls, tt.wantCalls)
+			}
+			if len(attempts) != tt.wantCalls[0]+tt.wantCalls[1] {
+				t.Errorf("logged %d attempts, want one per call", len(attempts))
+			}
+		})
+	}
+}
+
+// hangingAssistant 的流式回复一直等到 context 结束
+type hangingAssistant struct {
+	fakeAssistant
+}
+
+func (h *hangingAssistant) SendStream(ctx context.Context, _ string, _ ...string) *StreamReply {
+	h.calls++
+	reply := &StreamReply{Content: make(chan string)}
+	go func() {
+		defer close(reply.Content)
+		<-ctx.Done()
+		reply.Err = ctx.Err()
+	}()
+	return reply
+}
+
+func TestChainSendStream(t *testing.T) {
+	rateLimited := errors.New("error, status code: 429, status: 429 Too Many Requests")
+	tooLong := errors.New("error, status code: 400, message: maximum context length exceeded")
+	policy := RetryPolicy{MaxRetries: 2, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+	tests := []struct {
+		name      string
+		primary   Assistant
+		fallback  *fakeAssistant
+		want      string
+		wantErr   string
+		wantCalls [2]int
+	}{
+		{
+			name:      "retries before the first chunk",
+			primary:   &fakeAssistant{errs: []error{rateLimited}, reply: "primary"},
+			fallback:  &fakeAssistant{reply: "fallback"},
+			want:      "primary",
+			wantCalls: [2]int{2, 0},
+		},
+		{
+			name:      "falls back before the first chunk",
+			primary:   &fakeAssistant{errs: []error{tooLong}},
+			fallback:  &fakeAssistant{reply: "fallback"},
+			want:      "fallback",
+			wantCalls: [2]int{1, 1},
+		},
+		{
+			// 每次尝试单独计时, 超时后重试, 重试用尽后回退
+			name:      "times out each attempt",
+			primary:   &hangingAssistant{},
+			fallback:  &fakeAssistant{reply: "fallback"},
+			want:      "fallback",
+			wantCalls: [2]int{3, 1},
+		},
+		{
+			// 已经输出的内容无法撤回, 之后的错误直接返回
+			name:      "surfaces errors once output has started",
+			primary:   &streamAssistant{chunks: []string{"partial"}, err: errors.New("error, status code: 503, message: overloaded")},
+			fallback:  &fakeAssistant{reply: "fallback"},
+			want:      "partial",
+			wantErr:   "primary:m1: error, status code: 503",
+			wantCalls: [2]int{1, 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts []Attempt
+			chain := &Chain{
+				Links: []Link{
+					{Provider: "primary", Model: "m1", Client: tt.primary, Timeout: 20 * time.Millisecond},
+					{Provider: "fallback", Model: "m2", Client: tt.fallback},
+				},
+				Policy:    policy,
+				OnAttempt: func(a Attempt) { attempts = append(attempts, a) },
+			}
+			got, err := chain.SendStream(context.Background(), "prompt").Collect(func(string) {})
+			if got != tt.want {
+				t.Errorf("SendStream() content = %q, want %q", got, tt.want)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("SendStream() error = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("SendStream() error = %v", err)
+			}
+			var primaryCalls int
+			switch p := tt.primary.(type) {
+			case *fakeAssistant:
+				primaryCalls = p.calls
+			case *hangingAssistant:
+				primaryCalls = p.calls
+			case *streamAssistant:
+				primaryCalls = len(p.prompts)
+			}
+			if calls := [2]int{primaryCalls, tt.fallback.calls}; calls != tt.wantCalls {
+				t.Errorf("calls = %v, want %v", calls, tt.wantCalls)
+			}
+			if len(attempts) != tt.wantCalls[0]+tt.wantCalls[1] {
+				t.Errorf("logged %d attempts, want one per call", len(attempts))
+			}
+		})
+	}
+}
+
+func TestLimiterForIsShared(t *testing.T) {
+	if LimiterFor("unlimited", 0, 0) != nil {
+		t.Error("LimiterFor without requests_per_minute should not limit")
+	}
+	a, b := LimiterFor("shared-test", 60, 2), LimiterFor("shared-test", 600, 10)
+	if a != b || a.Burst() != 2 {
+		t.Errorf("LimiterFor returned %p (burst %d) and %p, want one shared limiter with the first config", a, a.Burst(), b)
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"errors"
 
 	"github.com/Skyenought/goprojectstarter/internal/llm/openai"
-	"github.com/sashabaranov/go-openai"
 )
 
 // https://api-docs.deepseek.com/
@@ -37,6 +36,8 @@ var (
 	WithInitialContextMessages = chatgpt.WithInitialContextMessages
 	WithEnableContext          = chatgpt.WithEnableContext
 	WithBaseURL                = chatgpt.WithBaseURL
+	WithHeaders                = chatgpt.WithHeaders
+	WithJSONMode               = chatgpt.WithJSONMode
 )
 
 // NewClient creates a new chat client.
@@ -45,7 +46,9 @@ func NewClient(apiKey string, opts ...ClientOption) (*Client, error) {
 		return nil, errors.New("API key cannot be empty")
 	}
 
-	c, err := chatgpt.NewClient(apiKey, opts...)
+	// the defaults go first so that options passed by the caller override them;
+	// DeepSeek supports the json_object response format but not json_schema
+	c, err := chatgpt.NewClient(apiKey, append([]ClientOption{WithBaseURL(BaseURL), WithJSONMode(chatgpt.JSONModeObject)}, opts...)...)
 	if err != nil {
 		return nil, err
 	}
@@ -54,9 +57,5 @@ func NewClient(apiKey string, opts ...ClientOption) (*Client, error) {
 		c.ModelName = DefaultModel
 	}
 
-	config := openai.DefaultConfig(apiKey)
-	config.BaseURL = BaseURL
-	c.Cli = openai.NewClientWithConfig(config)
-
 	return c, nil
 }
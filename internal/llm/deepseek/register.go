@@ -0,0 +1,17 @@
+package deepseek
+
+import (
+	"github.com/Skyenought/goprojectstarter/internal/llm"
+	"github.com/Skyenought/goprojectstarter/internal/llm/openai"
+)
+
+func init() {
+	llm.Register(llm.Provider{
+		Name:         "deepseek",
+		APIKeyEnv:    "DEEPSEEK_API_KEY",
+		DefaultModel: DefaultModel,
+		New: func(cfg llm.Config) (llm.Assistant, error) {
+			return NewClient(cfg.APIKey, chatgpt.OptionsFromConfig(cfg)...)
+		},
+	})
+}
@@ -26,29 +26,40 @@ type Client struct {
 	model *genai.GenerativeModel // 预先初始化的模型实例
 
 	// 配置项
+	apiKey         string
 	modelName      string
+	temperature    *float32
+	maxTokens      int32
 	enableContext  bool
 	contextHistory []*genai.Content // 存储对话历史，使用 genai 的格式
 }
 
-// NewClient 创建一个新的 Gemini LLM 客户端。
+// NewClient 创建一个新的 Gemini LLM 客户端。未通过 WithAPIKey 指定时从环境变量 GEMINI_API_KEY 读取 API Key。
 func NewClient(opts ...ClientOption) (*Client, error) {
-	apiKey := os.Getenv(geminiApiKeyEnvVar)
-	if apiKey == "" {
+	c := defaultClient()
+	c.apply(opts...)
+
+	if c.apiKey == "" {
+		c.apiKey = os.Getenv(geminiApiKeyEnvVar)
+	}
+	if c.apiKey == "" {
 		return nil, fmt.Errorf("环境变量 %s 必须被设置", geminiApiKeyEnvVar)
 	}
 
 	ctx := context.Background()
-	genaiClient, err := genai.NewClient(ctx, option.WithAPIKey(apiKey))
+	genaiClient, err := genai.NewClient(ctx, option.WithAPIKey(c.apiKey))
 	if err != nil {
 		return nil, fmt.Errorf("创建 genai 客户端失败: %w", err)
 	}
 
-	c := defaultClient()
-	c.apply(opts...)
-
 	c.cli = genaiClient
 	c.model = genaiClient.GenerativeModel(c.modelName)
+	if c.temperature != nil {
+		c.model.SetTemperature(*c.temperature)
+	}
+	if c.maxTokens > 0 {
+		c.model.SetMaxOutputTokens(c.maxTokens)
+	}
 
 	return c, nil
 }
@@ -84,6 +95,30 @@ func (c *Client) Send(ctx context.Context, prompt string, files ...string) (stri
 	return textContent, nil
 }
 
+// SendStructured 实现 Assistant 接口的 SendStructured 方法, 使用 Gemini 原生的 response_schema。
+// 每次调用复制一份模型设置, 不影响 Send 的纯文本输出。
+func (c *Client) SendStructured(ctx context.Context, prompt string, schema *llm.Schema) (string, error) {
+	if prompt == "" {
+		return "", errors.New("prompt cannot be empty")
+	}
+
+	model := *c.model
+	model.ResponseMIMEType = "application/json"
+	model.ResponseSchema = toGenaiSchema(schema)
+
+	resp, err := model.GenerateContent(ctx, genai.Text(prompt))
+	if err != nil {
+		return "", err
+	}
+	textContent := extractTextFromResponse(resp)
+	if textContent == "" {
+		return "", errors.New("LLM 返回了空的内容")
+	}
+
+	// 原生模式下回复通常已经合法, 这里仍然校验一次, 不合法时通过普通请求修复
+	return llm.RepairJSON(ctx, c.Send, textContent, schema)
+}
+
 // SendStream 实现 Assistant 接口的 SendStream 方法。
 func (c *Client) SendStream(ctx context.Context, prompt string, files ...string) *llm.StreamReply {
 	reply := &llm.StreamReply{Content: make(chan string)}
@@ -129,6 +164,41 @@ func (c *Client) SendStream(ctx context.Context, prompt string, files ...string)
 	return reply
 }
 
+// SendStructuredStream 实现 Assistant 接口的 SendStructuredStream 方法, 与 SendStructured 一样使用原生的 response_schema,
+// 返回的片段是未经校验的原始回复。
+func (c *Client) SendStructuredStream(ctx context.Context, prompt string, schema *llm.Schema) *llm.StreamReply {
+	reply := &llm.StreamReply{Content: make(chan string)}
+
+	go func() {
+		defer close(reply.Content)
+
+		model := *c.model
+		model.ResponseMIMEType = "application/json"
+		model.ResponseSchema = toGenaiSchema(schema)
+
+		iter := model.GenerateContentStream(ctx, genai.Text(prompt))
+		for {
+			resp, err := iter.Next()
+			if errors.Is(err, iterator.Done) {
+				return
+			}
+			if err != nil {
+				reply.Err = err
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				reply.Err = ctx.Err()
+				return
+			case reply.Content <- extractTextFromResponse(resp):
+			}
+		}
+	}()
+
+	return reply
+}
+
 // RefreshContext 实现 Assistant 接口的 RefreshContext 方法。
 func (c *Client) RefreshContext() {
 	c.contextHistory = nil
@@ -168,3 +238,32 @@ func extractTextFromResponse(resp *genai.GenerateContentResponse) string {
 	}
 	return builder.String()
 }
+
+// toGenaiSchema 把 llm.Schema 转换为 genai 的 Schema。genai 不支持 additionalProperties, 转换时忽略
+func toGenaiSchema(s *llm.Schema) *genai.Schema {
+	if s == nil {
+		return nil
+	}
+	gs := &genai.Schema{Description: s.Description, Required: s.Required, Items: toGenaiSchema(s.Items)}
+	switch s.Type {
+	case "object":
+		gs.Type = genai.TypeObject
+	case "array":
+		gs.Type = genai.TypeArray
+	case "integer":
+		gs.Type = genai.TypeInteger
+	case "number":
+		gs.Type = genai.TypeNumber
+	case "boolean":
+		gs.Type = genai.TypeBoolean
+	default:
+		gs.Type = genai.TypeString
+	}
+	if len(s.Properties) > 0 {
+		gs.Properties = make(map[string]*genai.Schema, len(s.Properties))
+		for name, prop := range s.Properties {
+			gs.Properties[name] = toGenaiSchema(prop)
+		}
+	}
+	return gs
+}
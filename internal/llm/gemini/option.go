@@ -33,6 +33,29 @@ func (c *Client) apply(opts ...ClientOption) {
 	}
 }
 
+// WithAPIKey 设置 API Key, 未设置时从环境变量 GEMINI_API_KEY 读取。
+func WithAPIKey(apiKey string) ClientOption {
+	return func(c *Client) {
+		c.apiKey = apiKey
+	}
+}
+
+// WithTemperature 设置生成的随机性。
+func WithTemperature(temperature float32) ClientOption {
+	return func(c *Client) {
+		c.temperature = &temperature
+	}
+}
+
+// WithMaxTokens 设置生成的最大 token 数量。
+func WithMaxTokens(maxTokens int) ClientOption {
+	return func(c *Client) {
+		if maxTokens > 0 {
+			c.maxTokens = int32(maxTokens)
+		}
+	}
+}
+
 // WithModel 设置要使用的模型名称。
 func WithModel(name string) ClientOption {
 	return func(c *Client) {
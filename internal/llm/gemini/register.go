@@ -0,0 +1,18 @@
+package gemini
+
+import "github.com/Skyenought/goprojectstarter/internal/llm"
+
+func init() {
+	llm.Register(llm.Provider{
+		Name:         "gemini",
+		APIKeyEnv:    geminiApiKeyEnvVar,
+		DefaultModel: DefaultModel,
+		New: func(cfg llm.Config) (llm.Assistant, error) {
+			opts := []ClientOption{WithAPIKey(cfg.APIKey), WithModel(cfg.Model), WithMaxTokens(cfg.MaxTokens)}
+			if cfg.Temperature != nil {
+				opts = append(opts, WithTemperature(*cfg.Temperature))
+			}
+			return NewClient(opts...)
+		},
+	})
+}
@@ -0,0 +1,192 @@
+// Package mock provides an offline LLM client that returns canned responses, for dry runs of the generators
+// and for end-to-end tests of the code injection pipeline without network access or API keys.
+package mock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/Skyenought/goprojectstarter/internal/llm"
+)
+
+const (
+	DefaultModel = "canned"
+	// DefaultReply is returned by Send when no canned text response is configured
+	DefaultReply = "mock response"
+	// sendFile is the file in the response directory used for Send and SendStream
+	sendFile = "send.txt"
+)
+
+var _ llm.Assistant = (*Client)(nil)
+
+// Client returns canned responses. A structured request for a schema named "api_code_snippets" is answered with
+// WithResponse("api_code_snippets", ...) if set, otherwise with <dir>/api_code_snippets.json, otherwise with
+// an object of zero values that matches the schema.
+type Client struct {
+	modelName string
+	dir       string
+	responses map[string]string
+
+	mu      sync.Mutex
+	prompts []string
+}
+
+// ClientOption configures a Client.
+type ClientOption func(*Client)
+
+// WithModel sets the model name reported by the client; it does not affect the responses.
+func WithModel(name string) ClientOption {
+	return func(c *Client) {
+		if name != "" {
+			c.modelName = name
+		}
+	}
+}
+
+// WithDir sets the directory holding canned responses: <schema name>.json for structured requests, send.txt for plain ones.
+func WithDir(dir string) ClientOption {
+	return func(c *Client) {
+		c.dir = dir
+	}
+}
+
+// WithResponse sets the canned response for a schema name, or for plain requests when name is empty.
+func WithResponse(name, response string) ClientOption {
+	return func(c *Client) {
+		c.responses[name] = response
+	}
+}
+
+// NewClient creates a mock client.
+func NewClient(opts ...ClientOption) (*Client, error) {
+	c := &Client{modelName: DefaultModel, responses: make(map[string]string)}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// Prompts returns the prompts received so far, in order.
+func (c *Client) Prompts() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.prompts...)
+}
+
+func (c *Client) record(prompt string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.prompts = append(c.prompts, prompt)
+}
+
+// canned looks up the response for name, reporting whether one was configured
+func (c *Client) canned(name, file string) (string, bool, error) {
+	if r, ok := c.responses[name]; ok {
+		return r, true, nil
+	}
+	if c.dir == "" {
+		return "", false, nil
+	}
+	data, err := os.ReadFile(filepath.Join(c.dir, file))
+	if os.IsNotExist(err) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return string(data), true, nil
+}
+
+// Send returns the canned plain response, or DefaultReply.
+func (c *Client) Send(ctx context.Context, prompt string, files ...string) (string, error) {
+	c.record(prompt)
+	reply, ok, err := c.canned("", sendFile)
+	if err != nil || !ok {
+		return DefaultReply, err
+	}
+	return reply, nil
+}
+
+// SendStructured returns the canned JSON for the schema. Like the real clients, a canned response that does not
+// match the schema is reported as *llm.StructuredError.
+func (c *Client) SendStructured(ctx context.Context, prompt string, schema *llm.Schema) (string, error) {
+	c.record(prompt)
+	reply, ok, err := c.canned(schema.Name, schema.Name+".json")
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		data, err := json.Marshal(zeroValue(schema))
+		return string(data), err
+	}
+	extracted, err := llm.ExtractJSON(reply)
+	if err == nil {
+		err = schema.Validate([]byte(extracted))
+	}
+	if err != nil {
+		return "", &llm.StructuredError{Response: reply, Err: fmt.Errorf("mock response for %s: %w", schema.Name, err)}
+	}
+	return extracted, nil
+}
+
+// SendStream returns the canned plain response as a single chunk.
+func (c *Client) SendStream(ctx context.Context, prompt string, files ...string) *llm.StreamReply {
+	text, err := c.Send(ctx, prompt, files...)
+	return single(text, err)
+}
+
+// SendStructuredStream answers like SendStructured for the schema, as a single chunk. A canned response that does
+// not match the schema is streamed as is, since llm.SendStructuredStream validates the assembled reply itself.
+func (c *Client) SendStructuredStream(ctx context.Context, prompt string, schema *llm.Schema) *llm.StreamReply {
+	text, err := c.SendStructured(ctx, prompt, schema)
+	var structErr *llm.StructuredError
+	if errors.As(err, &structErr) {
+		text, err = structErr.Response, nil
+	}
+	return single(text, err)
+}
+
+// single returns a closed stream holding text as its only chunk, or err
+func single(text string, err error) *llm.StreamReply {
+	reply := &llm.StreamReply{Content: make(chan string, 1)}
+	if err != nil {
+		reply.Err = err
+	} else {
+		reply.Content <- text
+	}
+	close(reply.Content)
+	return reply
+}
+
+// RefreshContext is a no-op: the mock client keeps no conversation history.
+func (c *Client) RefreshContext() {}
+
+// ListModelNames returns the configured model name.
+func (c *Client) ListModelNames(ctx context.Context) ([]string, error) {
+	return []string{c.modelName}, nil
+}
+
+// zeroValue builds a value of the schema's shape with every field at its zero value
+func zeroValue(s *llm.Schema) any {
+	switch s.Type {
+	case "object":
+		obj := make(map[string]any, len(s.Properties))
+		for name, prop := range s.Properties {
+			obj[name] = zeroValue(prop)
+		}
+		return obj
+	case "array":
+		return []any{}
+	case "integer", "number":
+		return 0
+	case "boolean":
+		return false
+	default:
+		return ""
+	}
+}
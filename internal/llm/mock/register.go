@@ -0,0 +1,14 @@
+package mock
+
+import "github.com/Skyenought/goprojectstarter/internal/llm"
+
+func init() {
+	// base_url is the directory holding canned responses, e.g. testdata/llm-mock
+	llm.Register(llm.Provider{
+		Name:         "mock",
+		DefaultModel: DefaultModel,
+		New: func(cfg llm.Config) (llm.Assistant, error) {
+			return NewClient(WithModel(cfg.Model), WithDir(cfg.BaseURL))
+		},
+	})
+}
@@ -0,0 +1,80 @@
+// Package ollama provides a client for models served locally by Ollama, through its OpenAI-compatible API.
+// Prompts never leave the machine (or the host set by OLLAMA_HOST), and no API key is needed.
+package ollama
+
+import (
+	"os"
+	"strings"
+
+	"github.com/Skyenought/goprojectstarter/internal/llm/openai"
+)
+
+// https://github.com/ollama/ollama/blob/main/docs/openai.md
+
+const (
+	DefaultHost = "http://localhost:11434"
+	hostEnvVar  = "OLLAMA_HOST"
+
+	ModelQwenCoder = "qwen2.5-coder"
+	ModelLlama     = "llama3.1"
+	DefaultModel   = ModelQwenCoder
+)
+
+type (
+	Client         = chatgpt.Client
+	ClientOption   = chatgpt.ClientOption
+	ContextMessage = chatgpt.ContextMessage
+)
+
+var (
+	WithMaxTokens              = chatgpt.WithMaxTokens
+	WithModel                  = chatgpt.WithModel
+	WithTemperature            = chatgpt.WithTemperature
+	WithInitialRole            = chatgpt.WithInitialRole
+	WithInitialContextMessages = chatgpt.WithInitialContextMessages
+	WithEnableContext          = chatgpt.WithEnableContext
+	WithHeaders                = chatgpt.WithHeaders
+	WithJSONMode               = chatgpt.WithJSONMode
+)
+
+// WithHost sets the Ollama server, e.g. "http://gpu-box:11434" or "gpu-box:11434".
+// An empty host keeps the default.
+func WithHost(host string) ClientOption {
+	if host == "" {
+		return func(*Client) {}
+	}
+	return chatgpt.WithBaseURL(apiURL(host))
+}
+
+// NewClient creates a new chat client for a local Ollama server.
+// The server defaults to $OLLAMA_HOST, or http://localhost:11434 when it is not set.
+func NewClient(opts ...ClientOption) (*Client, error) {
+	host := os.Getenv(hostEnvVar)
+	if host == "" {
+		host = DefaultHost
+	}
+
+	// Ollama's OpenAI-compatible API supports the json_object response format
+	c, err := chatgpt.NewClient("", append([]ClientOption{WithHost(host), WithJSONMode(chatgpt.JSONModeObject)}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.ModelName == chatgpt.DefaultModel {
+		c.ModelName = DefaultModel
+	}
+
+	return c, nil
+}
+
+// apiURL returns the OpenAI-compatible endpoint of an Ollama host
+func apiURL(host string) string {
+	if !strings.Contains(host, "://") {
+		host = "http://" + host
+	}
+	host = strings.TrimSuffix(host, "/")
+	if strings.HasSuffix(host, "/v1") {
+		return host
+	}
+	return host + "/v1"
+}
@@ -0,0 +1,17 @@
+package ollama
+
+import (
+	"github.com/Skyenought/goprojectstarter/internal/llm"
+	"github.com/Skyenought/goprojectstarter/internal/llm/openai"
+)
+
+func init() {
+	llm.Register(llm.Provider{
+		Name:         "ollama",
+		DefaultModel: DefaultModel,
+		New: func(cfg llm.Config) (llm.Assistant, error) {
+			// base_url is the Ollama host, WithHost appends the /v1 API path
+			return NewClient(append(chatgpt.OptionsFromConfig(cfg), WithHost(cfg.BaseURL))...)
+		},
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"path/filepath"
 
 	"github.com/Skyenought/goprojectstarter/internal/llm"
@@ -18,6 +19,7 @@ type Client struct {
 	maxTokens int
 	ModelName string
 	baseurl   string
+	headers   map[string]string
 	Cli       *openai.Client
 
 	/*
@@ -32,19 +34,22 @@ type Client struct {
 
 	roleDesc string // initial role description
 
+	jsonMode JSONMode // how SendStructured asks the server for JSON, default is JSONModeNone
+
 	enableContext   bool                           // whether to use assistant context, default is false
 	contextMessages []openai.ChatCompletionMessage // initial context messages
 }
 
-// NewClient creates a new chat client.
+// NewClient creates a new chat client. The API key may only be empty when a base URL is set,
+// since local OpenAI-compatible servers usually do not require one.
 func NewClient(apiKey string, opts ...ClientOption) (*Client, error) {
-	if apiKey == "" {
-		return nil, errors.New("API key cannot be empty")
-	}
-
 	c := defaultClientOptions()
 	c.apply(opts...)
 
+	if apiKey == "" && c.baseurl == "" {
+		return nil, errors.New("API key cannot be empty")
+	}
+
 	if c.ModelName == "" {
 		c.ModelName = DefaultModel
 	}
@@ -57,13 +62,79 @@ func NewClient(apiKey string, opts ...ClientOption) (*Client, error) {
 	}
 
 	c.apiKey = apiKey
-	c.Cli = openai.NewClient(apiKey)
+	config := openai.DefaultConfig(apiKey)
+	if c.baseurl != "" {
+		config.BaseURL = c.baseurl
+	}
+	if len(c.headers) > 0 {
+		config.HTTPClient = &headerDoer{headers: c.headers, next: http.DefaultClient}
+	}
+	c.Cli = openai.NewClientWithConfig(config)
 
 	return c, nil
 }
 
+// headerDoer adds fixed headers to every request, e.g. gateway or organization headers
+// required by OpenAI-compatible services.
+type headerDoer struct {
+	headers map[string]string
+	next    openai.HTTPDoer
+}
+
+func (d *headerDoer) Do(req *http.Request) (*http.Response, error) {
+	for k, v := range d.headers {
+		req.Header.Set(k, v)
+	}
+	return d.next.Do(req)
+}
+
 // Send sends a prompt to the chat gpt and returns the response.
 func (c *Client) Send(ctx context.Context, prompt string, files ...string) (string, error) {
+	return c.send(ctx, prompt, nil, files...)
+}
+
+// SendStructured asks for a JSON object matching schema and returns the validated JSON text.
+// Depending on the JSON mode it uses a strict json_schema response format, the json_object mode
+// plus schema instructions, or plain prompting; the reply is validated and repaired in every case.
+func (c *Client) SendStructured(ctx context.Context, prompt string, schema *llm.Schema) (string, error) {
+	if c.jsonMode != JSONModeSchema && c.jsonMode != JSONModeObject {
+		return llm.SendStructuredFallback(ctx, c.Send, prompt, schema)
+	}
+	prompt, format := c.structuredRequest(prompt, schema)
+	reply, err := c.send(ctx, prompt, format)
+	if err != nil {
+		return "", err
+	}
+	return llm.RepairJSON(ctx, c.Send, reply, schema)
+}
+
+// SendStructuredStream streams the raw reply of a structured request using the same JSON mode as SendStructured.
+// Without a native JSON mode the schema instructions are appended to the prompt instead.
+func (c *Client) SendStructuredStream(ctx context.Context, prompt string, schema *llm.Schema) *llm.StreamReply {
+	if c.jsonMode != JSONModeSchema && c.jsonMode != JSONModeObject {
+		return c.stream(ctx, prompt+llm.SchemaInstructions(schema), nil)
+	}
+	prompt, format := c.structuredRequest(prompt, schema)
+	return c.stream(ctx, prompt, format)
+}
+
+// structuredRequest returns the prompt and response format for the json_schema or json_object mode
+func (c *Client) structuredRequest(prompt string, schema *llm.Schema) (string, *openai.ChatCompletionResponseFormat) {
+	if c.jsonMode == JSONModeObject {
+		// json_object only guarantees valid JSON, the shape still has to be described in the prompt
+		return prompt + llm.SchemaInstructions(schema), &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject}
+	}
+	return prompt, &openai.ChatCompletionResponseFormat{
+		Type: openai.ChatCompletionResponseFormatTypeJSONSchema,
+		JSONSchema: &openai.ChatCompletionResponseFormatJSONSchema{
+			Name:   schema.Name,
+			Schema: schema,
+			Strict: true,
+		},
+	}
+}
+
+func (c *Client) send(ctx context.Context, prompt string, format *openai.ChatCompletionResponseFormat, files ...string) (string, error) {
 	if prompt == "" {
 		return "", errors.New("prompt cannot be empty")
 	}
@@ -81,6 +152,7 @@ func (c *Client) Send(ctx context.Context, prompt string, files ...string) (stri
 			Temperature:         c.temperature,
 			MaxCompletionTokens: c.maxTokens,
 			MaxTokens:           c.maxTokens, // Deprecated
+			ResponseFormat:      format,
 		},
 	)
 	if err != nil {
@@ -101,6 +173,10 @@ func (c *Client) Send(ctx context.Context, prompt string, files ...string) (stri
 
 // SendStream sends a prompt to the chat gpt and returns a channel of responses.
 func (c *Client) SendStream(ctx context.Context, prompt string, files ...string) *llm.StreamReply {
+	return c.stream(ctx, prompt, nil, files...)
+}
+
+func (c *Client) stream(ctx context.Context, prompt string, format *openai.ChatCompletionResponseFormat, files ...string) *llm.StreamReply {
 	response := &llm.StreamReply{Content: make(chan string), Err: error(nil)}
 
 	go func() {
@@ -119,6 +195,7 @@ func (c *Client) SendStream(ctx context.Context, prompt string, files ...string)
 			Temperature:         c.temperature,
 			MaxCompletionTokens: c.maxTokens,
 			MaxTokens:           c.maxTokens, // Deprecated
+			ResponseFormat:      format,
 		}
 		stream, err := c.Cli.CreateChatCompletionStream(ctx, req)
 		if err != nil {
@@ -2,9 +2,15 @@ package chatgpt
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
+
+	"github.com/Skyenought/goprojectstarter/internal/llm"
 )
 
 var apiKey = "sk-xxxxxx"
@@ -21,7 +27,8 @@ func TestClient_Send(t *testing.T) {
 		return
 	}
 
-	ctx, _ := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 	replyContent, err := client.Send(ctx, "Who are you?")
 	if err != nil {
 		t.Log(err)
@@ -43,10 +50,11 @@ func TestClient_SendStream(t *testing.T) {
 		return
 	}
 
-	ctx, _ := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 	reply := client.SendStream(ctx, "Which model did you use to answer the question?")
 	for content := range reply.Content {
-		fmt.Printf(content)
+		fmt.Print(content)
 	}
 	if reply.Err != nil {
 		t.Log(reply.Err)
@@ -61,7 +69,8 @@ func TestClient_ListModelNames(t *testing.T) {
 		return
 	}
 
-	ctx, _ := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 	modelNames, err := client.ListModelNames(ctx)
 	if err != nil {
 		t.Log(err)
@@ -69,3 +78,90 @@ func TestClient_ListModelNames(t *testing.T) {
 	}
 	t.Log(modelNames)
 }
+
+func TestNewClient_BaseURLAndHeaders(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("request path = %s, want /v1/chat/completions", r.URL.Path)
+		}
+		if got := r.Header.Get("X-Team"); got != "backend" {
+			t.Errorf("X-Team header = %q, want backend", got)
+		}
+		if got := r.Header.Get("Authorization"); got != "" {
+			t.Errorf("Authorization header = %q, want none without an API key", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"choices":[{"message":{"role":"assistant","content":"pong"}}]}`)
+	}))
+	defer srv.Close()
+
+	if _, err := NewClient(""); err == nil {
+		t.Error("NewClient accepted an empty API key without a base URL")
+	}
+	client, err := NewClient("", WithBaseURL(srv.URL+"/v1"), WithHeaders(map[string]string{"X-Team": "backend"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reply, err := client.Send(context.Background(), "ping")
+	if err != nil || reply != "pong" {
+		t.Fatalf("Send() = %q, %v", reply, err)
+	}
+}
+
+func TestClient_SendStructured(t *testing.T) {
+	type result struct {
+		Code string `json:"code"`
+	}
+	schema := llm.SchemaFor("result", result{})
+
+	tests := []struct {
+		name       string
+		mode       JSONMode
+		wantFormat string
+	}{
+		{name: "json schema", mode: JSONModeSchema, wantFormat: "json_schema"},
+		{name: "json object", mode: JSONModeObject, wantFormat: "json_object"},
+		{name: "no native mode", mode: JSONModeNone, wantFormat: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var req struct {
+					ResponseFormat *struct {
+						Type       string `json:"type"`
+						JSONSchema *struct {
+							Name   string          `json:"name"`
+							Strict bool            `json:"strict"`
+							Schema json.RawMessage `json:"schema"`
+						} `json:"json_schema"`
+					} `json:"response_format"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					t.Fatal(err)
+				}
+				gotFormat := ""
+				if req.ResponseFormat != nil {
+					gotFormat = req.ResponseFormat.Type
+				}
+				if gotFormat != tt.wantFormat {
+					t.Errorf("response_format.type = %q, want %q", gotFormat, tt.wantFormat)
+				}
+				if tt.mode == JSONModeSchema && (req.ResponseFormat.JSONSchema == nil || req.ResponseFormat.JSONSchema.Name != "result" || !req.ResponseFormat.JSONSchema.Strict) {
+					t.Errorf("json_schema = %+v, want strict schema named result", req.ResponseFormat.JSONSchema)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = io.WriteString(w, `{"choices":[{"message":{"role":"assistant","content":"Sure! {\"code\":\"ok\"}"}}]}`)
+			}))
+			defer srv.Close()
+
+			client, err := NewClient("", WithBaseURL(srv.URL+"/v1"), WithJSONMode(tt.mode))
+			if err != nil {
+				t.Fatal(err)
+			}
+			reply, err := client.SendStructured(context.Background(), "generate", schema)
+			if err != nil || reply != `{"code":"ok"}` {
+				t.Fatalf("SendStructured() = %q, %v", reply, err)
+			}
+		})
+	}
+}
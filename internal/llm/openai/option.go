@@ -15,6 +15,19 @@ const (
 	defaultMaxTokens = 8192
 )
 
+// JSONMode selects how SendStructured asks the server for JSON output.
+type JSONMode int
+
+const (
+	// JSONModeNone means the server has no native JSON mode: the schema is described in the prompt
+	// and the JSON object is extracted from the reply.
+	JSONModeNone JSONMode = iota
+	// JSONModeObject uses response_format json_object, e.g. DeepSeek and Ollama.
+	JSONModeObject
+	// JSONModeSchema uses response_format json_schema with strict validation, supported by OpenAI.
+	JSONModeSchema
+)
+
 // ClientOption is a function that sets a Client option.
 type ClientOption func(*Client)
 
@@ -70,6 +83,7 @@ func WithEnableContext() ClientOption {
 	}
 }
 
+// WithBaseURL sets the API base URL, e.g. "https://api.deepseek.com/" or "http://localhost:8080/v1"
 func WithBaseURL(url string) ClientOption {
 	return func(c *Client) {
 		if url != "" {
@@ -78,6 +92,28 @@ func WithBaseURL(url string) ClientOption {
 	}
 }
 
+// WithHeaders sets extra HTTP headers sent with every request
+func WithHeaders(headers map[string]string) ClientOption {
+	return func(c *Client) {
+		if len(headers) == 0 {
+			return
+		}
+		if c.headers == nil {
+			c.headers = make(map[string]string, len(headers))
+		}
+		for k, v := range headers {
+			c.headers[k] = v
+		}
+	}
+}
+
+// WithJSONMode sets how SendStructured asks the server for JSON output
+func WithJSONMode(mode JSONMode) ClientOption {
+	return func(c *Client) {
+		c.jsonMode = mode
+	}
+}
+
 // ContextMessage chat history message
 type ContextMessage struct {
 	Role    string `json:"role"` // system, user, assistant, etc.
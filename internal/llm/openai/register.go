@@ -0,0 +1,30 @@
+package chatgpt
+
+import "github.com/Skyenought/goprojectstarter/internal/llm"
+
+var _ llm.Assistant = (*Client)(nil)
+
+func init() {
+	llm.Register(llm.Provider{Name: "openai", APIKeyEnv: "OPENAI_API_KEY", DefaultModel: DefaultModel, New: func(cfg llm.Config) (llm.Assistant, error) {
+		return NewClient(cfg.APIKey, append(OptionsFromConfig(cfg), WithJSONMode(JSONModeSchema))...)
+	}})
+	// any service implementing the OpenAI Chat Completions API, e.g. llama.cpp server, vLLM or an internal gateway.
+	// Support for response_format varies between them, so structured output falls back to prompting.
+	llm.Register(llm.Provider{Name: "openai-compatible", RequiresBaseURL: true, New: newFromConfig})
+}
+
+// OptionsFromConfig converts a registry config into client options, leaving unset values at their defaults.
+func OptionsFromConfig(cfg llm.Config) []ClientOption {
+	opts := []ClientOption{WithModel(cfg.Model), WithBaseURL(cfg.BaseURL), WithHeaders(cfg.Headers)}
+	if cfg.Temperature != nil {
+		opts = append(opts, WithTemperature(*cfg.Temperature))
+	}
+	if cfg.MaxTokens > 0 {
+		opts = append(opts, WithMaxTokens(cfg.MaxTokens))
+	}
+	return opts
+}
+
+func newFromConfig(cfg llm.Config) (llm.Assistant, error) {
+	return NewClient(cfg.APIKey, OptionsFromConfig(cfg)...)
+}
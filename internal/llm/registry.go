@@ -0,0 +1,98 @@
+package llm
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Config 是创建客户端时使用的配置, 由 .goprojectstarter.yaml 中的提供商设置和 profile 合并而来
+type Config struct {
+	Model string
+	// APIKey 为空时, New 从 APIKeyEnv (为空时使用提供商默认的环境变量) 读取
+	APIKey    string
+	APIKeyEnv string
+	BaseURL   string
+	Headers   map[string]string
+	// Temperature 为空、MaxTokens 为 0 时使用客户端自己的默认值
+	Temperature *float32
+	MaxTokens   int
+	// Timeout 是单次请求的超时, 由调用方用于设置 context
+	Timeout time.Duration
+}
+
+// Factory 根据配置创建客户端, 调用时 cfg.APIKey 和 cfg.Model 已经解析完成
+type Factory func(cfg Config) (Assistant, error)
+
+// Provider 描述一个可以在配置中通过名称使用的 LLM 提供商
+type Provider struct {
+	Name string
+	// APIKeyEnv 是默认读取 API Key 的环境变量, 为空表示默认不需要 API Key (例如本地模型)
+	APIKeyEnv string
+	// RequiresBaseURL 表示必须在配置中指定 base_url
+	RequiresBaseURL bool
+	DefaultModel    string
+	New             Factory
+}
+
+var (
+	providersMu sync.RWMutex
+	providers   = make(map[string]Provider)
+)
+
+// Register 注册一个提供商, 通常在提供商包的 init 中调用。名称重复或缺少 Factory 时 panic
+func Register(p Provider) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	if p.New == nil {
+		panic("llm: Register provider " + p.Name + " without a factory")
+	}
+	if _, dup := providers[p.Name]; dup {
+		panic("llm: Register called twice for provider " + p.Name)
+	}
+	providers[p.Name] = p
+}
+
+// Providers 返回已注册的提供商名称, 按字母排序
+func Providers() []string {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New 使用名为 name 的提供商创建客户端: 补全默认模型, 按提供商声明的来源读取 API Key 并检查必填项
+func New(name string, cfg Config) (Assistant, error) {
+	providersMu.RLock()
+	p, ok := providers[name]
+	providersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("不支持的 LLM 提供商: %s (可用: %s)", name, strings.Join(Providers(), ", "))
+	}
+
+	if p.RequiresBaseURL && cfg.BaseURL == "" {
+		return nil, fmt.Errorf("使用 %s 时必须在 llm.providers.%s.base_url 中配置服务地址", name, name)
+	}
+	if cfg.APIKey == "" {
+		env := cfg.APIKeyEnv
+		if env == "" {
+			env = p.APIKeyEnv
+		}
+		if env != "" {
+			if cfg.APIKey = os.Getenv(env); cfg.APIKey == "" {
+				return nil, fmt.Errorf("环境变量 %s 未设置", env)
+			}
+		}
+	}
+	if cfg.Model == "" {
+		cfg.Model = p.DefaultModel
+	}
+	return p.New(cfg)
+}
@@ -0,0 +1,158 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// ErrorClass 是请求失败原因的分类, 决定是重试、切换到下一个提供商还是直接返回
+type ErrorClass int
+
+const (
+	// ErrorPermanent 包括鉴权失败、请求无效等, 重试和切换提供商都无法解决
+	ErrorPermanent ErrorClass = iota
+	// ErrorRateLimited 对应 HTTP 429
+	ErrorRateLimited
+	// ErrorServer 对应 HTTP 5xx
+	ErrorServer
+	// ErrorTimeout 是单次请求超时或网络超时
+	ErrorTimeout
+	// ErrorContextLength 表示 prompt 超出了模型的上下文长度, 重试同一个模型没有意义, 直接切换到下一个
+	ErrorContextLength
+)
+
+func (c ErrorClass) String() string {
+	switch c {
+	case ErrorRateLimited:
+		return "rate-limited"
+	case ErrorServer:
+		return "server-error"
+	case ErrorTimeout:
+		return "timeout"
+	case ErrorContextLength:
+		return "context-length"
+	default:
+		return "permanent"
+	}
+}
+
+// Retryable 表示是否值得在同一个提供商上退避重试
+func (c ErrorClass) Retryable() bool {
+	return c == ErrorRateLimited || c == ErrorServer || c == ErrorTimeout
+}
+
+// StatusError 是带有 HTTP 状态码的错误, 客户端可以用它包装 SDK 的错误以便分类
+type StatusError struct {
+	StatusCode int
+	Err        error
+}
+
+func (e *StatusError) Error() string { return e.Err.Error() }
+
+func (e *StatusError) Unwrap() error { return e.Err }
+
+// statusInMessage 匹配 go-openai ("status code: 429") 和 googleapi ("Error 429:") 错误信息中的状态码
+var statusInMessage = regexp.MustCompile(`(?:status code: |Error )(\d{3})\b`)
+
+var contextLengthHints = []string{"context_length_exceeded", "context length", "maximum context", "too many tokens", "input token count"}
+
+// Classify 对请求错误进行分类。各 SDK 的错误类型不同, 依次尝试 StatusError、
+// 带有 HTTPCode() 方法的错误 (Google API) 以及错误信息中的状态码。
+func Classify(err error) ErrorClass {
+	if err == nil {
+		return ErrorPermanent
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorTimeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return ErrorTimeout
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, hint := range contextLengthHints {
+		if strings.Contains(msg, hint) {
+			return ErrorContextLength
+		}
+	}
+
+	status := 0
+	var statusErr *StatusError
+	var httpCoder interface{ HTTPCode() int }
+	switch {
+	case errors.As(err, &statusErr):
+		status = statusErr.StatusCode
+	case errors.As(err, &httpCoder):
+		status = httpCoder.HTTPCode()
+	default:
+		if m := statusInMessage.FindStringSubmatch(err.Error()); m != nil {
+			status, _ = strconv.Atoi(m[1])
+		}
+	}
+	switch {
+	case status == 429:
+		return ErrorRateLimited
+	case status == 408:
+		return ErrorTimeout
+	case status >= 500:
+		return ErrorServer
+	default:
+		return ErrorPermanent
+	}
+}
+
+// RetryPolicy 是同一个提供商上的重试策略, 退避时间按指数增长并带有随机抖动
+type RetryPolicy struct {
+	MaxRetries     int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// DefaultRetryPolicy 在配置中没有 llm.retry 时使用
+var DefaultRetryPolicy = RetryPolicy{MaxRetries: 2, InitialBackoff: time.Second, MaxBackoff: 20 * time.Second}
+
+// Backoff 返回第 retry 次重试 (从 0 开始) 之前的等待时间: 在 [d/2, d] 之间随机, d = InitialBackoff * 2^retry, 不超过 MaxBackoff
+func (p RetryPolicy) Backoff(retry int) time.Duration {
+	d := p.InitialBackoff
+	for i := 0; i < retry && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+var (
+	limitersMu sync.Mutex
+	limiters   = make(map[string]*rate.Limiter)
+)
+
+// LimiterFor 返回提供商共享的令牌桶限流器, 同一进程中并发的生成共用一个桶。
+// requestsPerMinute 为 0 时不限流; 同一个提供商以第一次调用时的配置为准。
+func LimiterFor(provider string, requestsPerMinute, burst int) *rate.Limiter {
+	if requestsPerMinute <= 0 {
+		return nil
+	}
+	limitersMu.Lock()
+	defer limitersMu.Unlock()
+	if l, ok := limiters[provider]; ok {
+		return l
+	}
+	l := rate.NewLimiter(rate.Every(time.Minute/time.Duration(requestsPerMinute)), max(burst, 1))
+	limiters[provider] = l
+	return l
+}
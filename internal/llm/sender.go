@@ -1,14 +1,25 @@
 package llm
 
-import "context"
+import (
+	"context"
+	"strings"
+)
 
 type Assistant interface {
 	// Send 发送一个请求，并一次性返回完整的响应。
 	Send(ctx context.Context, prompt string, files ...string) (string, error)
 
+	// SendStructured 要求模型返回符合 schema 的 JSON 对象, 返回经过校验的 JSON 文本。
+	// 支持原生 JSON 模式的提供商使用 response_format / response_schema, 其余的退回到 SendStructuredFallback。
+	SendStructured(ctx context.Context, prompt string, schema *Schema) (string, error)
+
 	// SendStream 以流式方式发送请求，实时返回内容片段。
 	SendStream(ctx context.Context, prompt string, files ...string) *StreamReply
 
+	// SendStructuredStream 以流式方式发送结构化请求, 与 SendStructured 使用相同的原生 JSON 模式。
+	// 片段拼接后的回复还没有经过校验, 由 SendStructuredStream 函数负责提取、校验和修复。
+	SendStructuredStream(ctx context.Context, prompt string, schema *Schema) *StreamReply
+
 	// RefreshContext 清空当前客户端维护的对话上下文（历史记录）。
 	RefreshContext()
 
@@ -20,3 +31,16 @@ type StreamReply struct {
 	Content chan string
 	Err     error
 }
+
+// Collect 读取全部片段并返回拼接的回复, 每个片段同时交给 onChunk (可以为 nil)。
+// 出错时返回已经收到的部分内容和 Err
+func (r *StreamReply) Collect(onChunk func(string)) (string, error) {
+	var full strings.Builder
+	for chunk := range r.Content {
+		full.WriteString(chunk)
+		if onChunk != nil {
+			onChunk(chunk)
+		}
+	}
+	return full.String(), r.Err
+}
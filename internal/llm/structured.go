@@ -0,0 +1,235 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// maxJSONRepairs 是回复无法通过 schema 校验时, 发送修复 prompt 的最多次数
+const maxJSONRepairs = 2
+
+// Schema 是 JSON Schema 的一个子集, 足以描述生成器要求 LLM 返回的对象
+type Schema struct {
+	// Name 是 OpenAI json_schema 模式要求的名称, 不属于 schema 本身
+	Name                 string             `json:"-"`
+	Type                 string             `json:"type"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+}
+
+// MarshalJSON 让 *Schema 可以直接作为 go-openai 的 json.Marshaler 使用
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	type plain Schema
+	return json.Marshal((*plain)(s))
+}
+
+// SchemaFor 根据结构体的 json tag 推导 schema。所有字段都是必填的 (OpenAI 的 strict 模式要求如此),
+// 字段的 description tag 会作为说明传给模型。
+func SchemaFor(name string, v any) *Schema {
+	s := schemaOf(reflect.TypeOf(v))
+	s.Name = name
+	return s
+}
+
+func schemaOf(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		noExtra := false
+		s := &Schema{Type: "object", Properties: make(map[string]*Schema), AdditionalProperties: &noExtra}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if !f.IsExported() || name == "-" {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			prop := schemaOf(f.Type)
+			prop.Description = f.Tag.Get("description")
+			s.Properties[name] = prop
+			s.Required = append(s.Required, name)
+		}
+		return s
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	default:
+		return &Schema{Type: "string"}
+	}
+}
+
+// Validate 检查 JSON 文本是否符合 schema: 类型一致且必填字段齐全。多余的字段不视为错误
+func (s *Schema) Validate(data []byte) error {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return fmt.Errorf("不是合法的 JSON: %w", err)
+	}
+	var problems []string
+	s.validate("$", v, &problems)
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func (s *Schema) validate(path string, v any, problems *[]string) {
+	switch s.Type {
+	case "object":
+		obj, ok := v.(map[string]any)
+		if !ok {
+			*problems = append(*problems, fmt.Sprintf("%s 应为 object", path))
+			return
+		}
+		for _, name := range s.Required {
+			if _, ok := obj[name]; !ok {
+				*problems = append(*problems, fmt.Sprintf("缺少必填字段 %s.%s", path, name))
+			}
+		}
+		for name, prop := range s.Properties {
+			if value, ok := obj[name]; ok {
+				prop.validate(path+"."+name, value, problems)
+			}
+		}
+	case "array":
+		arr, ok := v.([]any)
+		if !ok {
+			*problems = append(*problems, fmt.Sprintf("%s 应为 array", path))
+			return
+		}
+		if s.Items != nil {
+			for i, item := range arr {
+				s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, problems)
+			}
+		}
+	case "string":
+		if _, ok := v.(string); !ok {
+			*problems = append(*problems, fmt.Sprintf("%s 应为 string", path))
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			*problems = append(*problems, fmt.Sprintf("%s 应为 boolean", path))
+		}
+	case "integer", "number":
+		n, ok := v.(float64)
+		if !ok || (s.Type == "integer" && n != float64(int64(n))) {
+			*problems = append(*problems, fmt.Sprintf("%s 应为 %s", path, s.Type))
+		}
+	}
+}
+
+// ExtractJSON 从模型的回复中宽松地提取第一个完整的 JSON 对象,
+// 可以处理 ```json 代码块以及对象前后的解释性文字。
+func ExtractJSON(text string) (string, error) {
+	start := strings.IndexByte(text, '{')
+	if start == -1 {
+		return "", errors.New("回复中没有 JSON 对象")
+	}
+	depth, inString, escaped := 0, false, false
+	for i := start; i < len(text); i++ {
+		ch := text[i]
+		switch {
+		case escaped:
+			escaped = false
+		case inString && ch == '\\':
+			escaped = true
+		case ch == '"':
+			inString = !inString
+		case inString:
+		case ch == '{':
+			depth++
+		case ch == '}':
+			depth--
+			if depth == 0 {
+				return text[start : i+1], nil
+			}
+		}
+	}
+	return "", errors.New("回复中的 JSON 对象不完整")
+}
+
+// SendFunc 是发送一次普通文本请求的函数, 与 Assistant.Send 的签名相同
+type SendFunc func(ctx context.Context, prompt string, files ...string) (string, error)
+
+// StructuredError 表示修复之后模型的回复仍然不符合 schema
+type StructuredError struct {
+	Response string // 最后一次的原始回复
+	Err      error
+}
+
+func (e *StructuredError) Error() string {
+	return fmt.Sprintf("LLM 的回复不符合 JSON schema: %v", e.Err)
+}
+
+func (e *StructuredError) Unwrap() error { return e.Err }
+
+// SendStructuredFallback 供不支持原生 JSON 模式的客户端实现 SendStructured:
+// 在 prompt 后附加 schema 说明, 然后通过 RepairJSON 提取并校验回复。
+func SendStructuredFallback(ctx context.Context, send SendFunc, prompt string, schema *Schema) (string, error) {
+	response, err := send(ctx, prompt+SchemaInstructions(schema))
+	if err != nil {
+		return "", err
+	}
+	return RepairJSON(ctx, send, response, schema)
+}
+
+// SchemaInstructions 返回附加在 prompt 之后、要求模型按 schema 输出的说明
+func SchemaInstructions(schema *Schema) string {
+	schemaJSON, _ := json.MarshalIndent(schema, "", "  ")
+	return "\n\n## JSON SCHEMA: " + schema.Name + "\n你的回答必须是一个符合以下 JSON Schema 的 JSON 对象, 不要使用 ```json 代码块包裹, 也不要附加任何解释:\n" + string(schemaJSON) + "\n"
+}
+
+// SendStructuredStream 以流式方式完成结构化请求: 通过 a.SendStructuredStream 使用提供商原生的 JSON 模式,
+// 收到的片段依次交给 onChunk, 流结束后对拼接的回复执行与 SendStructured 相同的提取、校验和修复。
+// 重试和回退由回退链在输出开始之前完成, 这里直接返回流的错误
+func SendStructuredStream(ctx context.Context, a Assistant, prompt string, schema *Schema, onChunk func(string)) (string, error) {
+	response, err := a.SendStructuredStream(ctx, prompt, schema).Collect(onChunk)
+	if err != nil {
+		return "", err
+	}
+	return RepairJSON(ctx, a.Send, response, schema)
+}
+
+// RepairJSON 从回复中提取 JSON 并按 schema 校验。校验失败时把错误和原回复发给模型要求修正,
+// 最多 maxJSONRepairs 次, 仍然失败时返回 *StructuredError。
+func RepairJSON(ctx context.Context, send SendFunc, response string, schema *Schema) (string, error) {
+	for attempt := 0; ; attempt++ {
+		extracted, err := ExtractJSON(response)
+		if err == nil {
+			err = schema.Validate([]byte(extracted))
+		}
+		if err == nil {
+			return extracted, nil
+		}
+		if attempt == maxJSONRepairs {
+			return "", &StructuredError{Response: response, Err: err}
+		}
+		response, err = send(ctx, repairPrompt(schema, response, err))
+		if err != nil {
+			return "", err
+		}
+	}
+}
+
+func repairPrompt(schema *Schema, response string, problem error) string {
+	return fmt.Sprintf("下面这段回复本应是一个 JSON 对象, 但无法通过校验: %v\n\n## 原始回复\n%s\n\n请修正其中的格式问题, 缺失的字段填入空值, 不要改动其余内容。%s",
+		problem, response, SchemaInstructions(schema))
+}
@@ -0,0 +1,153 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type snippets struct {
+	Handler string   `json:"handler" description:"handler code"`
+	Lines   []string `json:"lines"`
+	Retries int      `json:"retries"`
+	skipped string
+}
+
+func TestSchemaFor(t *testing.T) {
+	s := SchemaFor("snippets", snippets{})
+	if s.Name != "snippets" || s.Type != "object" {
+		t.Fatalf("SchemaFor() = %+v", s)
+	}
+	if got := strings.Join(s.Required, ","); got != "handler,lines,retries" {
+		t.Errorf("Required = %s, want handler,lines,retries", got)
+	}
+	if s.Properties["handler"].Description != "handler code" || s.Properties["lines"].Items.Type != "string" {
+		t.Errorf("Properties = %+v", s.Properties)
+	}
+	if s.AdditionalProperties == nil || *s.AdditionalProperties {
+		t.Error("object schemas must set additionalProperties to false for strict mode")
+	}
+}
+
+func TestExtractJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    string
+		wantErr bool
+	}{
+		{name: "plain", text: `{"a":"b"}`, want: `{"a":"b"}`},
+		{name: "fenced", text: "```json\n{\"a\":\"b\"}\n```", want: `{"a":"b"}`},
+		{name: "surrounding prose", text: "Here you go:\n{\"a\":{\"b\":1}} hope it helps", want: `{"a":{"b":1}}`},
+		{name: "braces in strings", text: `{"code":"func f() { return \"}\" }"}`, want: `{"code":"func f() { return \"}\" }"}`},
+		{name: "no object", text: "sorry", wantErr: true},
+		{name: "truncated", text: `{"a":"b"`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExtractJSON(tt.text)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Fatalf("ExtractJSON() = %q, %v; want %q, error %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSchemaValidate(t *testing.T) {
+	s := SchemaFor("snippets", snippets{})
+	if err := s.Validate([]byte(`{"handler":"x","lines":["a"],"retries":2,"extra":true}`)); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	err := s.Validate([]byte(`{"handler":1,"lines":["a",2]}`))
+	if err == nil {
+		t.Fatal("Validate() accepted an invalid object")
+	}
+	for _, want := range []string{"$.handler 应为 string", "$.lines[1] 应为 string", "缺少必填字段 $.retries"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() error = %v, want it to mention %q", err, want)
+		}
+	}
+}
+
+func TestSendStructuredFallback(t *testing.T) {
+	s := SchemaFor("snippets", snippets{})
+	replies := []string{"```json\n{\"handler\":\"x\"}\n```", `{"handler":"x","lines":[],"retries":0}`}
+	var prompts []string
+	send := func(_ context.Context, prompt string, _ ...string) (string, error) {
+		prompts = append(prompts, prompt)
+		reply := replies[0]
+		replies = replies[1:]
+		return reply, nil
+	}
+
+	got, err := SendStructuredFallback(context.Background(), send, "generate", s)
+	if err != nil {
+		t.Fatalf("SendStructuredFallback() error = %v", err)
+	}
+	if got != `{"handler":"x","lines":[],"retries":0}` {
+		t.Errorf("SendStructuredFallback() = %s", got)
+	}
+	if len(prompts) != 2 || !strings.Contains(prompts[0], "JSON SCHEMA") || !strings.Contains(prompts[1], "缺少必填字段 $.lines") {
+		t.Errorf("prompts = %q, want the schema in the first prompt and the problem in the repair prompt", prompts)
+	}
+
+	send = func(context.Context, string, ...string) (string, error) { return "no json here", nil }
+	_, err = SendStructuredFallback(context.Background(), send, "generate", s)
+	var structErr *StructuredError
+	if !errors.As(err, &structErr) || structErr.Response != "no json here" {
+		t.Fatalf("SendStructuredFallback() error = %v, want *StructuredError with the last response", err)
+	}
+}
+
+// streamAssistant 把 chunks 作为流式回复返回, 在输出 chunks 之后以 err 结束; 非流式请求交给 fakeAssistant
+type streamAssistant struct {
+	fakeAssistant
+	chunks  []string
+	err     error
+	prompts []string
+}
+
+func (s *streamAssistant) SendStream(_ context.Context, prompt string, _ ...string) *StreamReply {
+	s.prompts = append(s.prompts, prompt)
+	reply := &StreamReply{Content: make(chan string, len(s.chunks)), Err: s.err}
+	for _, c := range s.chunks {
+		reply.Content <- c
+	}
+	close(reply.Content)
+	return reply
+}
+
+func (s *streamAssistant) SendStructuredStream(ctx context.Context, prompt string, _ *Schema) *StreamReply {
+	return s.SendStream(ctx, prompt)
+}
+
+func TestSendStructuredStream(t *testing.T) {
+	ctx := context.Background()
+	s := SchemaFor("snippets", snippets{})
+	a := &streamAssistant{chunks: []string{"```json\n{\"handler\":", "\"x\",\"lines\":[],", "\"retries\":1}\n```"}}
+	var received []string
+	got, err := SendStructuredStream(ctx, a, "generate", s, func(chunk string) { received = append(received, chunk) })
+	if err != nil || got != `{"handler":"x","lines":[],"retries":1}` {
+		t.Fatalf("SendStructuredStream() = %q, %v", got, err)
+	}
+	if len(received) != 3 {
+		t.Errorf("onChunk received %d chunks, want 3", len(received))
+	}
+	// schema 由客户端的原生 JSON 模式处理, 不再附加到 prompt 中
+	if len(a.prompts) != 1 || a.prompts[0] != "generate" {
+		t.Errorf("prompts = %q, want the prompt passed through unchanged", a.prompts)
+	}
+
+	// 不完整的回复通过普通请求修复
+	a = &streamAssistant{chunks: []string{`{"handler":"y"}`}, fakeAssistant: fakeAssistant{reply: `{"handler":"y","lines":[],"retries":0}`}}
+	if got, err := SendStructuredStream(ctx, a, "generate", s, func(string) {}); err != nil || a.calls != 1 || !strings.Contains(got, `"retries":0`) {
+		t.Fatalf("SendStructuredStream() with an invalid reply = %q, %v, calls = %d, want a repair request", got, err, a.calls)
+	}
+
+	// 流的错误直接返回, 重试和回退由回退链负责
+	a = &streamAssistant{chunks: []string{`{"handler":`}, err: errors.New("connection reset")}
+	if _, err := SendStructuredStream(ctx, a, "generate", s, func(string) {}); err == nil || a.calls != 0 {
+		t.Fatalf("SendStructuredStream() after a stream error = %v, calls = %d, want the stream error", err, a.calls)
+	}
+}
@@ -0,0 +1,68 @@
+package llm
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultContextWindow 是未知模型的上下文长度 (tokens), 取常见本地模型的保守值
+const DefaultContextWindow = 32_000
+
+// modelFamily 描述一类模型的上下文长度和分词器的大致密度
+type modelFamily struct {
+	prefix        string
+	contextWindow int
+	// charsPerToken 是 ASCII 文本 (代码、英文) 平均每个 token 的字符数
+	charsPerToken float64
+}
+
+// modelFamilies 按前缀匹配模型名, 更具体的前缀必须排在前面
+var modelFamilies = []modelFamily{
+	{prefix: "gpt-4o", contextWindow: 128_000, charsPerToken: 4.0},
+	{prefix: "gpt-4-turbo", contextWindow: 128_000, charsPerToken: 3.7},
+	{prefix: "gpt-4", contextWindow: 8_192, charsPerToken: 3.7},
+	{prefix: "gpt-3.5", contextWindow: 16_385, charsPerToken: 3.7},
+	{prefix: "o1", contextWindow: 128_000, charsPerToken: 4.0},
+	{prefix: "deepseek", contextWindow: 64_000, charsPerToken: 3.5},
+	{prefix: "gemini-1.5", contextWindow: 1_000_000, charsPerToken: 4.0},
+	{prefix: "gemini", contextWindow: 32_000, charsPerToken: 4.0},
+	{prefix: "doubao", contextWindow: 32_000, charsPerToken: 3.5},
+	{prefix: "qwen", contextWindow: 32_000, charsPerToken: 3.3},
+	{prefix: "llama3", contextWindow: 128_000, charsPerToken: 3.5},
+}
+
+var defaultFamily = modelFamily{contextWindow: DefaultContextWindow, charsPerToken: 3.5}
+
+func familyOf(model string) modelFamily {
+	model = strings.ToLower(model)
+	for _, f := range modelFamilies {
+		if strings.HasPrefix(model, f.prefix) {
+			return f
+		}
+	}
+	return defaultFamily
+}
+
+// ContextWindow 返回模型的上下文长度 (tokens), 未知模型返回 DefaultContextWindow
+func ContextWindow(model string) int {
+	return familyOf(model).contextWindow
+}
+
+// EstimateTokens 估算文本在模型上的 token 数。没有引入各家的分词器,
+// ASCII 字符按模型的平均密度计算, 中日韩字符按每个字符一个 token 计算, 结果偏保守。
+func EstimateTokens(model, text string) int {
+	ascii, wide := 0, 0
+	for _, r := range text {
+		switch {
+		case r < utf8.RuneSelf:
+			ascii++
+		case unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r):
+			wide++
+		default:
+			// 其他非 ASCII 字符 (emoji、全角标点等) 通常被拆成多个 token
+			wide += 2
+		}
+	}
+	return int(float64(ascii)/familyOf(model).charsPerToken+0.999) + wide
+}
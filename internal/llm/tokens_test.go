@@ -0,0 +1,16 @@
+package llm
+
+import "testing"
+
+func TestEstimateTokens(t *testing.T) {
+	code := "func (h *SongHandler) Create(ctx fiber.Ctx) error { return nil }"
+	if got, other := EstimateTokens("gpt-4o", code), EstimateTokens("qwen2.5-coder:7b", code); got >= other || got < len(code)/5 {
+		t.Errorf("EstimateTokens(gpt-4o) = %d, EstimateTokens(qwen) = %d; want a denser tokenizer for gpt-4o", got, other)
+	}
+	if got := EstimateTokens("deepseek-chat", "创建歌曲"); got != 4 {
+		t.Errorf("EstimateTokens(CJK) = %d, want one token per character", got)
+	}
+	if ContextWindow("deepseek-chat") != 64_000 || ContextWindow("unknown-model") != DefaultContextWindow {
+		t.Error("ContextWindow() does not match the model table")
+	}
+}
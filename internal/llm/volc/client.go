@@ -21,6 +21,8 @@ var _ llm.Assistant = (*Client)(nil)
 type Client struct {
 	cli *openai.Client
 
+	apiKey          string
+	baseURL         string
 	modelName       string
 	temperature     float32
 	maxTokens       int
@@ -28,20 +30,23 @@ type Client struct {
 	contextMessages []openai.ChatCompletionMessage
 }
 
-// NewClient 创建一个新的火山方舟 LLM 客户端。
+// NewClient 创建一个新的火山方舟 LLM 客户端。未通过 WithAPIKey 指定时从环境变量 ARK_API_KEY 读取 API Key。
 func NewClient(opts ...ClientOption) (*Client, error) {
-	apiKey := os.Getenv(arkApiKeyEnvVar)
-	if apiKey == "" {
-		return nil, fmt.Errorf("环境变量 %s 必须被设置", arkApiKeyEnvVar)
-	}
-
 	// 1. 初始化默认配置
 	c := defaultClient()
 	c.apply(opts...)
 
+	// 2. 确定 API Key
+	if c.apiKey == "" {
+		c.apiKey = os.Getenv(arkApiKeyEnvVar)
+	}
+	if c.apiKey == "" {
+		return nil, fmt.Errorf("环境变量 %s 必须被设置", arkApiKeyEnvVar)
+	}
+
 	// 3. 创建针对火山方舟的特定配置
-	config := openai.DefaultConfig(apiKey)
-	config.BaseURL = defaultBaseURL
+	config := openai.DefaultConfig(c.apiKey)
+	config.BaseURL = c.baseURL
 
 	// 4. 初始化底层 HTTP 客户端
 	c.cli = openai.NewClientWithConfig(config)
@@ -78,6 +83,16 @@ func (c *Client) Send(ctx context.Context, prompt string, files ...string) (stri
 	return replyContent, nil
 }
 
+// SendStructured 实现 Assistant 接口的 SendStructured 方法。方舟上的模型不一定支持 response_format, 因此通过 prompt 约束并校验回复。
+func (c *Client) SendStructured(ctx context.Context, prompt string, schema *llm.Schema) (string, error) {
+	return llm.SendStructuredFallback(ctx, c.Send, prompt, schema)
+}
+
+// SendStructuredStream 实现 Assistant 接口的 SendStructuredStream 方法。与 SendStructured 一样通过 prompt 约束回复的格式
+func (c *Client) SendStructuredStream(ctx context.Context, prompt string, schema *llm.Schema) *llm.StreamReply {
+	return c.SendStream(ctx, prompt+llm.SchemaInstructions(schema))
+}
+
 func (c *Client) SendStream(ctx context.Context, prompt string, files ...string) *llm.StreamReply {
 	reply := &llm.StreamReply{Content: make(chan string)}
 
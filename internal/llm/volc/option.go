@@ -17,6 +17,7 @@ type ClientOption func(*Client)
 // defaultClient 返回一个带有默认配置的客户端实例。
 func defaultClient() *Client {
 	return &Client{
+		baseURL:     defaultBaseURL,
 		modelName:   DefaultModel,
 		temperature: 0.7, // 为代码修复设置一个较合理的默认值
 		maxTokens:   defaultMaxTokens,
@@ -30,6 +31,22 @@ func (c *Client) apply(opts ...ClientOption) {
 	}
 }
 
+// WithAPIKey 设置 API Key, 未设置时从环境变量 ARK_API_KEY 读取。
+func WithAPIKey(apiKey string) ClientOption {
+	return func(c *Client) {
+		c.apiKey = apiKey
+	}
+}
+
+// WithBaseURL 覆盖默认的火山方舟接入地址。
+func WithBaseURL(url string) ClientOption {
+	return func(c *Client) {
+		if url != "" {
+			c.baseURL = url
+		}
+	}
+}
+
 // WithModel 设置要使用的模型名称。
 func WithModel(name string) ClientOption {
 	return func(c *Client) {
@@ -0,0 +1,18 @@
+package volc
+
+import "github.com/Skyenought/goprojectstarter/internal/llm"
+
+func init() {
+	llm.Register(llm.Provider{
+		Name:         "volc",
+		APIKeyEnv:    arkApiKeyEnvVar,
+		DefaultModel: DefaultModel,
+		New: func(cfg llm.Config) (llm.Assistant, error) {
+			opts := []ClientOption{WithAPIKey(cfg.APIKey), WithBaseURL(cfg.BaseURL), WithModel(cfg.Model), WithMaxTokens(cfg.MaxTokens)}
+			if cfg.Temperature != nil {
+				opts = append(opts, WithTemperature(*cfg.Temperature))
+			}
+			return NewClient(opts...)
+		},
+	})
+}
@@ -0,0 +1,71 @@
+// Package audit 为生成的代码提供审计能力:
+// Actor 从 jwt 中间件解析出的 claims 中读取当前用户, 用于填充 CreatedBy/UpdatedBy;
+// Track 为实体注册 GORM 回调, 把每次创建、更新和删除前后的字段值记录到 audit_logs 表。
+package audit
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+)
+
+// ClaimsKey 是 jwt 中间件存放已解码 claims 的 c.Locals 键 (jwt.Config.ContextKey 的默认值)
+const ClaimsKey = "user"
+
+// ID 是 CreatedBy/UpdatedBy 字段支持的类型
+type ID interface {
+	~string | ~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64
+}
+
+// subjectClaims 由 jwt.MapClaims 以及嵌入 jwt.RegisteredClaims 的自定义 claims 实现
+type subjectClaims interface {
+	GetSubject() (string, error)
+}
+
+type actorKey struct{}
+
+// WithActor 返回携带操作者的 context, 用于后台任务等没有 JWT 的调用
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// Actor 返回 ctx 中的操作者: 优先使用 WithActor 设置的值, 其次使用 JWT claims 的 sub。
+// 处理器把 fiber.Ctx 作为 context 传给服务时, 可以直接读取 jwt 中间件写入 c.Locals 的 claims
+func Actor(ctx context.Context) (string, bool) {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor, true
+	}
+	claims, ok := ctx.Value(ClaimsKey).(subjectClaims)
+	if !ok {
+		return "", false
+	}
+	sub, err := claims.GetSubject()
+	return sub, err == nil && sub != ""
+}
+
+// ActorAs 返回转换为 CreatedBy/UpdatedBy 字段类型的操作者, 没有操作者或无法转换时返回 false
+func ActorAs[T ID](ctx context.Context) (T, bool) {
+	var id T
+	raw, ok := Actor(ctx)
+	if !ok {
+		return id, false
+	}
+	v := reflect.ValueOf(&id).Elem()
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, v.Type().Bits())
+		if err != nil {
+			return id, false
+		}
+		v.SetInt(n)
+	default:
+		n, err := strconv.ParseUint(raw, 10, v.Type().Bits())
+		if err != nil {
+			return id, false
+		}
+		v.SetUint(n)
+	}
+	return id, true
+}
@@ -0,0 +1,85 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type note struct {
+	ID    uint
+	Title string
+	Owner uint
+}
+
+type draft struct {
+	ID    uint
+	Title string
+}
+
+type claims map[string]any
+
+func (c claims) GetSubject() (string, error) {
+	sub, _ := c["sub"].(string)
+	return sub, nil
+}
+
+func TestTrack(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&note{}, &draft{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Track(db, &note{}); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.WithValue(context.Background(), ClaimsKey, claims{"sub": "42"})
+	tx := db.WithContext(ctx)
+
+	n := note{Title: "a", Owner: 1}
+	tx.Create(&n)
+	tx.Model(&n).Update("title", "b")
+	tx.Model(&note{}).Where("owner = ?", 1).Update("owner", 1) // 值未变化, 不记录
+	tx.Delete(&note{}, n.ID)
+	tx.Create(&draft{Title: "untracked"})
+
+	logs, err := History(db, "notes", n.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var actions []string
+	for _, l := range logs {
+		if l.Actor != "42" {
+			t.Errorf("%s logged actor %q, want 42", l.Action, l.Actor)
+		}
+		actions = append(actions, l.Action)
+	}
+	if len(actions) != 3 || actions[0] != ActionCreate || actions[1] != ActionUpdate || actions[2] != ActionDelete {
+		t.Fatalf("actions = %v, want [create update delete]", actions)
+	}
+	var changes map[string]Change
+	if err := json.Unmarshal(logs[1].Changes, &changes); err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes["title"].Before != "a" || changes["title"].After != "b" {
+		t.Errorf("update changes = %+v, want only title a -> b", changes)
+	}
+	var total int64
+	db.Model(&Log{}).Count(&total)
+	if total != 3 {
+		t.Errorf("audit_logs has %d rows, want 3 (untracked tables are ignored)", total)
+	}
+
+	if id, ok := ActorAs[uint](ctx); !ok || id != 42 {
+		t.Errorf("ActorAs[uint] = %d, %v", id, ok)
+	}
+	if _, ok := ActorAs[uint](WithActor(ctx, "admin")); ok {
+		t.Error("ActorAs accepted a non-numeric actor for a uint field")
+	}
+}
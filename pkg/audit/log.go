@@ -0,0 +1,51 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 审计记录的操作类型
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+// Log 是 audit_logs 表中的一条变更记录
+type Log struct {
+	ID       uint64 `gorm:"primaryKey" json:"id"`
+	Table    string `gorm:"column:table_name;size:64;index:idx_audit_logs_record" json:"table"`
+	RecordID string `gorm:"size:64;index:idx_audit_logs_record" json:"recordId"`
+	Action   string `gorm:"size:16" json:"action"`
+	Actor    string `gorm:"size:128" json:"actor"`
+	// Changes 是以列名为键的 Change, 创建时只有 after, 删除时只有 before
+	Changes   json.RawMessage `json:"changes"`
+	CreatedAt time.Time       `json:"createdAt"`
+}
+
+// TableName 指定审计记录的表名
+func (Log) TableName() string {
+	return "audit_logs"
+}
+
+// Change 是单个字段变更前后的值
+type Change struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// Migrate 创建或更新 audit_logs 表
+func Migrate(db *gorm.DB) error {
+	return db.AutoMigrate(&Log{})
+}
+
+// History 按时间顺序返回表中某条记录的全部变更
+func History(db *gorm.DB, table string, id any) ([]Log, error) {
+	var logs []Log
+	err := db.Where("table_name = ? AND record_id = ?", table, fmt.Sprint(id)).Order("id").Find(&logs).Error
+	return logs, err
+}
@@ -0,0 +1,264 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	pluginName = "audit"
+	// snapshotKey 是更新或删除前的快照在语句实例中的键
+	snapshotKey = "audit:before"
+)
+
+// plugin 通过 GORM 回调记录已注册表的变更。更新和删除前按语句的条件读取受影响的记录,
+// 之后重新读取这些记录并比较, 因此记录的是数据库中实际的前后值; 变更记录与数据写入在同一个事务中
+type plugin struct {
+	mu     sync.RWMutex
+	tables map[string]bool
+}
+
+// snapshot 是一条记录在某一时刻的列值
+type snapshot struct {
+	pk     any
+	id     string
+	values map[string]any
+}
+
+// Track 为 models 对应的表开启变更记录。首次调用时在 db 上注册回调并创建 audit_logs 表, 应在初始化阶段调用
+func Track(db *gorm.DB, models ...any) error {
+	p, ok := db.Config.Plugins[pluginName].(*plugin)
+	if !ok {
+		p = &plugin{tables: make(map[string]bool)}
+		if err := db.Use(p); err != nil {
+			return err
+		}
+		if err := Migrate(db); err != nil {
+			return err
+		}
+	}
+	for _, model := range models {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return err
+		}
+		p.mu.Lock()
+		p.tables[stmt.Schema.Table] = true
+		p.mu.Unlock()
+	}
+	return nil
+}
+
+func (p *plugin) Name() string {
+	return pluginName
+}
+
+func (p *plugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Create().After("gorm:create").Register("audit:after_create", p.afterCreate); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("audit:before_update", p.before); err != nil {
+		return err
+	}
+	if err := cb.Update().After("gorm:update").Register("audit:after_update", p.afterUpdate); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("gorm:delete").Register("audit:before_delete", p.before); err != nil {
+		return err
+	}
+	return cb.Delete().After("gorm:delete").Register("audit:after_delete", p.afterDelete)
+}
+
+func (p *plugin) tracked(db *gorm.DB) bool {
+	if db.Error != nil || db.Statement.Schema == nil || db.Statement.Schema.PrioritizedPrimaryField == nil {
+		return false
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.tables[db.Statement.Schema.Table]
+}
+
+func (p *plugin) before(db *gorm.DB) {
+	if !p.tracked(db) {
+		return
+	}
+	rows, err := load(db, nil)
+	if err != nil {
+		_ = db.AddError(fmt.Errorf("audit: 读取变更前的记录失败: %w", err))
+		return
+	}
+	db.InstanceSet(snapshotKey, rows)
+}
+
+func (p *plugin) afterCreate(db *gorm.DB) {
+	if !p.tracked(db) {
+		return
+	}
+	var logs []Log
+	eachElem(db.Statement.ReflectValue, func(rv reflect.Value) {
+		row := takeSnapshot(db.Statement.Context, db.Statement.Schema, rv)
+		logs = append(logs, newLog(db, ActionCreate, row.id, diff(nil, row.values)))
+	})
+	write(db, logs)
+}
+
+func (p *plugin) afterUpdate(db *gorm.DB) {
+	if !p.tracked(db) {
+		return
+	}
+	before := snapshotsOf(db)
+	if len(before) == 0 {
+		return
+	}
+	pks := make([]any, len(before))
+	for i, row := range before {
+		pks[i] = row.pk
+	}
+	after, err := load(db, pks)
+	if err != nil {
+		_ = db.AddError(fmt.Errorf("audit: 读取变更后的记录失败: %w", err))
+		return
+	}
+	afterByID := make(map[string]snapshot, len(after))
+	for _, row := range after {
+		afterByID[row.id] = row
+	}
+
+	var logs []Log
+	for _, row := range before {
+		changed, ok := afterByID[row.id]
+		if !ok {
+			continue
+		}
+		if changes := diff(row.values, changed.values); len(changes) > 0 {
+			logs = append(logs, newLog(db, ActionUpdate, row.id, changes))
+		}
+	}
+	write(db, logs)
+}
+
+func (p *plugin) afterDelete(db *gorm.DB) {
+	if !p.tracked(db) || db.RowsAffected == 0 {
+		return
+	}
+	var logs []Log
+	for _, row := range snapshotsOf(db) {
+		logs = append(logs, newLog(db, ActionDelete, row.id, diff(row.values, nil)))
+	}
+	write(db, logs)
+}
+
+func snapshotsOf(db *gorm.DB) []snapshot {
+	v, _ := db.InstanceGet(snapshotKey)
+	rows, _ := v.([]snapshot)
+	return rows
+}
+
+// load 在语句所在的连接 (或事务) 上读取记录: pks 非空时按主键读取, 否则使用语句的 WHERE 条件以及模型上的主键。
+// 没有任何条件时返回空, GORM 本身也会拒绝这样的更新和删除
+func load(db *gorm.DB, pks []any) ([]snapshot, error) {
+	stmt := db.Statement
+	pk := stmt.Schema.PrioritizedPrimaryField
+	pkColumn := clause.Column{Table: clause.CurrentTable, Name: pk.DBName}
+	query := db.Session(&gorm.Session{NewDB: true}).Model(reflect.New(stmt.Schema.ModelType).Interface())
+	if pks != nil {
+		query = query.Where(clause.IN{Column: pkColumn, Values: pks})
+	} else {
+		conditions := false
+		if where, ok := stmt.Clauses["WHERE"]; ok && where.Expression != nil {
+			query = query.Clauses(where.Expression)
+			conditions = true
+		}
+		if rv := reflect.Indirect(stmt.ReflectValue); rv.Kind() == reflect.Struct {
+			if v, zero := pk.ValueOf(stmt.Context, rv); !zero {
+				query = query.Where(clause.Eq{Column: pkColumn, Value: v})
+				conditions = true
+			}
+		}
+		if !conditions {
+			return nil, nil
+		}
+	}
+
+	dest := reflect.New(reflect.SliceOf(stmt.Schema.ModelType))
+	if err := query.Find(dest.Interface()).Error; err != nil {
+		return nil, err
+	}
+	var rows []snapshot
+	eachElem(dest.Elem(), func(rv reflect.Value) {
+		rows = append(rows, takeSnapshot(stmt.Context, stmt.Schema, rv))
+	})
+	return rows, nil
+}
+
+func eachElem(rv reflect.Value, fn func(reflect.Value)) {
+	rv = reflect.Indirect(rv)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			if elem := reflect.Indirect(rv.Index(i)); elem.Kind() == reflect.Struct {
+				fn(elem)
+			}
+		}
+	case reflect.Struct:
+		fn(rv)
+	}
+}
+
+func takeSnapshot(ctx context.Context, s *schema.Schema, rv reflect.Value) snapshot {
+	values := make(map[string]any, len(s.DBNames))
+	for _, name := range s.DBNames {
+		values[name], _ = s.FieldsByDBName[name].ValueOf(ctx, rv)
+	}
+	pk, _ := s.PrioritizedPrimaryField.ValueOf(ctx, rv)
+	return snapshot{pk: pk, id: fmt.Sprint(pk), values: values}
+}
+
+// diff 返回前后值不同的列, before 为空表示创建, after 为空表示删除。
+// 值按 JSON 比较, 避免同一时间因时区或单调时钟不同被当作变更
+func diff(before, after map[string]any) map[string]Change {
+	changes := make(map[string]Change)
+	for name, value := range after {
+		old, ok := before[name]
+		if ok && jsonEqual(old, value) {
+			continue
+		}
+		changes[name] = Change{Before: old, After: value}
+	}
+	for name, old := range before {
+		if _, ok := after[name]; !ok {
+			changes[name] = Change{Before: old}
+		}
+	}
+	return changes
+}
+
+func jsonEqual(a, b any) bool {
+	x, errX := json.Marshal(a)
+	y, errY := json.Marshal(b)
+	return errX == nil && errY == nil && bytes.Equal(x, y)
+}
+
+func newLog(db *gorm.DB, action, id string, changes map[string]Change) Log {
+	actor, _ := Actor(db.Statement.Context)
+	data, _ := json.Marshal(changes)
+	return Log{Table: db.Statement.Schema.Table, RecordID: id, Action: action, Actor: actor, Changes: data}
+}
+
+func write(db *gorm.DB, logs []Log) {
+	if len(logs) == 0 {
+		return
+	}
+	if err := db.Session(&gorm.Session{NewDB: true}).Create(&logs).Error; err != nil {
+		_ = db.AddError(fmt.Errorf("audit: 写入变更记录失败: %w", err))
+	}
+}
@@ -0,0 +1,108 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestBackends(t *testing.T) {
+	mr := miniredis.RunT(t)
+	backends := []struct {
+		name   string
+		cache  Cache
+		expire func(time.Duration)
+	}{
+		{"lru", NewLRU(10), time.Sleep},
+		{"redis", NewRedis(redis.NewClient(&redis.Options{Addr: mr.Addr()})), mr.FastForward},
+	}
+
+	ctx := context.Background()
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) {
+			if _, err := b.cache.Get(ctx, "a"); !errors.Is(err, ErrMiss) {
+				t.Fatalf("Get on empty cache: err = %v, want ErrMiss", err)
+			}
+			if err := b.cache.Set(ctx, "a", []byte("1"), 0); err != nil {
+				t.Fatal(err)
+			}
+			if err := b.cache.Set(ctx, "b", []byte("2"), 20*time.Millisecond); err != nil {
+				t.Fatal(err)
+			}
+			if got, err := b.cache.Get(ctx, "a"); err != nil || string(got) != "1" {
+				t.Fatalf("Get(a) = %q, %v", got, err)
+			}
+
+			b.expire(30 * time.Millisecond)
+			if _, err := b.cache.Get(ctx, "b"); !errors.Is(err, ErrMiss) {
+				t.Errorf("Get(b) after ttl: err = %v, want ErrMiss", err)
+			}
+			if err := b.cache.Delete(ctx, "a", "missing"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := b.cache.Get(ctx, "a"); !errors.Is(err, ErrMiss) {
+				t.Errorf("Get(a) after Delete: err = %v, want ErrMiss", err)
+			}
+		})
+	}
+}
+
+func TestLRUEviction(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRU(2)
+	_ = c.Set(ctx, "a", []byte("1"), 0)
+	_ = c.Set(ctx, "b", []byte("2"), 0)
+	_, _ = c.Get(ctx, "a") // a 成为最近访问的条目
+	_ = c.Set(ctx, "c", []byte("3"), 0)
+
+	if _, err := c.Get(ctx, "b"); !errors.Is(err, ErrMiss) {
+		t.Errorf("b should have been evicted, err = %v", err)
+	}
+	if _, err := c.Get(ctx, "a"); err != nil {
+		t.Errorf("a should still be cached, err = %v", err)
+	}
+	if c.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", c.Len())
+	}
+}
+
+type user struct {
+	ID       uint
+	Name     string
+	Password string `json:"-"`
+}
+
+func TestGetOrLoad(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRU(10)
+	loads := 0
+	load := func() (*user, error) {
+		loads++
+		return &user{ID: 1, Name: "alice", Password: "secret"}, nil
+	}
+
+	for i := 0; i < 2; i++ {
+		u, err := GetOrLoad(ctx, c, "users:1", time.Minute, load)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u.Name != "alice" || u.Password != "secret" {
+			t.Errorf("got %+v", u)
+		}
+	}
+	if loads != 1 {
+		t.Errorf("load called %d times, want 1", loads)
+	}
+
+	notFound := errors.New("not found")
+	if _, err := GetOrLoad(ctx, c, "users:2", time.Minute, func() (*user, error) { return nil, notFound }); !errors.Is(err, notFound) {
+		t.Errorf("err = %v, want %v", err, notFound)
+	}
+	if _, err := c.Get(ctx, "users:2"); !errors.Is(err, ErrMiss) {
+		t.Errorf("load errors must not be cached, err = %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis 是基于 Redis 的缓存实现, 适合多个实例共享缓存的部署
+type Redis struct {
+	client redis.UniversalClient
+}
+
+var _ Cache = (*Redis)(nil)
+
+// NewRedis 使用已有的 Redis 客户端创建缓存, 客户端的生命周期由调用方管理
+func NewRedis(client redis.UniversalClient) *Redis {
+	return &Redis{client: client}
+}
+
+// Get 返回 key 对应的值, 不存在时返回 ErrMiss
+func (c *Redis) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := c.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrMiss
+	}
+	return data, err
+}
+
+// Set 写入 key, ttl <= 0 时不过期
+func (c *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if ttl < 0 {
+		ttl = 0
+	}
+	return c.client.Set(ctx, key, value, ttl).Err()
+}
+
+// Delete 删除给定的键
+func (c *Redis) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	return c.client.Del(ctx, keys...).Err()
+}
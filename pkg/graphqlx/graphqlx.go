@@ -0,0 +1,168 @@
+// Package graphqlx 提供生成的 GraphQL 解析器所需的运行时支持:
+// 按实体拼装 schema, Fiber 处理函数, 参数转换以及批量加载器。
+package graphqlx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/graphql-go/graphql"
+)
+
+// Builder 收集各实体贡献的类型、查询和变更, 最后构建出完整的 schema。
+type Builder struct {
+	Query    graphql.Fields
+	Mutation graphql.Fields
+	types    map[string]*graphql.Object
+	loaders  map[string]LoadFunc
+}
+
+// LoadFunc 按主键加载某个类型的对象, 返回的 thunk 由 graphql-go 延迟执行以便批量加载
+type LoadFunc func(ctx context.Context, key any) func() (interface{}, error)
+
+// NewBuilder 创建一个空的 schema 构建器
+func NewBuilder() *Builder {
+	return &Builder{
+		Query:    graphql.Fields{},
+		Mutation: graphql.Fields{},
+		types:    make(map[string]*graphql.Object),
+		loaders:  make(map[string]LoadFunc),
+	}
+}
+
+// AddType 注册一个对象类型, 供其他实体的关联字段引用
+func (b *Builder) AddType(obj *graphql.Object) {
+	b.types[obj.Name()] = obj
+}
+
+// Type 返回已注册的对象类型, 未注册时返回 nil。
+// 关联字段应在 graphql.FieldsThunk 中调用它, 这样与注册顺序无关。
+func (b *Builder) Type(name string) *graphql.Object {
+	return b.types[name]
+}
+
+// AddLoader 注册类型的批量加载函数, 供其他实体的 belongs-to 关联字段使用
+func (b *Builder) AddLoader(typeName string, load LoadFunc) {
+	b.loaders[typeName] = load
+}
+
+// Loader 返回类型的批量加载函数, 未注册时返回 nil
+func (b *Builder) Loader(typeName string) LoadFunc {
+	return b.loaders[typeName]
+}
+
+// Build 构建 schema, 没有任何变更时省略 Mutation 根类型
+func (b *Builder) Build() (graphql.Schema, error) {
+	if len(b.Query) == 0 {
+		return graphql.Schema{}, fmt.Errorf("graphql: 没有注册任何查询")
+	}
+	config := graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{Name: "Query", Fields: b.Query}),
+	}
+	if len(b.Mutation) > 0 {
+		config.Mutation = graphql.NewObject(graphql.ObjectConfig{Name: "Mutation", Fields: b.Mutation})
+	}
+	names := make([]string, 0, len(b.types))
+	for name := range b.types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		config.Types = append(config.Types, b.types[name])
+	}
+	return graphql.NewSchema(config)
+}
+
+// request 是 GraphQL over HTTP 的请求体
+type request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// Handler 返回执行 GraphQL 请求的 Fiber 处理函数, 支持 POST JSON 和 GET ?query= 两种方式
+func Handler(schema graphql.Schema) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		var req request
+		if c.Method() == fiber.MethodGet {
+			req.Query = c.Query("query")
+			req.OperationName = c.Query("operationName")
+			if vars := c.Query("variables"); vars != "" {
+				if err := json.Unmarshal([]byte(vars), &req.Variables); err != nil {
+					return fiber.NewError(fiber.StatusBadRequest, "variables 不是合法的 JSON")
+				}
+			}
+		} else if err := json.Unmarshal(c.Body(), &req); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "请求体不是合法的 JSON")
+		}
+		if req.Query == "" {
+			return fiber.NewError(fiber.StatusBadRequest, "缺少 query")
+		}
+
+		result := graphql.Do(graphql.Params{
+			Schema:         schema,
+			RequestString:  req.Query,
+			VariableValues: req.Variables,
+			OperationName:  req.OperationName,
+			Context:        WithLoaders(c),
+		})
+		return c.JSON(result)
+	}
+}
+
+// KeyedLoader 将按主键批量加载的函数包装为 LoadFunc, 每个请求共享同一个名为 name 的 Loader。
+// 外键与主键类型不同但可以互相转换时 (如 uint 与 uint64) 会自动转换。
+func KeyedLoader[K comparable, V any](name string, fetch BatchFunc[K, V]) LoadFunc {
+	return func(ctx context.Context, key any) func() (interface{}, error) {
+		id, ok := key.(K)
+		if !ok {
+			var zero K
+			v := reflect.ValueOf(key)
+			if !v.IsValid() || !v.Type().ConvertibleTo(reflect.TypeOf(zero)) {
+				return func() (interface{}, error) {
+					return nil, fmt.Errorf("graphql: %s 的主键类型为 %T, 无法使用 %T 加载", name, zero, key)
+				}
+			}
+			id = v.Convert(reflect.TypeOf(zero)).Interface().(K)
+		}
+		return LoaderFor(ctx, name, fetch).Load(ctx, id)
+	}
+}
+
+// Source 返回解析参数中的父对象, 同时兼容值和指针两种形式
+func Source[T any](p graphql.ResolveParams) *T {
+	switch v := p.Source.(type) {
+	case *T:
+		return v
+	case T:
+		return &v
+	default:
+		return new(T)
+	}
+}
+
+// ParseID 将 GraphQL 的 ID 参数转换为实体主键类型
+func ParseID[T ~string | ~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64](v any) (T, error) {
+	var id T
+	s, ok := v.(string)
+	if !ok {
+		return id, fmt.Errorf("graphql: 非法的 ID 参数 %v", v)
+	}
+	if _, err := fmt.Sscan(s, &id); err != nil {
+		return id, fmt.Errorf("graphql: 非法的 ID 参数 %q: %w", s, err)
+	}
+	return id, nil
+}
+
+// Bind 将 GraphQL 输入对象参数写入 DTO, 输入字段名与 DTO 的 json tag 一致
+func Bind(input any, out any) error {
+	data, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
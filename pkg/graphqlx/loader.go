@@ -0,0 +1,107 @@
+package graphqlx
+
+import (
+	"context"
+	"sync"
+)
+
+// BatchFunc 根据一组 key 批量加载数据, 返回的 map 中缺失的 key 视为不存在。
+type BatchFunc[K comparable, V any] func(ctx context.Context, keys []K) (map[K]V, error)
+
+// Loader 是一个 dataloader 风格的批量加载器。
+//
+// Load 只登记 key 并返回 graphql-go 支持的 thunk, 执行器在同一层级的所有字段都解析完之后才调用 thunk,
+// 此时所有登记过的 key 会通过一次 BatchFunc 调用加载, 从而避免关联字段的 N+1 查询。
+// Loader 会缓存结果, 应当为每个请求创建新的实例, 见 WithLoaders 和 LoaderFor。
+type Loader[K comparable, V any] struct {
+	fetch BatchFunc[K, V]
+
+	mu      sync.Mutex
+	pending []K
+	queued  map[K]bool
+	results map[K]V
+	errs    map[K]error
+}
+
+// NewLoader 创建一个新的批量加载器
+func NewLoader[K comparable, V any](fetch BatchFunc[K, V]) *Loader[K, V] {
+	return &Loader[K, V]{
+		fetch:   fetch,
+		queued:  make(map[K]bool),
+		results: make(map[K]V),
+		errs:    make(map[K]error),
+	}
+}
+
+// Load 登记需要加载的 key, 返回值可以直接作为 graphql.FieldResolveFn 的结果返回。
+func (l *Loader[K, V]) Load(ctx context.Context, key K) func() (interface{}, error) {
+	l.mu.Lock()
+	if !l.queued[key] {
+		l.queued[key] = true
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+
+	return func() (interface{}, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.dispatch(ctx)
+		if err := l.errs[key]; err != nil {
+			return nil, err
+		}
+		if v, ok := l.results[key]; ok {
+			return v, nil
+		}
+		return nil, nil
+	}
+}
+
+// dispatch 加载所有尚未加载的 key, 调用方需持有锁
+func (l *Loader[K, V]) dispatch(ctx context.Context) {
+	if len(l.pending) == 0 {
+		return
+	}
+	keys := l.pending
+	l.pending = nil
+
+	results, err := l.fetch(ctx, keys)
+	for _, k := range keys {
+		if err != nil {
+			l.errs[k] = err
+			continue
+		}
+		if v, ok := results[k]; ok {
+			l.results[k] = v
+		}
+	}
+}
+
+type loadersKey struct{}
+
+// loaders 保存单个请求内的所有加载器
+type loaders struct {
+	mu    sync.Mutex
+	items map[string]any
+}
+
+// WithLoaders 为请求上下文附加加载器容器, Handler 会自动调用它
+func WithLoaders(ctx context.Context) context.Context {
+	return context.WithValue(ctx, loadersKey{}, &loaders{items: make(map[string]any)})
+}
+
+// LoaderFor 返回当前请求中名为 name 的加载器, 不存在时使用 fetch 创建。
+// 上下文中没有加载器容器时每次都会返回新的加载器, 此时不会进行批量加载。
+func LoaderFor[K comparable, V any](ctx context.Context, name string, fetch BatchFunc[K, V]) *Loader[K, V] {
+	ls, ok := ctx.Value(loadersKey{}).(*loaders)
+	if !ok {
+		return NewLoader(fetch)
+	}
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	if l, ok := ls.items[name].(*Loader[K, V]); ok {
+		return l
+	}
+	l := NewLoader(fetch)
+	ls.items[name] = l
+	return l
+}
@@ -0,0 +1,40 @@
+package graphqlx
+
+import (
+	"context"
+	"testing"
+)
+
+func TestLoaderBatchesPendingKeys(t *testing.T) {
+	var batches [][]int
+	fetch := func(_ context.Context, keys []int) (map[int]string, error) {
+		batches = append(batches, keys)
+		result := make(map[int]string)
+		for _, k := range keys {
+			if k != 3 {
+				result[k] = string(rune('a' + k))
+			}
+		}
+		return result, nil
+	}
+
+	ctx := WithLoaders(context.Background())
+	var thunks []func() (interface{}, error)
+	for _, key := range []int{1, 2, 1, 3} {
+		thunks = append(thunks, LoaderFor(ctx, "letters", fetch).Load(ctx, key))
+	}
+
+	want := []interface{}{"b", "c", "b", nil}
+	for i, thunk := range thunks {
+		got, err := thunk()
+		if err != nil {
+			t.Fatalf("thunk %d error = %v", i, err)
+		}
+		if got != want[i] {
+			t.Errorf("thunk %d = %v, want %v", i, got, want[i])
+		}
+	}
+	if len(batches) != 1 || len(batches[0]) != 3 {
+		t.Errorf("batches = %v, want a single batch of 3 keys", batches)
+	}
+}
@@ -0,0 +1,85 @@
+// Package grpcx 提供生成的 gRPC 服务在 protobuf 消息与 DTO 之间转换所需的辅助函数。
+//
+// 生成的 .proto 文件为每个字段设置了与 DTO json tag 一致的 json_name,
+// 因此两者之间可以通过 JSON 字段名对齐, 不需要为每个实体手写映射代码。
+package grpcx
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/Skyenought/goprojectstarter/pkg/tenant"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+)
+
+// Bind 将 protobuf 请求消息中已赋值的字段写入 DTO。
+// 未赋值的 optional 字段不会出现在结果中, 因此 DTO 中对应的指针字段保持为 nil。
+func Bind(msg proto.Message, out any) error {
+	data, err := json.Marshal(messageToMap(msg.ProtoReflect()))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+// Fill 将 DTO 转换为 protobuf 响应消息, DTO 中 proto 未定义的字段 (如关联对象) 会被忽略。
+func Fill(in any, msg proto.Message) error {
+	data, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(data, msg)
+}
+
+// Status 将业务层返回的错误转换为 gRPC 状态错误。
+func Status(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	if errors.Is(err, tenant.ErrMissing) {
+		return status.Error(codes.Unauthenticated, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// messageToMap 以 json_name 为键展开消息, 与 protojson 不同的是 64 位整数保持为数字,
+// 这样才能直接反序列化到 DTO 的整型字段中。
+func messageToMap(m protoreflect.Message) map[string]any {
+	fields := make(map[string]any)
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if fd.IsList() {
+			list := v.List()
+			items := make([]any, list.Len())
+			for i := range items {
+				items[i] = valueToAny(fd, list.Get(i))
+			}
+			fields[fd.JSONName()] = items
+			return true
+		}
+		fields[fd.JSONName()] = valueToAny(fd, v)
+		return true
+	})
+	return fields
+}
+
+func valueToAny(fd protoreflect.FieldDescriptor, v protoreflect.Value) any {
+	if fd.Message() == nil {
+		return v.Interface()
+	}
+	if ts, ok := v.Message().Interface().(*timestamppb.Timestamp); ok {
+		return ts.AsTime()
+	}
+	return messageToMap(v.Message())
+}
@@ -0,0 +1,68 @@
+// Package outbox 实现事务型发件箱 (transactional outbox):
+// 领域事件与实体变更在同一个数据库事务中写入 outbox_messages 表, 再由 Relay 异步投递给 Publisher,
+// 从而保证 "数据已提交" 与 "事件会被发布" 同时成立。投递语义为至少一次, 消费方应以消息 ID 去重。
+package outbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Event 是可以写入发件箱的领域事件, 事件本身会被编码为 JSON 作为消息载荷
+type Event interface {
+	EventName() string
+	AggregateType() string
+	AggregateID() string
+}
+
+// Message 是 outbox_messages 表中的一条待投递或已投递的事件
+type Message struct {
+	ID            uint64          `gorm:"primaryKey" json:"id"`
+	EventType     string          `gorm:"size:128;index" json:"type"`
+	AggregateType string          `gorm:"size:128" json:"aggregateType"`
+	AggregateID   string          `gorm:"size:128" json:"aggregateId"`
+	Payload       json.RawMessage `json:"payload"`
+	OccurredAt    time.Time       `json:"occurredAt"`
+	PublishedAt   *time.Time      `gorm:"index" json:"-"`
+	Attempts      int             `json:"-"`
+	NextAttemptAt time.Time       `gorm:"index" json:"-"`
+	LastError     string          `gorm:"size:1024" json:"-"`
+}
+
+// TableName 指定发件箱表名
+func (Message) TableName() string {
+	return "outbox_messages"
+}
+
+// Migrate 创建或更新发件箱表
+func Migrate(db *gorm.DB) error {
+	return db.AutoMigrate(&Message{})
+}
+
+// Append 把事件写入发件箱。tx 应当是写入实体变更的同一个事务, 这样事件与数据一同提交或回滚。
+// 事件在此时才编码, 因此可以引用事务中刚插入、已经回填主键的实体
+func Append[E Event](tx *gorm.DB, events ...E) error {
+	if len(events) == 0 {
+		return nil
+	}
+	now := time.Now()
+	msgs := make([]Message, len(events))
+	for i, e := range events {
+		payload, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("outbox: 无法编码事件 %s: %w", e.EventName(), err)
+		}
+		msgs[i] = Message{
+			EventType:     e.EventName(),
+			AggregateType: e.AggregateType(),
+			AggregateID:   e.AggregateID(),
+			Payload:       payload,
+			OccurredAt:    now,
+			NextAttemptAt: now,
+		}
+	}
+	return tx.Create(&msgs).Error
+}
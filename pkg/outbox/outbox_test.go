@@ -0,0 +1,158 @@
+package outbox
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type song struct {
+	ID    uint   `json:"id"`
+	Title string `json:"title"`
+}
+
+type songCreated struct {
+	Song *song `json:"song"`
+}
+
+func (e songCreated) EventName() string     { return "SongCreated" }
+func (e songCreated) AggregateType() string { return "Song" }
+func (e songCreated) AggregateID() string   { return strconv.FormatUint(uint64(e.Song.ID), 10) }
+
+func newDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&song{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestAppendFollowsTransaction(t *testing.T) {
+	db := newDB(t)
+	rollback := errors.New("rollback")
+	for _, wantErr := range []error{nil, rollback} {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			s := &song{Title: "a"}
+			if err := tx.Create(s).Error; err != nil {
+				return err
+			}
+			if err := Append(tx, songCreated{Song: s}); err != nil {
+				return err
+			}
+			return wantErr
+		})
+		if !errors.Is(err, wantErr) {
+			t.Fatalf("transaction err = %v, want %v", err, wantErr)
+		}
+	}
+
+	var msgs []Message
+	db.Find(&msgs)
+	if len(msgs) != 1 {
+		t.Fatalf("got %d outbox messages, want 1 (the rolled back one must not be written)", len(msgs))
+	}
+	if msgs[0].AggregateID != "1" || string(msgs[0].Payload) != `{"song":{"id":1,"title":"a"}}` {
+		t.Errorf("message = %+v, payload %s", msgs[0], msgs[0].Payload)
+	}
+}
+
+func TestRelayWebhook(t *testing.T) {
+	db := newDB(t)
+	s := &song{Title: "a"}
+	db.Create(s)
+	if err := Append(db, songCreated{Song: s}); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	fail := true
+	var received []Message
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if fail {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write(body)
+		if got, want := r.Header.Get("X-Outbox-Signature"), "sha256="+hex.EncodeToString(mac.Sum(nil)); got != want {
+			t.Errorf("signature = %q, want %q", got, want)
+		}
+		var msg Message
+		if err := json.Unmarshal(body, &msg); err != nil {
+			t.Error(err)
+		}
+		received = append(received, msg)
+	}))
+	defer srv.Close()
+
+	var failures int
+	relay := NewRelay(db, NewWebhook(srv.URL, "secret", 0), RelayOptions{OnError: func(Message, error) { failures++ }})
+	ctx := context.Background()
+
+	if n, err := relay.DispatchPending(ctx); err != nil || n != 0 || failures != 1 {
+		t.Fatalf("first dispatch: n=%d err=%v failures=%d", n, err, failures)
+	}
+	var msg Message
+	db.First(&msg)
+	if msg.Attempts != 1 || msg.PublishedAt != nil || msg.LastError == "" {
+		t.Fatalf("failed message = %+v", msg)
+	}
+
+	// 退避期间不会重试; 到期后重新投递成功
+	if n, _ := relay.DispatchPending(ctx); n != 0 || failures != 1 {
+		t.Fatalf("message retried before its backoff expired: n=%d failures=%d", n, failures)
+	}
+	db.Model(&Message{}).Where("id = ?", msg.ID).Update("next_attempt_at", msg.OccurredAt)
+	mu.Lock()
+	fail = false
+	mu.Unlock()
+	if n, err := relay.DispatchPending(ctx); err != nil || n != 1 {
+		t.Fatalf("retry: n=%d err=%v", n, err)
+	}
+	if n, _ := relay.DispatchPending(ctx); n != 0 {
+		t.Errorf("published message dispatched again")
+	}
+	if len(received) != 1 || received[0].EventType != "SongCreated" || received[0].ID != msg.ID {
+		t.Errorf("received = %+v", received)
+	}
+}
+
+func TestBus(t *testing.T) {
+	bus := NewBus()
+	var got []string
+	bus.Subscribe("SongCreated", func(_ context.Context, m Message) error { got = append(got, "created"); return nil })
+	bus.Subscribe("*", func(_ context.Context, m Message) error { got = append(got, "all:"+m.EventType); return nil })
+
+	if err := bus.Publish(context.Background(), Message{EventType: "SongCreated"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.Publish(context.Background(), Message{EventType: "SongDeleted"}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"created", "all:SongCreated", "all:SongDeleted"}; len(got) != 3 || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
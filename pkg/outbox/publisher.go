@@ -0,0 +1,108 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Publisher 把发件箱中的消息投递出去。返回错误时 Relay 会在退避后重试
+type Publisher interface {
+	Publish(ctx context.Context, msg Message) error
+}
+
+// Handler 处理进程内总线上的一条消息
+type Handler func(ctx context.Context, msg Message) error
+
+// Bus 是进程内的事件总线, 按事件类型把消息分发给订阅者, 订阅 "*" 可以接收所有事件
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+}
+
+var _ Publisher = (*Bus)(nil)
+
+// NewBus 创建一个没有订阅者的总线
+func NewBus() *Bus {
+	return &Bus{handlers: make(map[string][]Handler)}
+}
+
+// Subscribe 订阅指定类型的事件, 如 "SongCreated"
+func (b *Bus) Subscribe(eventType string, h Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[eventType] = append(b.handlers[eventType], h)
+}
+
+// Publish 依次调用订阅者, 任一订阅者失败时整条消息会被重试, 因此订阅者需要是幂等的
+func (b *Bus) Publish(ctx context.Context, msg Message) error {
+	b.mu.RLock()
+	handlers := append(append([]Handler(nil), b.handlers[msg.EventType]...), b.handlers["*"]...)
+	b.mu.RUnlock()
+
+	var errs []error
+	for _, h := range handlers {
+		if err := h(ctx, msg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Webhook 把消息以 JSON 的形式 POST 到固定的地址, 非 2xx 响应视为失败
+type Webhook struct {
+	URL    string
+	Secret string // 非空时使用 HMAC-SHA256 对请求体签名, 写入 X-Outbox-Signature: sha256=<hex>
+	Client *http.Client
+}
+
+var _ Publisher = (*Webhook)(nil)
+
+// NewWebhook 创建一个 Webhook 发布者, timeout <= 0 时使用 10 秒
+func NewWebhook(url, secret string, timeout time.Duration) *Webhook {
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &Webhook{URL: url, Secret: secret, Client: &http.Client{Timeout: timeout}}
+}
+
+// Publish 发送消息, 请求头中携带 X-Outbox-Message-ID 供接收方去重
+func (w *Webhook) Publish(ctx context.Context, msg Message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Outbox-Message-ID", strconv.FormatUint(msg.ID, 10))
+	req.Header.Set("X-Outbox-Event-Type", msg.EventType)
+	if w.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(w.Secret))
+		mac.Write(body)
+		req.Header.Set("X-Outbox-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 256))
+		return fmt.Errorf("outbox: webhook 返回 %d: %s", resp.StatusCode, bytes.TrimSpace(snippet))
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package outbox
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RelayOptions 控制 Relay 的轮询和重试, 零值字段使用默认值
+type RelayOptions struct {
+	Interval    time.Duration // 轮询间隔, 默认 1 秒
+	BatchSize   int           // 每次轮询最多投递的消息数, 默认 100
+	MaxAttempts int           // 单条消息的最大投递次数, 超过后不再重试, 默认 10
+	OnError     func(msg Message, err error)
+}
+
+// Relay 轮询发件箱表, 按写入顺序把未投递的消息交给 Publisher。
+// 失败的消息按 1s, 2s, 4s ... (最长 5 分钟) 退避后重试, 因此重试期间同一实体的后续事件可能先于它送达。
+// 多个实例同时运行 Relay 时同一条消息可能被重复投递
+type Relay struct {
+	db   *gorm.DB
+	pub  Publisher
+	opts RelayOptions
+}
+
+// NewRelay 创建 Relay, 调用方需要保证发件箱表已经通过 Migrate 创建
+func NewRelay(db *gorm.DB, pub Publisher, opts RelayOptions) *Relay {
+	if opts.Interval <= 0 {
+		opts.Interval = time.Second
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 10
+	}
+	if opts.OnError == nil {
+		opts.OnError = func(msg Message, err error) {
+			log.Printf("outbox: 投递消息 %d (%s) 失败 (第 %d 次): %v", msg.ID, msg.EventType, msg.Attempts, err)
+		}
+	}
+	return &Relay{db: db, pub: pub, opts: opts}
+}
+
+// Run 持续轮询直到 ctx 结束。一批消息全部投递成功时立即拉取下一批, 否则等待一个轮询间隔
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.opts.Interval)
+	defer ticker.Stop()
+	for {
+		n, err := r.DispatchPending(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("outbox: 读取发件箱失败: %v", err)
+		}
+		if err == nil && n == r.opts.BatchSize {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchPending 投递一批到期的消息, 返回成功投递的条数
+func (r *Relay) DispatchPending(ctx context.Context) (int, error) {
+	var msgs []Message
+	err := r.db.WithContext(ctx).
+		Where("published_at IS NULL AND attempts < ? AND next_attempt_at <= ?", r.opts.MaxAttempts, time.Now()).
+		Order("id").Limit(r.opts.BatchSize).Find(&msgs).Error
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	for _, msg := range msgs {
+		if ctx.Err() != nil {
+			return published, ctx.Err()
+		}
+		if err := r.pub.Publish(ctx, msg); err != nil {
+			msg.Attempts++
+			r.opts.OnError(msg, err)
+			lastError := err.Error()
+			if len(lastError) > 1024 {
+				lastError = lastError[:1024]
+			}
+			if err := r.db.WithContext(ctx).Model(&Message{}).Where("id = ?", msg.ID).Updates(map[string]any{
+				"attempts":        msg.Attempts,
+				"last_error":      lastError,
+				"next_attempt_at": time.Now().Add(backoff(msg.Attempts)),
+			}).Error; err != nil {
+				return published, err
+			}
+			continue
+		}
+		if err := r.db.WithContext(ctx).Model(&Message{}).Where("id = ?", msg.ID).Updates(map[string]any{
+			"published_at": time.Now(),
+			"last_error":   "",
+		}).Error; err != nil {
+			return published, err
+		}
+		published++
+	}
+	return published, nil
+}
+
+func backoff(attempts int) time.Duration {
+	const maxBackoff = 5 * time.Minute
+	if attempts > 9 {
+		return maxBackoff
+	}
+	return min(time.Second<<(attempts-1), maxBackoff)
+}
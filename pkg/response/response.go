@@ -22,6 +22,8 @@ const (
 	CodeError = 7
 	// CodeInvalidParams 表示请求参数无效。
 	CodeInvalidParams = 400
+	// CodeUnauthorized 表示请求缺少有效的身份或租户信息。
+	CodeUnauthorized = 401
 	// CodeNotFound 表示资源未找到。
 	CodeNotFound = 404
 	// CodeServerError 表示内部服务器错误。
@@ -63,6 +65,8 @@ func Fail(c fiber.Ctx, code int, msg string) error {
 	switch code {
 	case CodeInvalidParams:
 		httpStatus = fiber.StatusBadRequest
+	case CodeUnauthorized:
+		httpStatus = fiber.StatusUnauthorized
 	case CodeNotFound:
 		httpStatus = fiber.StatusNotFound
 	}
@@ -76,6 +80,8 @@ func FailWithData[T any](c fiber.Ctx, code int, msg string, data T) error {
 	switch code {
 	case CodeInvalidParams:
 		httpStatus = fiber.StatusBadRequest
+	case CodeUnauthorized:
+		httpStatus = fiber.StatusUnauthorized
 	case CodeNotFound:
 		httpStatus = fiber.StatusNotFound
 	}
@@ -109,8 +115,13 @@ func JSONFlat(c fiber.Ctx, httpStatus, code int, msg string, data interface{}) e
 	if data != nil {
 		dataMap, err := structToMap(data)
 		if err != nil {
-			// 如果转换失败，返回一个内部错误，并在错误数据中说明原因
-			return JSONFlat(c, fiber.StatusInternalServerError, CodeServerError, "服务器内部错误：无法序列化响应数据", nil)
+			// 切片、基础类型等无法展开为对象的数据，统一放在 data 字段下
+			bytes, marshalErr := json.Marshal(data)
+			if marshalErr != nil {
+				// 如果转换失败，返回一个内部错误，并在错误数据中说明原因
+				return JSONFlat(c, fiber.StatusInternalServerError, CodeServerError, "服务器内部错误：无法序列化响应数据", nil)
+			}
+			base["data"] = json.RawMessage(bytes)
 		}
 
 		for key, value := range dataMap {
@@ -140,6 +151,8 @@ func FailFlat(c fiber.Ctx, code int, msg string) error {
 	switch code {
 	case CodeInvalidParams:
 		httpStatus = fiber.StatusBadRequest
+	case CodeUnauthorized:
+		httpStatus = fiber.StatusUnauthorized
 	case CodeNotFound:
 		httpStatus = fiber.StatusNotFound
 	}
@@ -152,6 +165,8 @@ func FailWithDataFlat(c fiber.Ctx, code int, msg string, data interface{}) error
 	switch code {
 	case CodeInvalidParams:
 		httpStatus = fiber.StatusBadRequest
+	case CodeUnauthorized:
+		httpStatus = fiber.StatusUnauthorized
 	case CodeNotFound:
 		httpStatus = fiber.StatusNotFound
 	}
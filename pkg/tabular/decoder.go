@@ -0,0 +1,172 @@
+package tabular
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// RowError 描述导入时某一行的错误, Row 为表格中的行号 (表头为第 1 行)
+type RowError struct {
+	Row     int    `json:"row"`
+	Column  string `json:"column,omitempty"`
+	Message string `json:"error"`
+}
+
+func (e *RowError) Error() string {
+	if e.Column == "" {
+		return fmt.Sprintf("第 %d 行: %s", e.Row, e.Message)
+	}
+	return fmt.Sprintf("第 %d 行 %s 列: %s", e.Row, e.Column, e.Message)
+}
+
+// rowReader 是不同格式共用的逐行读取接口, 返回行内容及其行号, 读完时返回 io.EOF
+type rowReader interface {
+	ReadRow() ([]string, int, error)
+	Close() error
+}
+
+// Decoder 按表头把表格的每一行解析为 T, 表头与 T 的 json 名称匹配, 未知的列会被忽略
+type Decoder[T any] struct {
+	r       rowReader
+	columns []*column // 与表格各列一一对应, 未匹配的列为 nil
+	row     int
+}
+
+// NewDecoder 创建一个读取 r 的解码器并读取表头。XLSX 只读取第一个工作表
+func NewDecoder[T any](format Format, r io.Reader) (*Decoder[T], error) {
+	var rr rowReader
+	if format == XLSX {
+		xr, err := newXLSXReader(r)
+		if err != nil {
+			return nil, err
+		}
+		rr = xr
+	} else {
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = -1
+		rr = &csvReader{r: cr}
+	}
+
+	header, _, err := rr.ReadRow()
+	if err != nil {
+		_ = rr.Close()
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("tabular: 文件为空, 缺少表头")
+		}
+		return nil, err
+	}
+	byName := make(map[string]*column)
+	cols := columnsOf(reflect.TypeOf((*T)(nil)).Elem())
+	for i := range cols {
+		byName[strings.ToLower(cols[i].name)] = &cols[i]
+	}
+	d := &Decoder[T]{r: rr}
+	for i, name := range header {
+		if i == 0 {
+			name = strings.TrimPrefix(name, utf8BOM)
+		}
+		d.columns = append(d.columns, byName[strings.ToLower(strings.TrimSpace(name))])
+	}
+	return d, nil
+}
+
+// Next 读取下一行, 返回该行的行号。单元格无法转换时返回 *RowError, 调用方可以记录后继续读取;
+// 其他错误 (包括读完时的 io.EOF) 表示无法继续。空行会被跳过
+func (d *Decoder[T]) Next() (T, int, error) {
+	var item T
+	for {
+		record, row, err := d.r.ReadRow()
+		if err != nil {
+			return item, d.row, err
+		}
+		d.row = row
+		if isBlank(record) {
+			continue
+		}
+
+		v := reflect.ValueOf(&item).Elem()
+		for i, text := range record {
+			if i >= len(d.columns) || d.columns[i] == nil {
+				continue
+			}
+			c := d.columns[i]
+			if err := setField(v.FieldByIndex(c.index), text); err != nil {
+				return item, d.row, &RowError{Row: d.row, Column: c.name, Message: err.Error()}
+			}
+		}
+		return item, d.row, nil
+	}
+}
+
+// Close 释放读取时占用的资源
+func (d *Decoder[T]) Close() error {
+	return d.r.Close()
+}
+
+func isBlank(record []string) bool {
+	for _, s := range record {
+		if strings.TrimSpace(s) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+type csvReader struct {
+	r *csv.Reader
+}
+
+// ReadRow 使用 FieldPos 获取行号, encoding/csv 会跳过空行, 不能自行计数
+func (c *csvReader) ReadRow() ([]string, int, error) {
+	record, err := c.r.Read()
+	if err != nil {
+		return nil, 0, err
+	}
+	line, _ := c.r.FieldPos(0)
+	return record, line, nil
+}
+
+func (c *csvReader) Close() error { return nil }
+
+type xlsxReader struct {
+	file *excelize.File
+	rows *excelize.Rows
+	row  int
+}
+
+func newXLSXReader(r io.Reader) (*xlsxReader, error) {
+	f, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("tabular: 无法读取 xlsx 文件: %w", err)
+	}
+	rows, err := f.Rows(f.GetSheetName(0))
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return &xlsxReader{file: f, rows: rows}, nil
+}
+
+// ReadRow 中 excelize 的 Rows 会为中间缺失的行返回空行, 因此可以按调用次数计算行号
+func (x *xlsxReader) ReadRow() ([]string, int, error) {
+	if !x.rows.Next() {
+		if err := x.rows.Error(); err != nil {
+			return nil, 0, err
+		}
+		return nil, 0, io.EOF
+	}
+	x.row++
+	record, err := x.rows.Columns()
+	return record, x.row, err
+}
+
+func (x *xlsxReader) Close() error {
+	_ = x.rows.Close()
+	return x.file.Close()
+}
@@ -0,0 +1,141 @@
+package tabular
+
+import (
+	"encoding/csv"
+	"io"
+	"reflect"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// utf8BOM 写在 CSV 开头, 使 Excel 以 UTF-8 打开包含中文的文件
+const utf8BOM = "\ufeff"
+
+const sheetName = "Sheet1"
+
+// rowWriter 是不同格式共用的逐行写入接口
+type rowWriter interface {
+	WriteRow(values []any) error
+	Flush() error
+	Close() error
+}
+
+// Encoder 把 T 的值逐行写入表格, 第一行为表头
+type Encoder[T any] struct {
+	w    rowWriter
+	cols []column
+}
+
+// NewEncoder 创建一个写入 w 的编码器并立即写出表头。
+// CSV 边写边输出; XLSX 的行先写入 excelize 的流式临时存储, 在 Close 时输出完整文件
+func NewEncoder[T any](format Format, w io.Writer) (*Encoder[T], error) {
+	cols := columnsOf(reflect.TypeOf((*T)(nil)).Elem())
+	var rw rowWriter
+	if format == XLSX {
+		xw, err := newXLSXWriter(w)
+		if err != nil {
+			return nil, err
+		}
+		rw = xw
+	} else {
+		if _, err := io.WriteString(w, utf8BOM); err != nil {
+			return nil, err
+		}
+		rw = &csvWriter{w: csv.NewWriter(w)}
+	}
+
+	header := make([]any, len(cols))
+	for i, c := range cols {
+		header[i] = c.name
+	}
+	if err := rw.WriteRow(header); err != nil {
+		return nil, err
+	}
+	return &Encoder[T]{w: rw, cols: cols}, nil
+}
+
+// Encode 写入一行
+func (e *Encoder[T]) Encode(item T) error {
+	v := reflect.ValueOf(item)
+	for v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	values := make([]any, len(e.cols))
+	for i, c := range e.cols {
+		value, err := cellValue(v.FieldByIndex(c.index))
+		if err != nil {
+			return err
+		}
+		values[i] = value
+	}
+	return e.w.WriteRow(values)
+}
+
+// Flush 把已写入的 CSV 行交给底层 Writer, 便于边查询边输出; XLSX 在 Close 之前不会输出任何内容
+func (e *Encoder[T]) Flush() error {
+	return e.w.Flush()
+}
+
+// Close 输出剩余的数据, 对 XLSX 而言是写出整个文件
+func (e *Encoder[T]) Close() error {
+	return e.w.Close()
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvWriter) WriteRow(values []any) error {
+	record := make([]string, len(values))
+	for i, v := range values {
+		record[i] = cellText(v)
+	}
+	return c.w.Write(record)
+}
+
+func (c *csvWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvWriter) Close() error {
+	return c.Flush()
+}
+
+type xlsxWriter struct {
+	out  io.Writer
+	file *excelize.File
+	sw   *excelize.StreamWriter
+	row  int
+}
+
+func newXLSXWriter(out io.Writer) (*xlsxWriter, error) {
+	f := excelize.NewFile()
+	sw, err := f.NewStreamWriter(sheetName)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return &xlsxWriter{out: out, file: f, sw: sw}, nil
+}
+
+func (x *xlsxWriter) WriteRow(values []any) error {
+	x.row++
+	cell, err := excelize.CoordinatesToCellName(1, x.row)
+	if err != nil {
+		return err
+	}
+	return x.sw.SetRow(cell, values)
+}
+
+func (x *xlsxWriter) Flush() error {
+	return nil
+}
+
+func (x *xlsxWriter) Close() error {
+	defer x.file.Close()
+	if err := x.sw.Flush(); err != nil {
+		return err
+	}
+	return x.file.Write(x.out)
+}
@@ -0,0 +1,106 @@
+package tabular
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type base struct {
+	Note string `json:"note"`
+}
+
+type record struct {
+	ID        uint      `json:"id"`
+	Title     string    `json:"title"`
+	Price     float64   `json:"price"`
+	Active    bool      `json:"active"`
+	Rating    *int      `json:"rating"`
+	CreatedAt time.Time `json:"createdAt"`
+	Tags      []string  `json:"tags"`
+	Secret    string    `json:"-"`
+	base
+}
+
+func TestRoundTrip(t *testing.T) {
+	rating := 4
+	created := time.Date(2024, 5, 1, 8, 30, 0, 0, time.UTC)
+	want := []record{
+		{ID: 1, Title: "你好, \"world\"", Price: 9.5, Active: true, Rating: &rating, CreatedAt: created, base: base{Note: "n1"}},
+		{ID: 2, Title: "second"},
+	}
+
+	for _, format := range []Format{CSV, XLSX} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			enc, err := NewEncoder[record](format, &buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, r := range want {
+				if err := enc.Encode(r); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := enc.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			dec, err := NewDecoder[record](format, &buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer dec.Close()
+			var got []record
+			for {
+				r, row, err := dec.Next()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if err != nil {
+					t.Fatalf("row %d: %v", row, err)
+				}
+				got = append(got, r)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestDecoderRowErrors(t *testing.T) {
+	src := "Title,id,unknown\nok,1,x\n\nbad,abc,y\nlast,3,z\n"
+	dec, err := NewDecoder[record](CSV, strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var titles []string
+	var rowErrs []*RowError
+	for {
+		r, _, err := dec.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var rowErr *RowError
+		if errors.As(err, &rowErr) {
+			rowErrs = append(rowErrs, rowErr)
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		titles = append(titles, r.Title)
+	}
+
+	if !reflect.DeepEqual(titles, []string{"ok", "last"}) {
+		t.Errorf("titles = %v", titles)
+	}
+	if len(rowErrs) != 1 || rowErrs[0].Row != 4 || rowErrs[0].Column != "id" {
+		t.Errorf("row errors = %+v, want one error at row 4 column id", rowErrs)
+	}
+}
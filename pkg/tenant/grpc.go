@@ -0,0 +1,24 @@
+package tenant
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// UnaryServerInterceptor 返回从 gRPC metadata 读取租户的一元拦截器, key 为 metadata 键 (不区分大小写)。
+// 请求携带租户时写入 context; 没有租户时照常调用, 由多租户仓库返回 ErrMissing,
+// 这样同一个 gRPC Server 上不按租户隔离的服务不受影响
+func UnaryServerInterceptor(key string) grpc.UnaryServerInterceptor {
+	key = strings.ToLower(key)
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(key); len(values) > 0 && values[0] != "" {
+				ctx = WithTenant(ctx, values[0])
+			}
+		}
+		return handler(ctx, req)
+	}
+}
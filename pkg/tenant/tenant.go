@@ -0,0 +1,91 @@
+// Package tenant 为生成的多租户仓库提供租户上下文:
+// 中间件把请求的租户写入上下文, 仓库通过 Scope 把每条查询限定在该租户内, 创建时通过 ID 读取租户填充实体。
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrMissing 表示上下文中没有租户。多租户仓库在这种情况下拒绝执行, 而不是退化为跨租户查询
+var ErrMissing = errors.New("tenant: 上下文中缺少租户")
+
+// ID 是租户字段支持的类型
+type ID interface {
+	~string | ~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64
+}
+
+type contextKey struct{}
+
+// WithTenant 返回携带租户的 context, 用于后台任务等不经过 HTTP 中间件的调用
+func WithTenant(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// Bind 把租户写入请求的 Locals。fiber.Ctx 本身实现了 context.Context,
+// 处理器把它传给服务和仓库后即可通过 FromContext 读取
+func Bind(c fiber.Ctx, id string) {
+	c.Locals(contextKey{}, id)
+}
+
+// FromContext 返回 ctx 中的租户
+func FromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(contextKey{}).(string)
+	return id, ok && id != ""
+}
+
+// Detach 返回只保留租户的后台 context。fiber.Ctx 在处理函数返回后会被回收,
+// 流式响应等在此之后执行的查询需要使用它
+func Detach(ctx context.Context) context.Context {
+	if id, ok := FromContext(ctx); ok {
+		return WithTenant(context.Background(), id)
+	}
+	return context.Background()
+}
+
+// Parse 读取 ctx 中的租户并转换为实体中租户字段的类型
+func Parse[T ID](ctx context.Context) (T, error) {
+	var id T
+	raw, ok := FromContext(ctx)
+	if !ok {
+		return id, ErrMissing
+	}
+	v := reflect.ValueOf(&id).Elem()
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, v.Type().Bits())
+		if err != nil {
+			return id, fmt.Errorf("tenant: 无效的租户 %q: %w", raw, err)
+		}
+		v.SetInt(n)
+	default:
+		n, err := strconv.ParseUint(raw, 10, v.Type().Bits())
+		if err != nil {
+			return id, fmt.Errorf("tenant: 无效的租户 %q: %w", raw, err)
+		}
+		v.SetUint(n)
+	}
+	return id, nil
+}
+
+// Scope 返回把查询限定在 ctx 所属租户内的 GORM scope, column 为租户字段的列名。
+// ctx 取自 db.WithContext, 其中没有租户或租户格式错误时查询以错误结束
+func Scope[T ID](column string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		id, err := Parse[T](db.Statement.Context)
+		if err != nil {
+			_ = db.AddError(err)
+			return db
+		}
+		return db.Where(clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: column}, Value: id})
+	}
+}
@@ -0,0 +1,79 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type note struct {
+	ID       uint
+	TenantID uint
+	Title    string
+}
+
+func TestScope(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&note{}); err != nil {
+		t.Fatal(err)
+	}
+	db.Create(&[]note{{TenantID: 1, Title: "a"}, {TenantID: 2, Title: "b"}})
+
+	scoped := func(ctx context.Context) *gorm.DB {
+		return db.WithContext(ctx).Scopes(Scope[uint]("tenant_id"))
+	}
+	ctx := WithTenant(context.Background(), "1")
+
+	var notes []note
+	if err := scoped(ctx).Find(&notes).Error; err != nil || len(notes) != 1 || notes[0].Title != "a" {
+		t.Fatalf("tenant 1 sees %+v, err %v", notes, err)
+	}
+	var other note
+	if err := scoped(ctx).First(&other, 2).Error; !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("cross-tenant lookup err = %v, want ErrRecordNotFound", err)
+	}
+	if res := scoped(ctx).Delete(&note{}, 2); res.Error != nil || res.RowsAffected != 0 {
+		t.Errorf("cross-tenant delete affected %d rows, err %v", res.RowsAffected, res.Error)
+	}
+	if err := scoped(context.Background()).Find(&notes).Error; !errors.Is(err, ErrMissing) {
+		t.Errorf("query without tenant err = %v, want ErrMissing", err)
+	}
+	if _, err := Parse[uint](WithTenant(context.Background(), "x")); err == nil {
+		t.Error("Parse accepted a non-numeric tenant for a uint field")
+	}
+	if id, _ := FromContext(Detach(ctx)); id != "1" {
+		t.Errorf("Detach lost the tenant, got %q", id)
+	}
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	interceptor := UnaryServerInterceptor("X-Tenant-ID")
+	call := func(ctx context.Context) (string, bool) {
+		var id string
+		var ok bool
+		_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, _ any) (any, error) {
+			id, ok = FromContext(ctx)
+			return nil, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id, ok
+	}
+
+	if id, ok := call(metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-tenant-id", "7"))); !ok || id != "7" {
+		t.Errorf("tenant from metadata = %q, %v, want 7", id, ok)
+	}
+	if _, ok := call(context.Background()); ok {
+		t.Error("request without metadata must not carry a tenant")
+	}
+}